@@ -0,0 +1,239 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package calculator is the public API to calculate french income taxes from other Go programs
+// It never prints nor reads the standard input and never changes its inputs,
+// so a Calculator can be shared between goroutines
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/tax"
+	"github.com/LucasNoga/corpos-christie/user"
+)
+
+// ErrNoScale is returned when a calculator is created without scale
+var ErrNoScale = errors.New("no tax scale")
+
+// InputError is returned when a field of the household is not valid
+type InputError struct {
+	Field   string // Name of the field not valid
+	Message string // Reason of the error
+}
+
+// Error returns the field and the reason of the error
+func (err *InputError) Error() string {
+	return fmt.Sprintf("%s %s", err.Field, err.Message)
+}
+
+// YearError is returned when the scale of a year is not available
+// errors.Is(err, config.ErrUnknownYear) is true for this error
+type YearError struct {
+	Year int // Year asked
+}
+
+// Error returns the year not available
+func (err *YearError) Error() string {
+	return fmt.Sprintf("%v: %d", config.ErrUnknownYear, err.Year)
+}
+
+// Unwrap returns config.ErrUnknownYear
+func (err *YearError) Unwrap() error {
+	return config.ErrUnknownYear
+}
+
+// Household is the input of a calculation, the amounts are in euros
+type Household struct {
+	Income          money.Money    // Taxable income (revenu net imposable)
+	IsInCouple      bool           // Declaration of a couple (married or pacsed)
+	Children        int            // Number of dependent children
+	PreviousIncomes [2]money.Money // Reference incomes of the two previous years to smooth the high income contribution
+	Credits         Credits        // Expenses giving right to tax reductions and tax credits
+}
+
+// Credits is the expenses of the household giving right to tax reductions and tax credits
+type Credits struct {
+	Donations         money.Money // Donations to general interest organisations
+	AidDonations      money.Money // Donations to organisations helping people in difficulty
+	HomeEmployment    money.Money // Expenses for the employment of a home help
+	ChildcareExpenses money.Money // Childcare expenses for children under 6
+	YoungChildren     int         // Number of children under 6 among the children
+}
+
+// Calculator calculates the taxes with the scales of several years
+type Calculator struct {
+	configs     map[int]*config.Config // Configuration of each year
+	years       []int                  // Years available from the most recent
+	defaultYear int                    // Year used when the year asked is 0
+}
+
+// New create a calculator with the scales given, the most recent year is the default year
+// returns ErrNoScale if there is no scale
+func New(scales []config.Tax) (*Calculator, error) {
+	if len(scales) == 0 {
+		return nil, ErrNoScale
+	}
+
+	var calculator = Calculator{configs: make(map[int]*config.Config, len(scales))}
+	for _, scale := range scales {
+		var tax = copyTax(scale)
+		calculator.configs[tax.Year] = &config.Config{Name: config.APP_NAME, Version: config.APP_VERSION, Tax: tax, TaxList: []config.Tax{tax}}
+		calculator.years = append(calculator.years, tax.Year)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(calculator.years)))
+	calculator.defaultYear = calculator.years[0]
+	return &calculator, nil
+}
+
+// NewDefault create a calculator with the scales embedded in the program
+func NewDefault() (*Calculator, error) {
+	scales, err := config.LoadScales("")
+	if err != nil {
+		return nil, err
+	}
+	return New(scales)
+}
+
+// NewFromConfig create a calculator with the scales of the configuration, its year is the default year
+func NewFromConfig(cfg *config.Config) (*Calculator, error) {
+	calculator, err := New(cfg.TaxList)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := calculator.configs[cfg.GetTax().Year]; ok {
+		calculator.defaultYear = cfg.GetTax().Year
+	}
+	return calculator, nil
+}
+
+// Years returns the years available from the most recent
+func (calculator *Calculator) Years() []int {
+	return append([]int(nil), calculator.years...)
+}
+
+// DefaultYear returns the year used when the year asked is 0
+func (calculator *Calculator) DefaultYear() int {
+	return calculator.defaultYear
+}
+
+// Scale returns a copy of the scale of the year, 0 is the default year
+// returns a *YearError if the year is not available
+func (calculator *Calculator) Scale(year int) (config.Tax, error) {
+	cfg, err := calculator.getConfig(year)
+	if err != nil {
+		return config.Tax{}, err
+	}
+	return copyTax(cfg.GetTax()), nil
+}
+
+// Calculate determine the tax of the household with the scale of the year, 0 is the default year
+// returns a *InputError if the household is not valid or a *YearError if the year is not available
+func (calculator *Calculator) Calculate(household Household, year int) (tax.Result, error) {
+	cfg, err := calculator.getConfig(year)
+	if err != nil {
+		return tax.Result{}, err
+	}
+	if err := household.Validate(); err != nil {
+		return tax.Result{}, err
+	}
+	return tax.CalculateTax(household.toUser(), cfg), nil
+}
+
+// CalculateReverse determine the smallest income of the household leaving the remainder after tax
+// with the scale of the year, 0 is the default year, the income of the household is ignored
+// The remainder can't be over money.MAX_AMOUNT
+// returns a *InputError if the household or the remainder is not valid or a *YearError if the year is not available
+func (calculator *Calculator) CalculateReverse(household Household, remainder money.Money, year int) (tax.Result, error) {
+	cfg, err := calculator.getConfig(year)
+	if err != nil {
+		return tax.Result{}, err
+	}
+	if remainder < 0 {
+		return tax.Result{}, &InputError{Field: "remainder", Message: "can't be negative"}
+	}
+	if remainder > money.MAX_AMOUNT {
+		return tax.Result{}, &InputError{Field: "remainder", Message: fmt.Sprintf("can't be over %s", money.MAX_AMOUNT)}
+	}
+	household.Income = money.ZERO
+	if err := household.Validate(); err != nil {
+		return tax.Result{}, err
+	}
+
+	var user = household.toUser()
+	user.Remainder = remainder
+	result, err := tax.CalculateReverseTax(user, cfg)
+	if err != nil {
+		return tax.Result{}, &InputError{Field: "remainder", Message: fmt.Sprintf("can't be reached with an income up to %s", money.MAX_AMOUNT)}
+	}
+	return result, nil
+}
+
+// Validate check the fields of the household, the amounts are between 0 and money.MAX_AMOUNT
+// returns a *InputError with the first field not valid
+func (household Household) Validate() error {
+	var amounts = []struct {
+		field  string
+		amount money.Money
+	}{
+		{"income", household.Income},
+		{"previous_incomes", household.PreviousIncomes[0]},
+		{"previous_incomes", household.PreviousIncomes[1]},
+		{"donations", household.Credits.Donations},
+		{"aid_donations", household.Credits.AidDonations},
+		{"home_employment", household.Credits.HomeEmployment},
+		{"childcare_expenses", household.Credits.ChildcareExpenses},
+	}
+	for _, value := range amounts {
+		if value.amount < 0 {
+			return &InputError{Field: value.field, Message: "can't be negative"}
+		}
+		if value.amount > money.MAX_AMOUNT {
+			return &InputError{Field: value.field, Message: fmt.Sprintf("can't be over %s", money.MAX_AMOUNT)}
+		}
+	}
+
+	if household.Children < 0 || household.Children > user.MAX_CHILDREN {
+		return &InputError{Field: "children", Message: fmt.Sprintf("should be between 0 and %d", user.MAX_CHILDREN)}
+	}
+	if household.Credits.YoungChildren < 0 || household.Credits.YoungChildren > household.Children {
+		return &InputError{Field: "young_children", Message: fmt.Sprintf("should be between 0 and the number of children %d", household.Children)}
+	}
+	return nil
+}
+
+// getConfig returns the configuration of the year, 0 is the default year
+func (calculator *Calculator) getConfig(year int) (*config.Config, error) {
+	if year == 0 {
+		year = calculator.defaultYear
+	}
+	cfg, ok := calculator.configs[year]
+	if !ok {
+		return nil, &YearError{Year: year}
+	}
+	return cfg, nil
+}
+
+// toUser convert the household into the user used by the tax package
+func (household Household) toUser() user.User {
+	return user.User{
+		Income:          household.Income,
+		IsInCouple:      household.IsInCouple,
+		Children:        household.Children,
+		PreviousIncomes: household.PreviousIncomes,
+		Credits:         user.Credits(household.Credits),
+	}
+}
+
+// copyTax returns a copy of the scale which doesn't share its lists with the original
+func copyTax(tax config.Tax) config.Tax {
+	tax.Tranches = append([]config.Tranche(nil), tax.Tranches...)
+	tax.Withholding = append([]config.Tranche(nil), tax.Withholding...)
+	tax.HighIncome.Single = append([]config.Tranche(nil), tax.HighIncome.Single...)
+	tax.HighIncome.Couple = append([]config.Tranche(nil), tax.HighIncome.Couple...)
+	return tax
+}
@@ -0,0 +1,151 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package calculator is the public API to calculate french income taxes from other Go programs
+package calculator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd calculator
+// $ go test -v
+
+// newTestCalculator create the calculator with the embedded scales
+func newTestCalculator(t *testing.T) *Calculator {
+	calculator, err := NewDefault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return calculator
+}
+
+// Calculate the tax of a couple with 2 children in 2023 with the details of each tranche
+func TestCalculate(t *testing.T) {
+	var calculator = newTestCalculator(t)
+	var household = Household{Income: money.Euros(60000), IsInCouple: true, Children: 2}
+
+	result, err := calculator.Calculate(household, 2023)
+	t.Logf("Function result:\t%+v", result)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Year != 2023 || result.Tax != money.Euros(3043) || result.Shares != 3 {
+		t.Errorf("Expected that the Tax %s should be equal to %s", colors.Red(money.Euros(3043)), colors.Red(result.Tax))
+	}
+	if result.TaxTranches[1].Tranche.Rate != "11%" || result.TaxTranches[1].Tax != money.Cents(304359) {
+		t.Errorf("Expected that the tranche %+v should be at 11%%", result.TaxTranches[1])
+	}
+}
+
+// Calculate with the year 0 uses the most recent year
+func TestCalculateDefaultYear(t *testing.T) {
+	var calculator = newTestCalculator(t)
+
+	result, err := calculator.Calculate(Household{Income: money.Euros(30000)}, 0)
+	if err != nil || result.Year != calculator.DefaultYear() || result.Year != calculator.Years()[0] {
+		t.Errorf("Expected the year %s, got %s %v", colors.Red(calculator.Years()[0]), colors.Red(result.Year), err)
+	}
+}
+
+// The largest income calculates the tax of the next 1000 € without overflow
+func TestCalculateMaxIncome(t *testing.T) {
+	var calculator = newTestCalculator(t)
+
+	result, err := calculator.Calculate(Household{Income: money.MAX_AMOUNT}, 2023)
+	if err != nil || result.NextIncomeCost < 0 || result.NextIncomeCost > money.Euros(1000) {
+		t.Errorf("Expected the tax of the next 1000 € between 0 and 1000 €, got %s %v", colors.Red(result.NextIncomeCost), err)
+	}
+}
+
+// Estimate the income leaving the remainder
+func TestCalculateReverse(t *testing.T) {
+	var calculator = newTestCalculator(t)
+
+	result, err := calculator.CalculateReverse(Household{}, money.Euros(40000), 2024)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Remainder < money.Euros(40000) || result.Remainder >= money.Euros(40001) {
+		t.Errorf("Expected that the Remainder %s should be equal to %s", colors.Red(money.Euros(40000)), colors.Red(result.Remainder))
+	}
+}
+
+// A year not available returns a *YearError which is a config.ErrUnknownYear
+func TestCalculateUnknownYear(t *testing.T) {
+	var calculator = newTestCalculator(t)
+
+	_, err := calculator.Calculate(Household{Income: money.Euros(30000)}, 1990)
+	var yearError *YearError
+	if !errors.As(err, &yearError) || yearError.Year != 1990 || !errors.Is(err, config.ErrUnknownYear) {
+		t.Errorf("Expected a YearError, got %s", colors.Red(err))
+	}
+
+	if _, err := calculator.Scale(1990); !errors.Is(err, config.ErrUnknownYear) {
+		t.Errorf("Expected a YearError, got %s", colors.Red(err))
+	}
+}
+
+// An invalid household returns a *InputError with the field
+func TestCalculateInvalidHousehold(t *testing.T) {
+	var calculator = newTestCalculator(t)
+	var tests = []struct {
+		household Household
+		field     string
+	}{
+		{Household{Income: money.Euros(-1)}, "income"},
+		{Household{Income: money.MAX_AMOUNT + 1}, "income"},
+		{Household{Income: money.MAX - 100}, "income"},
+		{Household{Income: money.Euros(30000), Credits: Credits{Donations: money.MAX_AMOUNT + 1}}, "donations"},
+		{Household{Income: money.Euros(30000), PreviousIncomes: [2]money.Money{0, money.MAX_AMOUNT + 1}}, "previous_incomes"},
+		{Household{Children: -1}, "children"},
+		{Household{Children: user.MAX_CHILDREN + 1}, "children"},
+		{Household{Children: 1000000000}, "children"},
+		{Household{Children: 1, Credits: Credits{YoungChildren: 2}}, "young_children"},
+		{Household{PreviousIncomes: [2]money.Money{money.Euros(-1), 0}}, "previous_incomes"},
+	}
+
+	for _, test := range tests {
+		_, err := calculator.Calculate(test.household, 0)
+		var inputError *InputError
+		if !errors.As(err, &inputError) || inputError.Field != test.field {
+			t.Errorf("Expected an InputError on %s, got %s", colors.Red(test.field), colors.Red(err))
+		}
+	}
+
+	for _, remainder := range []money.Money{money.Euros(-1), money.MAX_AMOUNT + 1, money.MAX_AMOUNT} {
+		_, err := calculator.CalculateReverse(Household{}, remainder, 0)
+		var inputError *InputError
+		if !errors.As(err, &inputError) || inputError.Field != "remainder" {
+			t.Errorf("Expected an InputError on %s for %s, got %s", colors.Red("remainder"), remainder, colors.Red(err))
+		}
+	}
+}
+
+// The scale returned is a copy which can't change the calculations
+func TestScaleIsACopy(t *testing.T) {
+	var calculator = newTestCalculator(t)
+
+	scale, _ := calculator.Scale(2024)
+	scale.Tranches[1].Rate = "99%"
+
+	result, _ := calculator.Calculate(Household{Income: money.Euros(30000)}, 2024)
+	if result.Tax != money.Euros(2286) {
+		t.Errorf("Expected that the Tax %s should be equal to %s", colors.Red(money.Euros(2286)), colors.Red(result.Tax))
+	}
+}
+
+// A calculator needs at least a scale
+func TestNewWithoutScale(t *testing.T) {
+	if _, err := New(nil); !errors.Is(err, ErrNoScale) {
+		t.Errorf("Expected the error %s, got %s", colors.Red(ErrNoScale), colors.Red(err))
+	}
+}
@@ -5,13 +5,16 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"math"
 
+	"github.com/LucasNoga/corpos-christie/money"
 	"github.com/LucasNoga/corpos-christie/utils"
-	"github.com/LucasNoga/corpos-christie/utils/colors"
 )
 
+// ErrUnknownYear is returned when the metrics of a year are not on the list
+var ErrUnknownYear = errors.New("tax year is not on the list")
+
 // Config represents the configuration of the program with the tax metrics
 type Config struct {
 	Name    string
@@ -23,110 +26,123 @@ type Config struct {
 // Tax represent the metrics of french tax in a specific year
 // This metrics are called 'tranche'
 type Tax struct {
-	Year     int       // Year of the tax specifications
-	Tranches []Tranche // List of Tranches
+	Year        int         // Year of the tax specifications
+	Source      string      // Reference of the law defining the metrics
+	Tranches    []Tranche   // List of Tranches
+	QuotientCap QuotientCap // Ceilings of the family quotient benefit (plafonnement du quotient familial)
+	Decote      Decote      // Discount on tax for low incomes (décote)
+	HighIncome  HighIncome  // Contribution on high incomes (contribution exceptionnelle sur les hauts revenus)
+	Credits     Credits     // Parameters of tax reductions and tax credits (réductions et crédits d'impôt)
+	Withholding []Tranche   // Monthly grid of the neutral rate of withholding tax (grille du taux neutre)
+	Salary      Salary      // Rates to convert a gross salary into a taxable income
 }
 
 // Tranche is a unit to define several metrics to calculate tax
 type Tranche struct {
-	Min  int    // Minimun in euros to get in the tranche
-	Max  int    // Maximum in euros to get in the tranche
-	Rate string // Rate taxable in euros in this tranche
+	Min  money.Money // Minimun in euros to get in the tranche
+	Max  money.Money // Maximum in euros to get in the tranche
+	Rate string      // Rate taxable in euros in this tranche
+}
+
+// QuotientCap defines the maximum tax reduction given by the extra shares of the family quotient
+// The extra shares are the ones added to the shares of the declarants (1 if single, 2 if couple)
+type QuotientCap struct {
+	HalfShare      money.Money // Maximum benefit in euros for each extra half-share
+	IsolatedParent money.Money // Maximum benefit in euros for the two first extra half-shares of an isolated parent (case T)
+}
+
+// Decote defines the discount on tax for low incomes (décote)
+// The discount is the threshold minus the rate applied on the tax
+type Decote struct {
+	SingleThreshold money.Money // Threshold in euros for a single declaration
+	CoupleThreshold money.Money // Threshold in euros for a couple declaration
+	Rate            string      // Rate of the tax to substract from the threshold
+}
+
+// HighIncome defines the scales of the contribution on high incomes (CEHR)
+// The contribution is calculated on the reference income (revenu fiscal de référence)
+type HighIncome struct {
+	Single []Tranche // Tranches for a single declaration
+	Couple []Tranche // Tranches for a couple declaration
+}
+
+// Credits defines the parameters of the tax reductions and tax credits
+type Credits struct {
+	Donation       Credit // Donations to general interest organisations (dons aux oeuvres)
+	AidDonation    Credit // Donations to organisations helping people in difficulty (dons aux organismes d'aide)
+	HomeEmployment Credit // Employment of a home help (emploi d'un salarié à domicile)
+	Childcare      Credit // Childcare expenses for children under 6 (frais de garde des jeunes enfants)
 }
 
-// New create new configuration
-func New() *Config {
+// Credit defines the rate and the ceilings of a tax reduction or a tax credit
+type Credit struct {
+	Rate          string      // Rate applied on the expenses retained
+	Ceiling       money.Money // Ceiling in euros of the expenses
+	IncomeCeiling string      // Ceiling of the expenses as a rate of the taxable income (empty if none)
+	ExtraCeiling  money.Money // Increase in euros of the ceiling for each child
+	MaxCeiling    money.Money // Maximum in euros of the ceiling with its increases (0 if none)
+	CarryForward  int         // Number of years the expenses over the ceiling can be carried forward
+	Refundable    bool        // True for a tax credit refunded when greater than the tax, false for a reduction
+}
+
+// Salary defines the rates to convert a gross salary into a taxable income
+type Salary struct {
+	NonExecutiveRate string    // Rate of employee social contributions without CSG and CRDS for a non-executive (non-cadre)
+	ExecutiveRate    string    // Rate of employee social contributions without CSG and CRDS for an executive (cadre)
+	CSGBase          string    // Rate of the gross salary subject to CSG and CRDS (assiette)
+	DeductibleCSG    string    // Rate of the deductible CSG
+	NonDeductibleCSG string    // Rate of the non-deductible CSG
+	CRDS             string    // Rate of the CRDS, non-deductible
+	Allowance        Allowance // Allowance for professional expenses (abattement de 10%)
+}
+
+// Allowance defines the allowance for professional expenses deducted from the net taxable salary
+type Allowance struct {
+	Rate string      // Rate of the net taxable salary
+	Min  money.Money // Minimum in euros of the allowance
+	Max  money.Money // Maximum in euros of the allowance
+}
+
+// New create new configuration with the tax scales embedded and the ones of the user directory
+// returns an error if a scale is not valid or if there is no scale
+func New() (*Config, error) {
 	var config = Config{
 		Name:    APP_NAME,
 		Version: APP_VERSION,
-		TaxList: []Tax{
-			{
-				Year: 2024,
-				Tranches: []Tranche{
-
-					{Min: 0, Max: 11294, Rate: "0%"},
-					{Min: 11295, Max: 28797, Rate: "11%"},
-					{Min: 28798, Max: 82341, Rate: "30%"},
-					{Min: 82342, Max: 177106, Rate: "41%"},
-					{Min: 177107, Max: math.MaxInt64, Rate: "45%"},
-				},
-			},
-			{
-				Year: 2023,
-				Tranches: []Tranche{
-
-					{Min: 0, Max: 10777, Rate: "0%"},
-					{Min: 10778, Max: 27478, Rate: "11%"},
-					{Min: 27479, Max: 78570, Rate: "30%"},
-					{Min: 78571, Max: 168994, Rate: "41%"},
-					{Min: 168995, Max: math.MaxInt64, Rate: "45%"},
-				},
-			},
-			{
-				Year: 2022,
-				Tranches: []Tranche{
-
-					{Min: 0, Max: 10225, Rate: "0%"},
-					{Min: 10226, Max: 26070, Rate: "11%"},
-					{Min: 26071, Max: 74545, Rate: "30%"},
-					{Min: 74546, Max: 160336, Rate: "41%"},
-					{Min: 160337, Max: math.MaxInt64, Rate: "45%"},
-				},
-			},
-			{
-				Year: 2021,
-				Tranches: []Tranche{
-					{Min: 0, Max: 10084, Rate: "0%"},
-					{Min: 10085, Max: 25710, Rate: "11%"},
-					{Min: 25711, Max: 73516, Rate: "30%"},
-					{Min: 73517, Max: 158122, Rate: "41%"},
-					{Min: 158123, Max: math.MaxInt64, Rate: "45%"},
-				},
-			},
-			{
-				Year: 2020,
-				Tranches: []Tranche{
-					{Min: 0, Max: 10064, Rate: "0%"},
-					{Min: 10065, Max: 25659, Rate: "11%"},
-					{Min: 25660, Max: 73369, Rate: "30%"},
-					{Min: 73370, Max: 157806, Rate: "41%"},
-					{Min: 157807, Max: math.MaxInt64, Rate: "45%"},
-				},
-			},
-			{
-				Year: 2019,
-				Tranches: []Tranche{
-					{Min: 0, Max: 10064, Rate: "0%"},
-					{Min: 10065, Max: 27794, Rate: "14%"},
-					{Min: 27795, Max: 74517, Rate: "30%"},
-					{Min: 74518, Max: 157806, Rate: "41%"},
-					{Min: 157807, Max: math.MaxInt64, Rate: "45%"},
-				},
-			},
-		},
 	}
 
+	taxList, err := LoadScales(GetUserScalesPath())
+	if err != nil {
+		return nil, err
+	}
+	config.TaxList = taxList
+
 	// set tax list of current year
-	config.loadTaxYear()
+	if err := config.loadTaxYear(); err != nil {
+		return nil, err
+	}
 
-	return &config
+	return &config, nil
 }
 
 // loadTaxYear set a default tax metrics among the year of tax metrics set in cfg Config
 // If we have the metrics of current year we set this
-// If not we set the last tax metrics present in the cfg Config
-func (cfg *Config) loadTaxYear() {
+// If not we set the most recent tax metrics present in the cfg Config
+// returns an error if there is no tax metrics
+func (cfg *Config) loadTaxYear() error {
+	if len(cfg.TaxList) == 0 {
+		return fmt.Errorf("%w: no scale loaded", ErrInvalidScale)
+	}
+
+	// TaxList is sorted from the most recent year
+	cfg.Tax = cfg.TaxList[0]
 	for _, tax := range cfg.TaxList {
 		if tax.Year == utils.GetCurrentYear() { // get tax of current year
 			cfg.Tax = tax
 			break
 		}
 	}
-
-	// If no tax tranches are defined from current year load default tax 2021
-	if len(cfg.Tax.Tranches) == 0 {
-		cfg.Tax = cfg.TaxList[0]
-	}
+	return nil
 }
 
 // GetTax returns the Tax metrics to calculate tax of user
@@ -134,14 +150,13 @@ func (cfg *Config) GetTax() Tax {
 	return cfg.Tax
 }
 
-// ChangeTax get in Taxlist of cfg the metrics of the year wished
-func (cfg *Config) ChangeTax(year int) {
+// FindTax get in TaxList of cfg the metrics of the year wished
+// returns an error if the year is not on the list
+func (cfg *Config) FindTax(year int) (Tax, error) {
 	for _, tax := range cfg.TaxList {
 		if tax.Year == year {
-			cfg.Tax = tax
-			return
+			return tax, nil
 		}
 	}
-	fmt.Printf(colors.Red("%d is not on the list\n"), year)
-	fmt.Printf(colors.Red("Get default tax year: %d\n"), cfg.GetTax().Year)
+	return Tax{}, fmt.Errorf("%w: %d", ErrUnknownYear, year)
 }
@@ -19,4 +19,23 @@ const (
 	ASSETS_PATH    string = "resources/assets"    // Path to assets folder
 	LOGS_PATH      string = "logs/log.json"       // Path of the logs
 	SETTINGS_PATH  string = ".settings.json"      // Path of GUI settings
+	SCALES_PATH    string = "scales"              // Path of the tax scale files, embedded and in the user config directory
+	PROFILES_PATH  string = "profiles"            // Path of the household profiles in the user config directory
+)
+
+// Profiles
+const (
+	RECENT_PROFILES int = 5 // Number of profiles opened recently kept in GUI settings
+)
+
+// Tax
+const (
+	NEXT_INCOME     int = 1000 // Extra income in euros to calculate the tax on the next income
+	RANGE_MAX_STEPS int = 1000 // Maximum number of incomes simulated in a range
+)
+
+// Server
+const (
+	SERVER_ADDRESS  string = "localhost:8080" // Default address of the HTTP API server
+	SERVER_MAX_BODY int64  = 1 << 20          // Maximum size in bytes of the body of a request
 )
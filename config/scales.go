@@ -0,0 +1,418 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package config define the loading of configuration of the program
+package config
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/LucasNoga/corpos-christie/money"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SCALE_SCHEMA_VERSION is the version of the format of the scale files read by the program
+const SCALE_SCHEMA_VERSION int = 1
+
+// Limits of the values of a scale file
+const (
+	MAX_SCALE_AMOUNT int64      = int64(money.MAX_AMOUNT / 100) // Largest amount in euros
+	MAX_SCALE_RATE   money.Rate = 100 * 100                     // Largest rate, 100%
+)
+
+// ErrInvalidScale is returned when a scale file can't be read or is not valid
+var ErrInvalidScale = errors.New("invalid tax scale")
+
+// embeddedScales are the default scales shipped with the program
+//
+//go:embed scales/*.yaml
+var embeddedScales embed.FS
+
+// scaleFile is the content of a scale file in YAML or JSON, amounts are in euros
+type scaleFile struct {
+	SchemaVersion int             `yaml:"schema_version"`
+	Year          int             `yaml:"year"`
+	Source        string          `yaml:"source"`
+	Tranches      []trancheFile   `yaml:"tranches"`
+	QuotientCap   quotientCapFile `yaml:"quotient_cap"`
+	Decote        decoteFile      `yaml:"decote"`
+	HighIncome    highIncomeFile  `yaml:"high_income"`
+	Credits       creditsFile     `yaml:"credits"`
+	Withholding   []trancheFile   `yaml:"withholding"`
+	Salary        salaryFile      `yaml:"salary"`
+}
+
+// trancheFile is a tranche of a scale file, the last tranche has no max
+type trancheFile struct {
+	Min  int    `yaml:"min"`
+	Max  *int   `yaml:"max"`
+	Rate string `yaml:"rate"`
+}
+
+// quotientCapFile is the ceilings of the family quotient of a scale file
+type quotientCapFile struct {
+	HalfShare      int `yaml:"half_share"`
+	IsolatedParent int `yaml:"isolated_parent"`
+}
+
+// decoteFile is the discount for low incomes of a scale file
+type decoteFile struct {
+	SingleThreshold int    `yaml:"single_threshold"`
+	CoupleThreshold int    `yaml:"couple_threshold"`
+	Rate            string `yaml:"rate"`
+}
+
+// highIncomeFile is the contribution on high incomes of a scale file
+type highIncomeFile struct {
+	Single []trancheFile `yaml:"single"`
+	Couple []trancheFile `yaml:"couple"`
+}
+
+// creditsFile is the tax reductions and tax credits of a scale file
+type creditsFile struct {
+	Donation       creditFile `yaml:"donation"`
+	AidDonation    creditFile `yaml:"aid_donation"`
+	HomeEmployment creditFile `yaml:"home_employment"`
+	Childcare      creditFile `yaml:"childcare"`
+}
+
+// creditFile is a tax reduction or a tax credit of a scale file
+type creditFile struct {
+	Rate          string `yaml:"rate"`
+	Ceiling       int    `yaml:"ceiling"`
+	IncomeCeiling string `yaml:"income_ceiling"`
+	ExtraCeiling  int    `yaml:"extra_ceiling"`
+	MaxCeiling    int    `yaml:"max_ceiling"`
+	CarryForward  int    `yaml:"carry_forward"`
+	Refundable    bool   `yaml:"refundable"`
+}
+
+// salaryFile is the rates to convert a gross salary of a scale file
+type salaryFile struct {
+	NonExecutiveRate string        `yaml:"non_executive_rate"`
+	ExecutiveRate    string        `yaml:"executive_rate"`
+	CSGBase          string        `yaml:"csg_base"`
+	DeductibleCSG    string        `yaml:"deductible_csg"`
+	NonDeductibleCSG string        `yaml:"non_deductible_csg"`
+	CRDS             string        `yaml:"crds"`
+	Allowance        allowanceFile `yaml:"allowance"`
+}
+
+// allowanceFile is the allowance for professional expenses of a scale file
+type allowanceFile struct {
+	Rate string `yaml:"rate"`
+	Min  int    `yaml:"min"`
+	Max  int    `yaml:"max"`
+}
+
+// GetUserScalesPath returns the directory where the user can add scale files
+// to override the embedded scales or add new years (ex: ~/.config/corpos-christie/scales)
+func GetUserScalesPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, APP_NAME, SCALES_PATH)
+}
+
+// LoadScales load the embedded scales then the scales of the user directory
+// A scale of the user directory overrides the embedded scale of the same year
+// returns the scales sorted from the most recent year
+// returns an error if a scale file is not valid
+func LoadScales(userPath string) ([]Tax, error) {
+	scales, err := loadScalesFS(embeddedScales, SCALES_PATH)
+	if err != nil {
+		return nil, err
+	}
+
+	// The user directory is optional
+	if userPath != "" {
+		if info, err := os.Stat(userPath); err == nil && info.IsDir() {
+			userScales, err := loadScalesFS(os.DirFS(userPath), ".")
+			if err != nil {
+				return nil, err
+			}
+			for year, tax := range userScales {
+				scales[year] = tax
+			}
+		}
+	}
+
+	var list = make([]Tax, 0, len(scales))
+	for _, tax := range scales {
+		list = append(list, tax)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Year > list[j].Year })
+	return list, nil
+}
+
+// loadScalesFS read every YAML or JSON scale file of the directory
+// returns the scales by year
+func loadScalesFS(fsys fs.FS, dir string) (map[int]Tax, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading directory %s: %v", ErrInvalidScale, dir, err)
+	}
+
+	var scales = make(map[int]Tax, len(entries))
+	var names = make(map[int]string, len(entries))
+	for _, entry := range entries {
+		var ext = strings.ToLower(path.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("%w: reading %s: %v", ErrInvalidScale, entry.Name(), err)
+		}
+		tax, err := ParseScale(entry.Name(), data)
+		if err != nil {
+			return nil, err
+		}
+		if name, ok := names[tax.Year]; ok {
+			return nil, fmt.Errorf("%w: %s: year %d is already defined in %s", ErrInvalidScale, entry.Name(), tax.Year, name)
+		}
+		scales[tax.Year] = tax
+		names[tax.Year] = entry.Name()
+	}
+	return scales, nil
+}
+
+// ParseScale read a scale file in YAML or JSON (JSON is read as YAML) and validate it
+// returns the tax metrics of the scale
+// returns an error wrapping ErrInvalidScale if the file is not valid
+func ParseScale(name string, data []byte) (Tax, error) {
+	var file scaleFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return Tax{}, fmt.Errorf("%w: %s: %v", ErrInvalidScale, name, err)
+	}
+	if err := file.validate(); err != nil {
+		return Tax{}, fmt.Errorf("%w: %s: %v", ErrInvalidScale, name, err)
+	}
+	return file.toTax(), nil
+}
+
+// validate check the schema version, the year, all the tranches, the ceilings of the family quotient
+// and the discount of the scale file
+// returns the first error found
+func (file scaleFile) validate() error {
+	if file.SchemaVersion != SCALE_SCHEMA_VERSION {
+		return fmt.Errorf("schema version %d is not supported, expected %d", file.SchemaVersion, SCALE_SCHEMA_VERSION)
+	}
+	if file.Year <= 0 {
+		return errors.New("year is missing")
+	}
+	if len(file.Tranches) == 0 {
+		return errors.New("tranches are missing")
+	}
+
+	var sections = []struct {
+		name     string
+		tranches []trancheFile
+	}{
+		{"tranches", file.Tranches},
+		{"high_income.single", file.HighIncome.Single},
+		{"high_income.couple", file.HighIncome.Couple},
+		{"withholding", file.Withholding},
+	}
+	for _, section := range sections {
+		if err := validateTranches(section.tranches); err != nil {
+			return fmt.Errorf("%s: %v", section.name, err)
+		}
+	}
+
+	var amounts = []struct {
+		name  string
+		value int
+	}{
+		{"quotient_cap.half_share", file.QuotientCap.HalfShare},
+		{"quotient_cap.isolated_parent", file.QuotientCap.IsolatedParent},
+		{"decote.single_threshold", file.Decote.SingleThreshold},
+		{"decote.couple_threshold", file.Decote.CoupleThreshold},
+	}
+	for _, amount := range amounts {
+		if amount.value <= 0 {
+			return fmt.Errorf("%s is missing or not positive", amount.name)
+		}
+	}
+	if rate, err := money.ParseRate(file.Decote.Rate); err != nil || rate <= 0 {
+		return fmt.Errorf("decote.rate %q is missing or not a positive percentage", file.Decote.Rate)
+	}
+
+	var optionalAmounts = []struct {
+		name  string
+		value int
+	}{
+		{"credits.donation.ceiling", file.Credits.Donation.Ceiling},
+		{"credits.aid_donation.ceiling", file.Credits.AidDonation.Ceiling},
+		{"credits.home_employment.ceiling", file.Credits.HomeEmployment.Ceiling},
+		{"credits.home_employment.extra_ceiling", file.Credits.HomeEmployment.ExtraCeiling},
+		{"credits.home_employment.max_ceiling", file.Credits.HomeEmployment.MaxCeiling},
+		{"credits.childcare.ceiling", file.Credits.Childcare.Ceiling},
+		{"salary.allowance.min", file.Salary.Allowance.Min},
+		{"salary.allowance.max", file.Salary.Allowance.Max},
+	}
+	for _, amount := range append(amounts, optionalAmounts...) {
+		if err := validateAmount(amount.value); err != nil {
+			return fmt.Errorf("%s: %v", amount.name, err)
+		}
+	}
+
+	var rates = []string{file.Decote.Rate, file.Credits.Donation.Rate, file.Credits.Donation.IncomeCeiling, file.Credits.AidDonation.Rate,
+		file.Credits.HomeEmployment.Rate, file.Credits.Childcare.Rate, file.Salary.NonExecutiveRate, file.Salary.ExecutiveRate,
+		file.Salary.CSGBase, file.Salary.DeductibleCSG, file.Salary.NonDeductibleCSG, file.Salary.CRDS, file.Salary.Allowance.Rate}
+	for _, rate := range rates {
+		if rate == "" {
+			continue
+		}
+		if err := validateRate(rate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateAmount check an amount in euros of a scale file is between 0 and MAX_SCALE_AMOUNT
+// so it can be converted in cents without overflow
+func validateAmount(euros int) error {
+	if euros < 0 || int64(euros) > MAX_SCALE_AMOUNT {
+		return fmt.Errorf("amount %d should be between 0 and %d", euros, MAX_SCALE_AMOUNT)
+	}
+	return nil
+}
+
+// validateRate check a rate of a scale file is a percentage between 0 and MAX_SCALE_RATE
+func validateRate(rate string) error {
+	value, err := money.ParseRate(rate)
+	if err != nil {
+		return fmt.Errorf("rate %q is not a percentage", rate)
+	}
+	if value < 0 || value > MAX_SCALE_RATE {
+		return fmt.Errorf("rate %q should be between 0%% and 100%%", rate)
+	}
+	return nil
+}
+
+// validateTranches check the tranches start at 0, are contiguous and increasing
+// with rates between 0 and 100% and only the last tranche is open-ended
+// returns an error describing the first invalid tranche
+func validateTranches(tranches []trancheFile) error {
+	for index, tranche := range tranches {
+		var number = index + 1
+
+		if !strings.HasSuffix(tranche.Rate, "%") {
+			return fmt.Errorf("tranche %d: rate %q is not a percentage", number, tranche.Rate)
+		}
+		if err := validateRate(tranche.Rate); err != nil {
+			return fmt.Errorf("tranche %d: %v", number, err)
+		}
+		if err := validateAmount(tranche.Min); err != nil {
+			return fmt.Errorf("tranche %d: min: %v", number, err)
+		}
+		if tranche.Max != nil {
+			if err := validateAmount(*tranche.Max); err != nil {
+				return fmt.Errorf("tranche %d: max: %v", number, err)
+			}
+		}
+
+		if index == 0 && tranche.Min != 0 {
+			return fmt.Errorf("tranche %d: min %d should be 0", number, tranche.Min)
+		}
+		if index > 0 {
+			var previous = tranches[index-1]
+			if previous.Max != nil && tranche.Min != *previous.Max+1 {
+				return fmt.Errorf("tranche %d: min %d should be %d to follow the previous tranche", number, tranche.Min, *previous.Max+1)
+			}
+		}
+
+		var isLast = index == len(tranches)-1
+		if isLast && tranche.Max != nil {
+			return fmt.Errorf("tranche %d: the last tranche should have no max", number)
+		}
+		if !isLast && tranche.Max == nil {
+			return fmt.Errorf("tranche %d: only the last tranche can have no max", number)
+		}
+		if !isLast && *tranche.Max <= tranche.Min {
+			return fmt.Errorf("tranche %d: max %d should be greater than min %d", number, *tranche.Max, tranche.Min)
+		}
+	}
+	return nil
+}
+
+// toTax convert the scale file into tax metrics with amounts in money
+func (file scaleFile) toTax() Tax {
+	return Tax{
+		Year:     file.Year,
+		Source:   file.Source,
+		Tranches: toTranches(file.Tranches),
+		QuotientCap: QuotientCap{
+			HalfShare:      money.Euros(file.QuotientCap.HalfShare),
+			IsolatedParent: money.Euros(file.QuotientCap.IsolatedParent),
+		},
+		Decote: Decote{
+			SingleThreshold: money.Euros(file.Decote.SingleThreshold),
+			CoupleThreshold: money.Euros(file.Decote.CoupleThreshold),
+			Rate:            file.Decote.Rate,
+		},
+		HighIncome: HighIncome{
+			Single: toTranches(file.HighIncome.Single),
+			Couple: toTranches(file.HighIncome.Couple),
+		},
+		Credits: Credits{
+			Donation:       file.Credits.Donation.toCredit(),
+			AidDonation:    file.Credits.AidDonation.toCredit(),
+			HomeEmployment: file.Credits.HomeEmployment.toCredit(),
+			Childcare:      file.Credits.Childcare.toCredit(),
+		},
+		Withholding: toTranches(file.Withholding),
+		Salary: Salary{
+			NonExecutiveRate: file.Salary.NonExecutiveRate,
+			ExecutiveRate:    file.Salary.ExecutiveRate,
+			CSGBase:          file.Salary.CSGBase,
+			DeductibleCSG:    file.Salary.DeductibleCSG,
+			NonDeductibleCSG: file.Salary.NonDeductibleCSG,
+			CRDS:             file.Salary.CRDS,
+			Allowance: Allowance{
+				Rate: file.Salary.Allowance.Rate,
+				Min:  money.Euros(file.Salary.Allowance.Min),
+				Max:  money.Euros(file.Salary.Allowance.Max),
+			},
+		},
+	}
+}
+
+// toTranches convert the tranches of a scale file, the last tranche without max ends at money.MAX
+func toTranches(tranches []trancheFile) []Tranche {
+	var list = make([]Tranche, 0, len(tranches))
+	for _, tranche := range tranches {
+		var max = money.MAX
+		if tranche.Max != nil {
+			max = money.Euros(*tranche.Max)
+		}
+		list = append(list, Tranche{Min: money.Euros(tranche.Min), Max: max, Rate: tranche.Rate})
+	}
+	return list
+}
+
+// toCredit convert a tax reduction or a tax credit of a scale file
+func (credit creditFile) toCredit() Credit {
+	return Credit{
+		Rate:          credit.Rate,
+		Ceiling:       money.Euros(credit.Ceiling),
+		IncomeCeiling: credit.IncomeCeiling,
+		ExtraCeiling:  money.Euros(credit.ExtraCeiling),
+		MaxCeiling:    money.Euros(credit.MaxCeiling),
+		CarryForward:  credit.CarryForward,
+		Refundable:    credit.Refundable,
+	}
+}
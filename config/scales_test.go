@@ -0,0 +1,165 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package config define the loading of configuration of the program
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd config
+// $ go test -v
+
+// validScale is a minimal scale file
+const validScale = `
+schema_version: 1
+year: 2030
+source: Test
+tranches:
+  - { min: 0, max: 10000, rate: "0%" }
+  - { min: 10001, max: 30000, rate: "11%" }
+  - { min: 30001, rate: "30%" }
+quotient_cap:
+  half_share: 1600
+  isolated_parent: 3800
+decote:
+  single_threshold: 800
+  couple_threshold: 1300
+  rate: "45.25%"
+`
+
+// Load the embedded scales sorted from the most recent year
+func TestLoadEmbeddedScales(t *testing.T) {
+	scales, err := LoadScales("")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var years []int
+	for _, tax := range scales {
+		years = append(years, tax.Year)
+	}
+	t.Logf("Function result:\t%v", years)
+
+	if len(scales) != 6 || scales[0].Year != 2024 || scales[len(scales)-1].Year != 2019 {
+		t.Errorf("Expected that the years %v should be from 2024 to 2019", colors.Red(years))
+	}
+
+	for _, tax := range scales {
+		if tax.Source == "" || len(tax.Tranches) != 5 || len(tax.Withholding) != 20 {
+			t.Errorf("Expected that the scale of %s should be complete", colors.Red(tax.Year))
+		}
+		if tax.Tranches[len(tax.Tranches)-1].Max != money.MAX {
+			t.Errorf("Expected that the last tranche of %s should be open-ended", colors.Red(tax.Year))
+		}
+	}
+}
+
+// Parse a scale in YAML and in JSON
+func TestParseScale(t *testing.T) {
+	tax, err := ParseScale("2030.yaml", []byte(validScale))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tax.Year != 2030 || tax.Tranches[1].Min != money.Euros(10001) || tax.Tranches[2].Max != money.MAX || tax.Decote.SingleThreshold != money.Euros(800) {
+		t.Errorf("Expected that the scale %+v should be read", tax)
+	}
+
+	var json = `{"schema_version": 1, "year": 2031, "source": "Test", "tranches": [{"min": 0, "max": 100, "rate": "0%"}, {"min": 101, "rate": "10%"}],
+		"quotient_cap": {"half_share": 1600, "isolated_parent": 3800}, "decote": {"single_threshold": 800, "couple_threshold": 1300, "rate": "45.25%"}}`
+	tax, err = ParseScale("2031.json", []byte(json))
+	if err != nil || tax.Year != 2031 || len(tax.Tranches) != 2 {
+		t.Errorf("Expected that the JSON scale should be read, got %+v, %v", tax, err)
+	}
+}
+
+// Invalid scales returns a clear error
+func TestParseInvalidScale(t *testing.T) {
+	var tests = []struct {
+		name     string
+		replace  string
+		by       string
+		expected string
+	}{
+		{"schema version", "schema_version: 1", "schema_version: 2", "schema version 2 is not supported"},
+		{"missing year", "year: 2030", "", "year is missing"},
+		{"first min", "{ min: 0, max: 10000", "{ min: 1, max: 10000", "tranche 1: min 1 should be 0"},
+		{"gap", "{ min: 10001,", "{ min: 10002,", "tranche 2: min 10002 should be 10001"},
+		{"decreasing", "{ min: 10001, max: 30000", "{ min: 10001, max: 9000", "tranche 2: max 9000 should be greater than min 10001"},
+		{"closed last tranche", `{ min: 30001, rate: "30%" }`, `{ min: 30001, max: 50000, rate: "30%" }`, "the last tranche should have no max"},
+		{"open tranche", `{ min: 10001, max: 30000, rate: "11%" }`, `{ min: 10001, rate: "11%" }`, "only the last tranche can have no max"},
+		{"rate", `rate: "11%"`, `rate: "eleven"`, `tranche 2: rate "eleven" is not a percentage`},
+		{"missing half share", "half_share: 1600", "", "quotient_cap.half_share is missing or not positive"},
+		{"negative isolated parent", "isolated_parent: 3800", "isolated_parent: -1", "quotient_cap.isolated_parent is missing or not positive"},
+		{"missing decote threshold", "single_threshold: 800", "", "decote.single_threshold is missing or not positive"},
+		{"zero decote threshold", "couple_threshold: 1300", "couple_threshold: 0", "decote.couple_threshold is missing or not positive"},
+		{"negative rate", `rate: "30%"`, `rate: "-45%"`, `tranche 3: rate "-45%" should be between 0% and 100%`},
+		{"rate over 100%", `rate: "11%"`, `rate: "900000000000%"`, `tranche 2: rate "900000000000%" should be between 0% and 100%`},
+		{"decote rate over 100%", `rate: "45.25%"`, `rate: "145.25%"`, `rate "145.25%" should be between 0% and 100%`},
+		{"max too high", "max: 30000", "max: 99999999999999999", "tranche 2: max: amount 99999999999999999 should be between 0 and 1000000000000"},
+		{"half share too high", "half_share: 1600", "half_share: 1000000000001", "quotient_cap.half_share: amount 1000000000001 should be between 0 and 1000000000000"},
+		{"credit rate over 100%", "source: Test", "source: Test\ncredits:\n  donation: { rate: \"166%\", ceiling: 1000 }", `rate "166%" should be between 0% and 100%`},
+		{"credit ceiling too high", "source: Test", "source: Test\ncredits:\n  childcare: { rate: \"50%\", ceiling: 99999999999999999 }", "credits.childcare.ceiling: amount 99999999999999999"},
+		{"missing decote rate", `rate: "45.25%"`, "", `decote.rate "" is missing or not a positive percentage`},
+	}
+
+	for _, test := range tests {
+		var data = strings.Replace(validScale, test.replace, test.by, 1)
+		_, err := ParseScale("2030.yaml", []byte(data))
+		if err == nil || !errors.Is(err, ErrInvalidScale) || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Expected for %s the error %s, got %s", test.name, colors.Red(test.expected), colors.Red(err))
+		}
+	}
+}
+
+// A scale of the user directory overrides the embedded scale of the same year
+func TestLoadUserScales(t *testing.T) {
+	var dir = t.TempDir()
+	var override = strings.Replace(validScale, "year: 2030", "year: 2024", 1)
+	if err := os.WriteFile(filepath.Join(dir, "2024.yaml"), []byte(override), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "2030.yaml"), []byte(validScale), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scales, err := LoadScales(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(scales) != 7 || scales[0].Year != 2030 || scales[1].Year != 2024 || scales[1].Source != "Test" {
+		t.Errorf("Expected that the user scales should be loaded first, got %s", colors.Red(scales[0].Year))
+	}
+}
+
+// An invalid scale of the user directory returns an error with the name of the file
+func TestLoadInvalidUserScales(t *testing.T) {
+	var dir = t.TempDir()
+	var invalid = strings.Replace(validScale, "{ min: 10001,", "{ min: 10002,", 1)
+	if err := os.WriteFile(filepath.Join(dir, "2030.yaml"), []byte(invalid), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadScales(dir)
+	if err == nil || !strings.Contains(err.Error(), "2030.yaml") {
+		t.Errorf("Expected an error with the name of the file, got %s", colors.Red(err))
+	}
+}
+
+// No scale loaded returns an error instead of an empty tax
+func TestLoadTaxYearWithoutScale(t *testing.T) {
+	var cfg = Config{}
+	if err := cfg.loadTaxYear(); !errors.Is(err, ErrInvalidScale) {
+		t.Errorf("Expected an error, got %s", colors.Red(err))
+	}
+}
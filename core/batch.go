@@ -0,0 +1,453 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/LucasNoga/corpos-christie/calculator"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/tax"
+)
+
+// Input formats of the batch
+const (
+	BATCH_CSV   string = "csv"   // CSV file with a header line
+	BATCH_JSONL string = "jsonl" // JSON Lines file, one object by line
+
+	BATCH_MAX_LINE int = 1 << 20 // Maximum size in bytes of a line of a JSON Lines file
+)
+
+// household is a line of a batch file to calculate
+// Its fields are id, income, couple, children, year, donations, aid_donations,
+// home_employment, childcare_expenses and young_children, only income is required
+type household struct {
+	line   int               // Line number in the batch file
+	fields map[string]string // Value of each field of the line
+	err    error             // Error if the line can't be read
+}
+
+// batchResult is the result of a household of a batch file
+type batchResult struct {
+	Line int    `json:"line"`
+	ID   string `json:"id,omitempty"`
+	tax.Report
+	err error // Error if the household can't be calculated
+}
+
+// batch calculate the tax of the households of a CSV or JSON Lines file with a pool of workers
+// The malformed lines are reported with their line number without stopping the batch
+func (app CLI) batch(args []string) error {
+	var input, output, inputFormat, format string
+	var year, workers int
+	var flags = app.newFlagSet("batch")
+	flags.StringVar(&input, "input", "", "File of the households (.csv or .jsonl), required")
+	flags.StringVar(&inputFormat, "input-format", "", "Format of the input: csv or jsonl (default from the extension)")
+	flags.StringVar(&output, "output", "", "File of the results (default standard output)")
+	flags.StringVar(&format, "format", FORMAT_CSV, "Format of the results: csv or json (JSON Lines)")
+	flags.IntVar(&year, "year", app.Config.GetTax().Year, "Year of the tax scale of the households without year")
+	flags.IntVar(&workers, "workers", runtime.NumCPU(), "Number of households calculated in parallel")
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if input == "" {
+		return fmt.Errorf("%w: flag --input is required", errUsage)
+	}
+	if format != FORMAT_CSV && format != FORMAT_JSON {
+		return fmt.Errorf("%w: unknown format %q, expected csv or json", errUsage, format)
+	}
+	if workers < 1 {
+		return fmt.Errorf("%w: the number of workers %d should be at least 1", errUsage, workers)
+	}
+	if inputFormat == "" {
+		inputFormat = getBatchFormat(input)
+	}
+	if inputFormat != BATCH_CSV && inputFormat != BATCH_JSONL {
+		return fmt.Errorf("%w: unknown input format %q, expected csv or jsonl", errUsage, inputFormat)
+	}
+	calc, err := calculator.NewFromConfig(app.Config)
+	if err != nil {
+		return err
+	}
+	if _, err := calc.Scale(year); err != nil {
+		return err
+	}
+
+	file, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	households, err := readHouseholds(file, inputFormat)
+	if err != nil {
+		return err
+	}
+
+	var results = calculateHouseholds(calc, households, year, workers)
+
+	var w = app.Stdout
+	if output != "" {
+		outputFile, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer outputFile.Close()
+		w = outputFile
+	}
+
+	// Report the malformed lines and write the others
+	var failed int
+	var valid = make([]batchResult, 0, len(results))
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(app.Stderr, "line %d: %v\n", result.Line, result.err)
+			failed++
+			continue
+		}
+		valid = append(valid, result)
+	}
+	if err := writeBatchResults(w, format, valid); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.Stderr, "%d households calculated, %d lines with errors\n", len(valid), failed)
+	if failed > 0 {
+		return fmt.Errorf("%d lines of %s can't be calculated", failed, input)
+	}
+	return nil
+}
+
+// getBatchFormat returns the format of the batch file from its extension
+func getBatchFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".json", ".ndjson":
+		return BATCH_JSONL
+	default:
+		return BATCH_CSV
+	}
+}
+
+// readHouseholds read all the households of a batch file
+// The lines which can't be read are returned with their error
+// returns an error only if the file itself can't be read
+func readHouseholds(r io.Reader, format string) ([]household, error) {
+	if format == BATCH_JSONL {
+		return readHouseholdsJSONL(r)
+	}
+	return readHouseholdsCSV(r)
+}
+
+// readHouseholdsCSV read the households of a CSV file, the first line is the header with the name of the fields
+func readHouseholdsCSV(r io.Reader) ([]household, error) {
+	var reader = csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("line 1: can't read the header, details: %v", err)
+	}
+	for index, name := range header {
+		header[index] = strings.ToLower(strings.TrimSpace(name))
+	}
+	if !contains(header, "income") {
+		return nil, errors.New("line 1: the header should have an income column")
+	}
+
+	var households []household
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var parseError *csv.ParseError
+		if errors.As(err, &parseError) {
+			households = append(households, household{line: parseError.StartLine, err: parseError.Err})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// The line where the record starts, a quoted field can span several lines
+		line, _ := reader.FieldPos(0)
+
+		var fields = make(map[string]string, len(header))
+		for index, name := range header {
+			fields[name] = strings.TrimSpace(record[index])
+		}
+		households = append(households, household{line: line, fields: fields})
+	}
+	return households, nil
+}
+
+// readHouseholdsJSONL read the households of a JSON Lines file, the empty lines are skipped
+// and the lines over BATCH_MAX_LINE bytes are reported without being kept in memory
+func readHouseholdsJSONL(r io.Reader) ([]household, error) {
+	var households []household
+	var reader = bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, isTooLong, err := readLine(reader, BATCH_MAX_LINE)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		var text = bytes.TrimSpace(data)
+		switch {
+		case isTooLong:
+			households = append(households, household{line: line, err: fmt.Errorf("line over %d bytes", BATCH_MAX_LINE)})
+		case len(text) > 0:
+			households = append(households, parseHouseholdJSON(line, text))
+		}
+
+		if err == io.EOF {
+			return households, nil
+		}
+	}
+}
+
+// readLine read a line of the reader with its line feed
+// the line is dropped and isTooLong is true if it's over max bytes
+// returns io.EOF with the last line if the reader ends without a line feed
+func readLine(reader *bufio.Reader, max int) (line []byte, isTooLong bool, err error) {
+	for {
+		var chunk []byte
+		chunk, err = reader.ReadSlice('\n')
+		if !isTooLong && len(line)+len(chunk) > max {
+			isTooLong = true
+			line = nil
+		}
+		if !isTooLong {
+			line = append(line, chunk...)
+		}
+		if err != bufio.ErrBufferFull {
+			return line, isTooLong, err
+		}
+	}
+}
+
+// parseHouseholdJSON parse the JSON object of a line of a JSON Lines file
+func parseHouseholdJSON(line int, text []byte) household {
+	var object map[string]interface{}
+	var decoder = json.NewDecoder(bytes.NewReader(text))
+	decoder.UseNumber()
+	if err := decoder.Decode(&object); err != nil {
+		return household{line: line, err: fmt.Errorf("invalid JSON, details: %v", err)}
+	}
+	return household{line: line, fields: getJSONFields(object)}
+}
+
+// getJSONFields convert the values of a JSON object decoded with numbers into the fields of a household
+func getJSONFields(object map[string]interface{}) map[string]string {
+	var fields = make(map[string]string, len(object))
+	for name, value := range object {
+		switch v := value.(type) {
+		case nil:
+			fields[strings.ToLower(name)] = ""
+		case string:
+			fields[strings.ToLower(name)] = strings.TrimSpace(v)
+		default:
+			fields[strings.ToLower(name)] = fmt.Sprint(v)
+		}
+	}
+	return fields
+}
+
+// calculateHouseholds calculate the households on a pool of workers
+// The calculator only reads its scales so it's shared by the workers
+// returns the results in the order of the households
+func calculateHouseholds(calc *calculator.Calculator, households []household, year int, workers int) []batchResult {
+	var results = make([]batchResult, len(households))
+	var jobs = make(chan int)
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				results[index] = calculateHousehold(calc, households[index], year)
+			}
+		}()
+	}
+
+	for index := range households {
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// calculateHousehold calculate the tax of a household with the scale of its year
+func calculateHousehold(calc *calculator.Calculator, household household, defaultYear int) batchResult {
+	var result = batchResult{Line: household.line, ID: household.fields["id"], err: household.err}
+	if result.err != nil {
+		return result
+	}
+
+	if household.fields["income"] == "" {
+		result.err = errors.New("income is missing")
+		return result
+	}
+	input, year, err := parseHousehold(household.fields, defaultYear)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	taxResult, err := calc.Calculate(input, year)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	result.Report = tax.NewReport(taxResult)
+	return result
+}
+
+// parseHousehold convert the fields of a household into the household to calculate and the year of the scale
+// The fields missing are 0 or false, the year missing is the default year
+// returns an error with the name of the field not valid
+func parseHousehold(fields map[string]string, defaultYear int) (calculator.Household, int, error) {
+	var household calculator.Household
+	var err error
+
+	if household.Income, err = parseAmountField(fields, "income"); err != nil {
+		return household, 0, err
+	}
+	if household.IsInCouple, err = parseBoolField(fields, "couple"); err != nil {
+		return household, 0, err
+	}
+	if household.Children, err = parseIntField(fields, "children", 0); err != nil {
+		return household, 0, err
+	}
+	year, err := parseIntField(fields, "year", defaultYear)
+	if err != nil {
+		return household, 0, err
+	}
+
+	if household.Credits.Donations, err = parseAmountField(fields, "donations"); err != nil {
+		return household, 0, err
+	}
+	if household.Credits.AidDonations, err = parseAmountField(fields, "aid_donations"); err != nil {
+		return household, 0, err
+	}
+	if household.Credits.HomeEmployment, err = parseAmountField(fields, "home_employment"); err != nil {
+		return household, 0, err
+	}
+	if household.Credits.ChildcareExpenses, err = parseAmountField(fields, "childcare_expenses"); err != nil {
+		return household, 0, err
+	}
+	if household.Credits.YoungChildren, err = parseIntField(fields, "young_children", 0); err != nil {
+		return household, 0, err
+	}
+
+	return household, year, household.Validate()
+}
+
+// parseAmountField convert a field into a positive amount, an empty field is 0
+func parseAmountField(fields map[string]string, name string) (money.Money, error) {
+	if fields[name] == "" {
+		return money.ZERO, nil
+	}
+	amount, err := money.Parse(fields[name])
+	if err != nil || amount < 0 {
+		return money.ZERO, fmt.Errorf("%s %q is not a valid amount", name, fields[name])
+	}
+	return amount, nil
+}
+
+// parseIntField convert a field into a positive number, an empty field is the default value
+func parseIntField(fields map[string]string, name string, defaultValue int) (int, error) {
+	if fields[name] == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(fields[name])
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%s %q is not a valid number", name, fields[name])
+	}
+	return value, nil
+}
+
+// parseBoolField convert a field like 'true', '1', 'yes' or 'y' into a boolean, an empty field is false
+func parseBoolField(fields map[string]string, name string) (bool, error) {
+	switch strings.ToLower(fields[name]) {
+	case "", "false", "0", "no", "n":
+		return false, nil
+	case "true", "1", "yes", "y":
+		return true, nil
+	}
+	return false, fmt.Errorf("%s %q is not a valid boolean", name, fields[name])
+}
+
+// writeBatchResults write the results in CSV with a column for the tax of each tranche or in JSON Lines
+func writeBatchResults(w io.Writer, format string, results []batchResult) error {
+	if format == FORMAT_JSON {
+		var encoder = json.NewEncoder(w)
+		for _, result := range results {
+			if err := encoder.Encode(result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// The years can have a different number of tranches
+	var tranches int
+	for _, result := range results {
+		if len(result.Tranches) > tranches {
+			tranches = len(result.Tranches)
+		}
+	}
+
+	var header = append([]string{"line", "id"}, tax.REPORT_HEADER...)
+	for i := 1; i <= tranches; i++ {
+		header = append(header, fmt.Sprintf("tranche_%d", i))
+	}
+
+	var writer = csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, result := range results {
+		var row = append([]string{strconv.Itoa(result.Line), result.ID}, result.Row()...)
+		for i := 0; i < tranches; i++ {
+			var amount string
+			if i < len(result.Tranches) && result.Tranches[i].Tax != nil {
+				amount = strconv.FormatFloat(*result.Tranches[i].Tax, 'f', -1, 64)
+			}
+			row = append(row, amount)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// contains check if the value is in the list
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+package core
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/calculator"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd core
+// $ go test -v
+
+// Read the households of a CSV file with the malformed lines
+func TestReadHouseholdsCSV(t *testing.T) {
+	var data = "id,income,couple,children\na,30000,false,0\nb,60000\nc,100000,yes,3\n"
+	households, err := readHouseholdsCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(households) != 3 || households[0].fields["income"] != "30000" || households[2].fields["couple"] != "yes" {
+		t.Errorf("Expected 3 households, got %v", households)
+	}
+	if households[1].line != 3 || households[1].err == nil {
+		t.Errorf("Expected an error on the line %s, got %s", colors.Red(3), colors.Red(households[1].line))
+	}
+
+	// The quoted id of the household b spans 2 lines
+	data = "id,income\na,30000\n\"b\nsecond line\",60000\nc,abc,1\n"
+	households, err = readHouseholdsCSV(strings.NewReader(data))
+	if err != nil || len(households) != 3 || households[1].line != 3 || households[2].line != 5 {
+		t.Errorf("Expected the households of the lines 2, 3 and 5, got %v %v", households, err)
+	}
+
+	if _, err := readHouseholdsCSV(strings.NewReader("id,salary\na,30000\n")); err == nil {
+		t.Errorf("Expected an error without income column")
+	}
+}
+
+// Read the households of a JSON Lines file with the malformed lines
+func TestReadHouseholdsJSONL(t *testing.T) {
+	var data = "{\"income\": 30000.5, \"couple\": true}\n\n{bad}\n{\"income\": \"60000\", \"children\": 2}\n"
+	households, err := readHouseholdsJSONL(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(households) != 3 || households[0].fields["income"] != "30000.5" || households[0].fields["couple"] != "true" {
+		t.Errorf("Expected 3 households, got %v", households)
+	}
+	if households[1].line != 3 || households[1].err == nil {
+		t.Errorf("Expected an error on the line %s, got %s", colors.Red(3), colors.Red(households[1].line))
+	}
+	if households[2].line != 4 || households[2].fields["children"] != "2" {
+		t.Errorf("Expected the household of the line %s, got %s", colors.Red(4), colors.Red(households[2].line))
+	}
+}
+
+// A line over the maximum size is reported without stopping the reading of the next lines
+func TestReadHouseholdsJSONLTooLong(t *testing.T) {
+	var long = "{\"id\": \"" + strings.Repeat("a", BATCH_MAX_LINE) + "\", \"income\": 1}"
+	var data = "{\"income\": 30000}\n" + long + "\n{\"income\": 60000}"
+	households, err := readHouseholdsJSONL(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(households) != 3 || households[1].line != 2 || households[1].err == nil {
+		t.Fatalf("Expected an error on the line %s, got %v", colors.Red(2), households)
+	}
+	if households[2].line != 3 || households[2].fields["income"] != "60000" {
+		t.Errorf("Expected the household of the line %s, got %s", colors.Red(3), colors.Red(households[2].line))
+	}
+}
+
+// Invalid fields returns an error with the name of the field
+func TestParseHouseholdInvalid(t *testing.T) {
+	var tests = []struct {
+		fields   map[string]string
+		expected string
+	}{
+		{map[string]string{"income": "-5"}, "income"},
+		{map[string]string{"income": "30000", "couple": "maybe"}, "couple"},
+		{map[string]string{"income": "30000", "children": "two"}, "children"},
+		{map[string]string{"income": "30000", "children": "-1"}, "children"},
+		{map[string]string{"income": "30000", "children": "1000000000"}, "children"},
+		{map[string]string{"income": "30000", "year": "20x4"}, "year"},
+		{map[string]string{"income": "30000", "donations": "1.234"}, "donations"},
+		{map[string]string{"income": "30000", "children": "1", "young_children": "2"}, "young_children"},
+	}
+
+	for _, test := range tests {
+		_, _, err := parseHousehold(test.fields, 2024)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Expected for %v an error on %s, got %s", test.fields, colors.Red(test.expected), colors.Red(err))
+		}
+	}
+}
+
+// Calculate many households on several workers keeps the order of the file
+func TestCalculateHouseholdsOrder(t *testing.T) {
+	var data = "income\n"
+	for i := 1; i <= 200; i++ {
+		data += strconv.Itoa(i*1000) + "\n"
+	}
+	households, _ := readHouseholdsCSV(strings.NewReader(data))
+
+	calc, err := calculator.NewFromConfig(newTestConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results = calculateHouseholds(calc, households, 2024, 8)
+
+	for index, result := range results {
+		if result.err != nil || result.Line != index+2 || result.Income != float64((index+1)*1000) {
+			t.Fatalf("Expected the result of the line %s, got %s", colors.Red(index+2), colors.Red(result.Line))
+		}
+	}
+}
+
+// Run the batch from a CSV file to a result file, the malformed lines don't stop the batch
+func TestCLIBatch(t *testing.T) {
+	var dir = t.TempDir()
+	var input = filepath.Join(dir, "households.csv")
+	var output = filepath.Join(dir, "results.csv")
+	var data = "id,income,couple,children,year\na,30000,,,\nb,abc,,,\nc,60000,true,2,2023\n"
+	if err := os.WriteFile(input, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, _, stderr := runCLI(t, "batch", "--input", input, "--output", output, "--workers", "2")
+	t.Logf("Function result:\t%s", stderr)
+	if code != EXIT_FAILURE || !strings.Contains(stderr, "line 3: income \"abc\"") {
+		t.Errorf("Expected the error of the line %s, got %s", colors.Red(3), colors.Red(stderr))
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil || len(records) != 3 {
+		t.Fatalf("Expected a header and 2 results, got %v %v", records, err)
+	}
+	if records[1][1] != "a" || records[1][8] != "2286" || records[2][1] != "c" || records[2][8] != "3043" || records[0][len(records[0])-1] != "tranche_5" {
+		t.Errorf("Expected the taxes %s and %s, got %s", colors.Red(2286), colors.Red(3043), colors.Red(records))
+	}
+}
@@ -0,0 +1,412 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+package core
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/LucasNoga/corpos-christie/calculator"
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/tax"
+
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/yaml.v3"
+)
+
+// Exit codes of the command line application
+const (
+	EXIT_SUCCESS int = 0 // Command succeeded
+	EXIT_FAILURE int = 1 // Command failed (ex: year not on the list)
+	EXIT_USAGE   int = 2 // Command called with invalid flags or values
+)
+
+// Output formats of the command line application
+const (
+	FORMAT_TABLE string = "table"
+	FORMAT_JSON  string = "json"
+	FORMAT_YAML  string = "yaml"
+	FORMAT_CSV   string = "csv"
+)
+
+// errUsage is returned when a subcommand is called with invalid flags or values
+var errUsage = errors.New("invalid usage")
+
+// CLI represents the non-interactive command line application
+// It never reads the standard input so it can be used in scripts
+type CLI struct {
+	Config *config.Config // Config to use correctly the program
+	Stdout io.Writer      // Output of the results
+	Stderr io.Writer      // Output of the errors and the usage
+}
+
+// Subcommand define a subcommand of the command line application
+type Subcommand struct {
+	name        string                                   // Name of the subcommand
+	description string                                   // Description of the subcommand
+	exec        func(app CLI, args []string) (err error) // Function to execute subcommand
+}
+
+// SUBCOMMANDS is the list of subcommands usable in command line
+var SUBCOMMANDS []Subcommand
+
+// Init SUBCOMMANDS variables
+func init() {
+	SUBCOMMANDS = []Subcommand{
+		{
+			name:        "calc",
+			exec:        CLI.calc,
+			description: "Calculate the tax from the income (ex: calc --income 52000 --couple --children 2 --year 2023)",
+		},
+		{
+			name:        "reverse",
+			exec:        CLI.reverse,
+			description: "Estimate the income from the remainder after tax (ex: reverse --net 40000)",
+		},
+		{
+			name:        "batch",
+			exec:        CLI.batch,
+			description: "Calculate the tax of the households of a CSV or JSON Lines file (ex: batch --input households.csv --output results.csv)",
+		},
+		{
+			name:        "compare",
+			exec:        CLI.compare,
+			description: "Compare the tax of 2 to 4 scenarios (ex: compare --income 60000 --scenario name=Single --scenario name=PACS,couple)",
+		},
+		{
+			name:        "report",
+			exec:        CLI.report,
+			description: "Write the PDF report of the tax from the income (ex: report --income 52000 --lang fr --output report.pdf)",
+		},
+		{
+			name:        "scales",
+			exec:        CLI.scales,
+			description: "Show the scale of a year (ex: scales --year 2022)",
+		},
+		{
+			name:        "years",
+			exec:        CLI.years,
+			description: "Show the list of years available",
+		},
+		{
+			name:        "help",
+			exec:        func(app CLI, args []string) error { app.showUsage(app.Stdout); return nil },
+			description: "Show this help",
+		},
+	}
+}
+
+// isSubcommand check if the name is a subcommand of the command line application
+func isSubcommand(name string) bool {
+	_, ok := findSubcommand(name)
+	return ok
+}
+
+// findSubcommand returns the subcommand with the name and true if it exists
+func findSubcommand(name string) (Subcommand, bool) {
+	for _, cmd := range SUBCOMMANDS {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return Subcommand{}, false
+}
+
+// Run execute the subcommand given in first argument with its flags
+// returns the exit code of the program
+func (app CLI) Run(args []string) int {
+	if len(args) == 0 {
+		app.showUsage(app.Stderr)
+		return EXIT_USAGE
+	}
+
+	cmd, ok := findSubcommand(args[0])
+	if !ok {
+		fmt.Fprintf(app.Stderr, "Error: unknown command %q\n", args[0])
+		app.showUsage(app.Stderr)
+		return EXIT_USAGE
+	}
+
+	err := cmd.exec(app, args[1:])
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return EXIT_SUCCESS
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(app.Stderr, "Error: %v\n", err)
+		return EXIT_USAGE
+	default:
+		fmt.Fprintf(app.Stderr, "Error: %v\n", err)
+		return EXIT_FAILURE
+	}
+}
+
+// showUsage show the list of subcommands
+func (app CLI) showUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", config.APP_NAME)
+	for _, cmd := range SUBCOMMANDS {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintf(w, "\nRun '%s <command> --help' to get the flags of a command\n", config.APP_NAME)
+}
+
+// householdFlags define the flags describing the household of the user
+type householdFlags struct {
+	couple   bool
+	children int
+	year     int
+	format   string
+}
+
+// newFlagSet create the flags of a subcommand which writes its errors on Stderr
+func (app CLI) newFlagSet(name string) *flag.FlagSet {
+	var flags = flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(app.Stderr)
+	return flags
+}
+
+// addHouseholdFlags add the flags of the household, the year and the format to the subcommand
+func (app CLI) addHouseholdFlags(flags *flag.FlagSet, household *householdFlags) {
+	flags.BoolVar(&household.couple, "couple", false, "Declaration of a couple (married or pacsed)")
+	flags.IntVar(&household.children, "children", 0, "Number of dependent children")
+	app.addYearFlags(flags, &household.year, &household.format)
+}
+
+// addYearFlags add the flags of the year and the format to the subcommand
+func (app CLI) addYearFlags(flags *flag.FlagSet, year *int, format *string) {
+	flags.IntVar(year, "year", app.Config.GetTax().Year, "Year of the tax scale")
+	flags.StringVar(format, "format", FORMAT_TABLE, "Output format: table, json, yaml or csv")
+}
+
+// parseFlags parse the args of the subcommand
+// returns an error wrapping errUsage if a flag is not valid
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected argument %q", errUsage, flags.Arg(0))
+	}
+	return nil
+}
+
+// checkFormat check if the format is supported
+func checkFormat(format string) error {
+	switch format {
+	case FORMAT_TABLE, FORMAT_JSON, FORMAT_YAML, FORMAT_CSV:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown format %q, expected table, json, yaml or csv", errUsage, format)
+}
+
+// toHousehold create the household to calculate from the flags with its income
+func (household householdFlags) toHousehold(income money.Money) calculator.Household {
+	return calculator.Household{Income: income, IsInCouple: household.couple, Children: household.children}
+}
+
+// checkCalculation returns an error wrapping errUsage if the household is not valid
+func checkCalculation(err error) error {
+	var inputError *calculator.InputError
+	if errors.As(err, &inputError) {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	return err
+}
+
+// calc calculate the tax from the income given in flags
+func (app CLI) calc(args []string) error {
+	var household householdFlags
+	var income amountFlag
+	var flags = app.newFlagSet("calc")
+	flags.Var(&income, "income", "Taxable income in euros (revenu net imposable), required")
+	app.addHouseholdFlags(flags, &household)
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := checkFormat(household.format); err != nil {
+		return err
+	}
+	if !income.set {
+		return fmt.Errorf("%w: flag --income is required", errUsage)
+	}
+
+	calc, err := calculator.NewFromConfig(app.Config)
+	if err != nil {
+		return err
+	}
+	result, err := calc.Calculate(household.toHousehold(income.value), household.year)
+	if err != nil {
+		return checkCalculation(err)
+	}
+	return writeReport(app.Stdout, household.format, result)
+}
+
+// reverse estimate the income from the remainder given in flags
+func (app CLI) reverse(args []string) error {
+	var household householdFlags
+	var net amountFlag
+	var flags = app.newFlagSet("reverse")
+	flags.Var(&net, "net", "Remainder in euros wished after tax, required")
+	app.addHouseholdFlags(flags, &household)
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := checkFormat(household.format); err != nil {
+		return err
+	}
+	if !net.set {
+		return fmt.Errorf("%w: flag --net is required", errUsage)
+	}
+
+	calc, err := calculator.NewFromConfig(app.Config)
+	if err != nil {
+		return err
+	}
+	result, err := calc.CalculateReverse(household.toHousehold(money.ZERO), net.value, household.year)
+	if err != nil {
+		return checkCalculation(err)
+	}
+	return writeReport(app.Stdout, household.format, result)
+}
+
+// writeReport write the report of the result in the format
+func writeReport(w io.Writer, format string, result tax.Result) error {
+	var report = tax.NewReport(result)
+	var rows = [][]string{report.Row()}
+	return writeOutput(w, format, report, tax.REPORT_HEADER, rows)
+}
+
+// scales show the scale of the year given in flags
+func (app CLI) scales(args []string) error {
+	var year int
+	var format string
+	var flags = app.newFlagSet("scales")
+	app.addYearFlags(flags, &year, &format)
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+	calc, err := calculator.NewFromConfig(app.Config)
+	if err != nil {
+		return err
+	}
+	scale, err := calc.Scale(year)
+	if err != nil {
+		return err
+	}
+
+	var report = tax.NewScaleReport(scale)
+	var rows = make([][]string, 0, len(report.Tranches))
+	for _, tranche := range report.Tranches {
+		var max = ""
+		if tranche.Max != nil {
+			max = strconv.FormatFloat(*tranche.Max, 'f', -1, 64)
+		}
+		rows = append(rows, []string{strconv.FormatFloat(tranche.Min, 'f', -1, 64), max, tranche.Rate})
+	}
+	return writeOutput(app.Stdout, format, report, []string{"min", "max", "rate"}, rows)
+}
+
+// yearReport is a year available in the command line output
+type yearReport struct {
+	Year    int    `json:"year" yaml:"year"`
+	Source  string `json:"source" yaml:"source"`
+	Default bool   `json:"default" yaml:"default"`
+}
+
+// years show the list of years available
+func (app CLI) years(args []string) error {
+	var format string
+	var flags = app.newFlagSet("years")
+	flags.StringVar(&format, "format", FORMAT_TABLE, "Output format: table, json, yaml or csv")
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	var years = make([]yearReport, 0, len(app.Config.TaxList))
+	var rows = make([][]string, 0, len(app.Config.TaxList))
+	for _, tax := range app.Config.TaxList {
+		var year = yearReport{Year: tax.Year, Source: tax.Source, Default: tax.Year == app.Config.GetTax().Year}
+		years = append(years, year)
+		rows = append(rows, []string{strconv.Itoa(year.Year), year.Source, strconv.FormatBool(year.Default)})
+	}
+	return writeOutput(app.Stdout, format, years, []string{"year", "source", "default"}, rows)
+}
+
+// writeOutput write the data in JSON or YAML, or its rows in CSV or in a table
+func writeOutput(w io.Writer, format string, data interface{}, header []string, rows [][]string) error {
+	switch format {
+	case FORMAT_JSON:
+		var encoder = json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	case FORMAT_YAML:
+		var encoder = yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(data); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case FORMAT_CSV:
+		var writer = csv.NewWriter(w)
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	default:
+		var table = tablewriter.NewWriter(w)
+		table.SetHeader(header)
+		table.SetAutoFormatHeaders(false)
+		table.SetAutoWrapText(false)
+		table.AppendBulk(rows)
+		table.Render()
+		return nil
+	}
+}
+
+// amountFlag is a flag of an amount in euros like '52000' or '52000.50'
+type amountFlag struct {
+	value money.Money // Amount parsed
+	set   bool        // True if the flag has been given
+}
+
+// String returns the amount of the flag
+func (f *amountFlag) String() string {
+	if f == nil || !f.set {
+		return ""
+	}
+	return f.value.String()
+}
+
+// Set parse the amount of the flag
+func (f *amountFlag) Set(value string) error {
+	amount, err := money.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("invalid amount %q", value)
+	}
+	f.value = amount
+	f.set = true
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/tax"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+	"gopkg.in/yaml.v3"
+)
+
+// For testing
+// $ cd core
+// $ go test -v
+
+// newTestConfig create the configuration with the embedded scales, 2024 is the default year
+func newTestConfig(t *testing.T) *config.Config {
+	taxList, err := config.LoadScales("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &config.Config{Tax: taxList[0], TaxList: taxList}
+}
+
+// runCLI run the command line application with the embedded scales
+// returns the exit code, the standard output and the error output
+func runCLI(t *testing.T, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	var app = CLI{
+		Config: newTestConfig(t),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	var code = app.Run(args)
+	return code, stdout.String(), stderr.String()
+}
+
+// Test select mode when a subcommand is given
+func TestSelectModeWithSubcommand(t *testing.T) {
+	var expectedValue = CLI_APP
+	var args []string = []string{"main.go", "calc", "--income", "30000"}
+
+	var mode string = selectMode(args)
+	t.Logf("Function result:\t%s", mode)
+
+	if mode != expectedValue {
+		t.Errorf("Expected that the Mode '%v' should be equal to %v", colors.Red(expectedValue), colors.Red(mode))
+	}
+}
+
+// Calculate the tax in JSON of a couple with 2 children in 2023
+func TestCLICalcJSON(t *testing.T) {
+	code, stdout, stderr := runCLI(t, "calc", "--income", "60000", "--couple", "--children", "2", "--year", "2023", "--format", "json")
+	t.Logf("Function result:\t%s", stdout)
+
+	var report tax.Report
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil || code != EXIT_SUCCESS {
+		t.Fatalf("Expected a valid JSON, got %d %v %s", code, err, stderr)
+	}
+
+	if report.Year != 2023 || report.Shares != 3 || report.Tax != 3043 || len(report.Tranches) != 5 {
+		t.Errorf("Expected that the tax %s should be equal to %s", colors.Red(3043), colors.Red(report.Tax))
+	}
+	if report.Tranches[4].Max != nil {
+		t.Errorf("Expected that the last tranche should have no max")
+	}
+}
+
+// Calculate the tax in YAML and CSV with the default year
+func TestCLICalcFormats(t *testing.T) {
+	_, stdout, _ := runCLI(t, "calc", "--income", "30000", "--format", "yaml")
+	var report tax.Report
+	if err := yaml.Unmarshal([]byte(stdout), &report); err != nil || report.Year != 2024 || report.Tax != 2286 {
+		t.Errorf("Expected that the tax %s should be equal to %s, %v", colors.Red(2286), colors.Red(report.Tax), err)
+	}
+
+	_, stdout, _ = runCLI(t, "calc", "--income", "30000", "--format", "csv")
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil || len(records) != 2 || records[0][6] != "tax" || records[1][6] != "2286" {
+		t.Errorf("Expected a CSV with a header and a row, got %s", colors.Red(records))
+	}
+
+	_, stdout, _ = runCLI(t, "calc", "--income", "30000")
+	if !strings.Contains(stdout, "2286") {
+		t.Errorf("Expected a table with the tax %s, got %s", colors.Red(2286), colors.Red(stdout))
+	}
+}
+
+// Estimate the income from the remainder
+func TestCLIReverse(t *testing.T) {
+	code, stdout, _ := runCLI(t, "reverse", "--net", "40000", "--format", "json")
+
+	var report tax.Report
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil || code != EXIT_SUCCESS {
+		t.Fatalf("Expected a valid JSON, got %d %v", code, err)
+	}
+	if report.Remainder < 40000 || report.Remainder >= 40001 {
+		t.Errorf("Expected that the remainder %s should be equal to %s", colors.Red(40000), colors.Red(report.Remainder))
+	}
+}
+
+// Show the scale of a year and the list of years
+func TestCLIScalesAndYears(t *testing.T) {
+	_, stdout, _ := runCLI(t, "scales", "--year", "2022", "--format", "json")
+	var scale tax.ScaleReport
+	if err := json.Unmarshal([]byte(stdout), &scale); err != nil || scale.Year != 2022 || scale.Tranches[1].Min != 10226 {
+		t.Errorf("Expected the scale of %s, got %s", colors.Red(2022), colors.Red(stdout))
+	}
+
+	_, stdout, _ = runCLI(t, "years", "--format", "csv")
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil || len(records) != 7 || records[1][0] != "2024" || records[1][2] != "true" {
+		t.Errorf("Expected the list of years, got %s", colors.Red(records))
+	}
+}
+
+// Invalid usages returns the exit code of usage, errors returns the exit code of failure
+func TestCLIExitCodes(t *testing.T) {
+	var tests = []struct {
+		args     []string
+		expected int
+	}{
+		{[]string{}, EXIT_USAGE},
+		{[]string{"unknown"}, EXIT_USAGE},
+		{[]string{"calc"}, EXIT_USAGE},
+		{[]string{"calc", "--income", "abc"}, EXIT_USAGE},
+		{[]string{"calc", "--income", "-1"}, EXIT_USAGE},
+		{[]string{"calc", "--income", "30000", "--children", "-1"}, EXIT_USAGE},
+		{[]string{"calc", "--income", "30000", "--children", "1000000000"}, EXIT_USAGE},
+		{[]string{"calc", "--income", "30000", "--format", "xml"}, EXIT_USAGE},
+		{[]string{"calc", "--income", "30000", "extra"}, EXIT_USAGE},
+		{[]string{"calc", "--income", "30000", "--year", "1990"}, EXIT_FAILURE},
+		{[]string{"scales", "--year", "1990"}, EXIT_FAILURE},
+		{[]string{"report", "--income", "30000"}, EXIT_USAGE},
+		{[]string{"report", "--income", "30000", "--output", "-", "--lang", "de"}, EXIT_USAGE},
+		{[]string{"calc", "--help"}, EXIT_SUCCESS},
+		{[]string{"help"}, EXIT_SUCCESS},
+	}
+
+	for _, test := range tests {
+		code, _, stderr := runCLI(t, test.args...)
+		if code != test.expected {
+			t.Errorf("Expected that the exit code of %v %s should be equal to %s (%s)", test.args, colors.Red(test.expected), colors.Red(code), stderr)
+		}
+	}
+}
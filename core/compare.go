@@ -0,0 +1,120 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/LucasNoga/corpos-christie/calculator"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/tax"
+	"github.com/LucasNoga/corpos-christie/user"
+)
+
+// compare compare the tax of the scenarios given in flags
+// each scenario changes the household given by the other flags
+func (app CLI) compare(args []string) error {
+	var household householdFlags
+	var income amountFlag
+	var scenarios scenariosFlag
+	var flags = app.newFlagSet("compare")
+	flags.Var(&income, "income", "Taxable income in euros of the household of the scenarios")
+	flags.Var(&scenarios, "scenario", fmt.Sprintf("Scenario changing the household, %d to %d times (ex: 'name=PACS,couple=true,children=3,year=2023,income=60000')", tax.COMPARE_MIN_SCENARIOS, tax.COMPARE_MAX_SCENARIOS))
+	app.addHouseholdFlags(flags, &household)
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := checkFormat(household.format); err != nil {
+		return err
+	}
+	if len(scenarios) < tax.COMPARE_MIN_SCENARIOS || len(scenarios) > tax.COMPARE_MAX_SCENARIOS {
+		return fmt.Errorf("%w: flag --scenario is required %d to %d times", errUsage, tax.COMPARE_MIN_SCENARIOS, tax.COMPARE_MAX_SCENARIOS)
+	}
+
+	var list = make([]tax.Scenario, 0, len(scenarios))
+	for i, spec := range scenarios {
+		scenario, err := parseScenario(spec, household.toHousehold(income.value), household.year)
+		if err != nil {
+			return err
+		}
+		if scenario.Name == "" {
+			scenario.Name = fmt.Sprintf("Scenario %d", i+1)
+		}
+		list = append(list, scenario)
+	}
+
+	comparison, err := tax.CompareScenarios(list, app.Config)
+	if err != nil {
+		return err
+	}
+	if household.format == FORMAT_TABLE {
+		tax.ShowComparison(app.Stdout, comparison)
+		return nil
+	}
+	var report = tax.NewComparisonReport(comparison)
+	return writeOutput(app.Stdout, household.format, report, tax.COMPARISON_HEADER, report.Rows())
+}
+
+// parseScenario create the scenario from the household and the year changed by the fields of the spec
+// the spec is a list of key=value separated by commas with the keys name, income, couple, children and year
+// returns an error wrapping errUsage if a field is not valid
+func parseScenario(spec string, household calculator.Household, year int) (tax.Scenario, error) {
+	var scenario = tax.Scenario{Year: year}
+	for _, field := range strings.Split(spec, ",") {
+		var key, value = strings.TrimSpace(field), ""
+		if index := strings.Index(key, "="); index >= 0 {
+			key, value = strings.TrimSpace(key[:index]), strings.TrimSpace(key[index+1:])
+		}
+
+		var err error
+		switch key {
+		case "":
+			continue
+		case "name":
+			scenario.Name = value
+		case "income":
+			household.Income, err = money.Parse(value)
+		case "couple":
+			household.IsInCouple = true
+			if value != "" {
+				household.IsInCouple, err = strconv.ParseBool(value)
+			}
+		case "children":
+			household.Children, err = strconv.Atoi(value)
+		case "year":
+			scenario.Year, err = strconv.Atoi(value)
+		default:
+			return tax.Scenario{}, fmt.Errorf("%w: unknown field %q in scenario %q", errUsage, key, spec)
+		}
+		if err != nil {
+			return tax.Scenario{}, fmt.Errorf("%w: invalid %s %q in scenario %q", errUsage, key, value, spec)
+		}
+	}
+	if err := household.Validate(); err != nil {
+		return tax.Scenario{}, fmt.Errorf("%w: %v in scenario %q", errUsage, err, spec)
+	}
+
+	scenario.User = user.User{Income: household.Income, IsInCouple: household.IsInCouple, Children: household.Children}
+	return scenario, nil
+}
+
+// scenariosFlag is a flag given several times with a scenario each time
+type scenariosFlag []string
+
+// String returns the scenarios of the flag
+func (f *scenariosFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(*f, " ")
+}
+
+// Set add a scenario to the flag
+func (f *scenariosFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/tax"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd core
+// $ go test -v
+
+// Compare in JSON a single person with a couple with 2 children in 2023
+func TestCLICompareJSON(t *testing.T) {
+	code, stdout, stderr := runCLI(t, "compare", "--income", "60000", "--year", "2023", "--format", "json",
+		"--scenario", "name=Single", "--scenario", "name=Family,couple,children=2")
+
+	var report tax.ComparisonReport
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil || code != EXIT_SUCCESS {
+		t.Fatalf("Expected a valid JSON, got %d %v %s", code, err, stderr)
+	}
+	if len(report.Scenarios) != 2 {
+		t.Fatalf("Expected %s scenarios, got %s", colors.Red(2), colors.Red(len(report.Scenarios)))
+	}
+
+	var family = report.Scenarios[1]
+	if family.Name != "Family" || family.Result.Tax != 3043 || family.Result.Shares != 3 {
+		t.Errorf("Expected the tax %s of the family, got %+v", colors.Red(3043), family.Result)
+	}
+	if family.Diff.Shares != 2 || family.Diff.Tax != 3043-report.Scenarios[0].Result.Tax || len(family.Diff.Tranches) != 5 {
+		t.Errorf("Expected the differences from the single person, got %+v", family.Diff)
+	}
+}
+
+// Compare in a table the default year with another year and a default name
+func TestCLICompareTable(t *testing.T) {
+	code, stdout, stderr := runCLI(t, "compare", "--income", "30000", "--scenario", "name=Now", "--scenario", "year=2023")
+	if code != EXIT_SUCCESS {
+		t.Fatalf("Expected the exit code %s, got %s (%s)", colors.Red(EXIT_SUCCESS), colors.Red(code), stderr)
+	}
+	for _, text := range []string{"Now", "Scenario 2", "2024", "2023", "2286 €"} {
+		if !strings.Contains(stdout, text) {
+			t.Errorf("Expected %s in the comparison, got:\n%s", colors.Red(text), stdout)
+		}
+	}
+}
+
+// Compare with a wrong number of scenarios, an invalid scenario or an unknown year
+func TestCLICompareErrors(t *testing.T) {
+	var tests = []struct {
+		args []string
+		code int
+	}{
+		{[]string{"compare", "--scenario", "name=A"}, EXIT_USAGE},
+		{[]string{"compare", "--scenario", "name=A", "--scenario", "wife=1"}, EXIT_USAGE},
+		{[]string{"compare", "--scenario", "children=-1", "--scenario", "name=B"}, EXIT_USAGE},
+		{[]string{"compare", "--scenario", "income=abc", "--scenario", "name=B"}, EXIT_USAGE},
+		{[]string{"compare", "--scenario", "year=1990", "--scenario", "name=B"}, EXIT_FAILURE},
+	}
+
+	for _, test := range tests {
+		code, _, stderr := runCLI(t, test.args...)
+		if code != test.code {
+			t.Errorf("Expected the exit code %s for %s, got %s (%s)", colors.Red(test.code), test.args, colors.Red(code), stderr)
+		}
+	}
+}
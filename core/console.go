@@ -5,11 +5,12 @@ package core
 
 import (
 	"fmt"
-	"os"
 	"strconv"
 	"time"
 
 	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/export"
+	"github.com/LucasNoga/corpos-christie/profile"
 	"github.com/LucasNoga/corpos-christie/tax"
 	"github.com/LucasNoga/corpos-christie/user"
 	"github.com/LucasNoga/corpos-christie/utils"
@@ -18,16 +19,18 @@ import (
 
 // Console represents the console application
 type Console struct {
-	Config *config.Config // Config to use correctly the program
-	User   *user.User     // User param to use program
+	Config   *config.Config  // Config to use correctly the program
+	User     *user.User      // User param to use program
+	Prompter *utils.Prompter // Reader of the answers and writer of the results (ex: os.Stdin and os.Stdout)
+	Pause    time.Duration   // Pause after each command to let the user read the result
 }
 
 // Command define a command to use in console app
 type Command struct {
-	index       int                                       // Number to type to exec command
-	name        string                                    // Name of the command
-	description string                                    // Description of the command
-	exec        func(cfg *config.Config, user *user.User) // Function to execute command
+	index       int                                                                 // Number to type to exec command
+	name        string                                                              // Name of the command
+	description string                                                              // Description of the command
+	exec        func(prompter *utils.Prompter, cfg *config.Config, user *user.User) // Function to execute command
 }
 
 // OPTIONS is the list of options usable in console app
@@ -41,44 +44,101 @@ func init() {
 			exec:        tax.StartTaxCalculator,
 			description: "Calculate your tax from your incomes (income > tax)",
 		},
+		{
+			name:        "gross_salary_calculator",
+			exec:        tax.StartGrossSalaryCalculator,
+			description: "Calculate your tax from your gross salary (gross salary > income > tax)",
+		},
+		{
+			name:        "withholding_calculator",
+			exec:        tax.StartWithholdingCalculator,
+			description: "Calculate your withholding tax rates from your incomes (prélèvement à la source)",
+		},
 		{
 			name:        "reverse_tax_calculator",
 			exec:        tax.StartReverseTaxCalculator,
 			description: "Estimate your incomes from a tax amount (tax > income)",
 		},
 		{
-			name:        "show_tax_tranche",
-			exec:        func(cfg *config.Config, user *user.User) { tax.ShowTaxTranche(*cfg) },
+			name:        "simulate_range",
+			exec:        tax.StartSimulateRange,
+			description: "Calculate your tax for a range of incomes with a chart (min > max by step)",
+		},
+		{
+			name:        "compare",
+			exec:        tax.StartCompare,
+			description: "Compare your tax in 2 to 4 scenarios (ex: single or pacsed, another child, another year)",
+		},
+		{
+			name:        "household_calculator",
+			exec:        tax.StartHouseholdCalculator,
+			description: "Calculate your tax from the incomes of each declarant, the details of your children and your particular cases",
+		},
+		{
+			name:        "declaration_optimizer",
+			exec:        tax.StartDeclarationOptimizer,
+			description: "Find your cheapest declarations in the year of a marriage, a PACS or a divorce (joint or separate)",
+		},
+		{
+			name: "show_tax_tranche",
+			exec: func(prompter *utils.Prompter, cfg *config.Config, user *user.User) {
+				tax.ShowTaxTranche(prompter.Writer(), *cfg)
+			},
 			description: "Show the scale of taxes from the year selected",
 		},
 		{
-			name:        "show_tax_year_list",
-			exec:        func(cfg *config.Config, user *user.User) { tax.ShowTaxList(*cfg) },
+			name: "show_tax_year_list",
+			exec: func(prompter *utils.Prompter, cfg *config.Config, user *user.User) {
+				tax.ShowTaxList(prompter.Writer(), *cfg)
+			},
 			description: "Show the list of years to calculate your taxes",
 		},
 		{
-			name:        "show_tax_year_used",
-			exec:        func(cfg *config.Config, user *user.User) { tax.ShowTaxListUsed(*cfg) },
+			name: "show_tax_year_used",
+			exec: func(prompter *utils.Prompter, cfg *config.Config, user *user.User) {
+				tax.ShowTaxListUsed(prompter.Writer(), *cfg)
+			},
 			description: "Show the year base to calculate your taxes",
 		},
 		{
 			name:        "select_tax_year",
-			exec:        func(cfg *config.Config, user *user.User) { tax.SelectTaxYear(cfg) },
+			exec:        func(prompter *utils.Prompter, cfg *config.Config, user *user.User) { tax.SelectTaxYear(prompter, cfg) },
 			description: "Select a tax year if you want to calculate your taxes based on metrics of another year",
 		},
+		{
+			name: "save_profile",
+			exec: func(prompter *utils.Prompter, cfg *config.Config, user *user.User) {
+				profile.StartSaveProfile(prompter, profile.GetProfilesPath(), cfg, user)
+			},
+			description: "Save your household and the year selected into a profile",
+		},
+		{
+			name: "load_profile",
+			exec: func(prompter *utils.Prompter, cfg *config.Config, user *user.User) {
+				profile.StartLoadProfile(prompter, profile.GetProfilesPath(), cfg, user)
+			},
+			description: "Load a household saved into a profile",
+		},
+		{
+			name:        "export",
+			exec:        export.StartExport,
+			description: "Export your results or the tax scales into a CSV or XLSX file",
+		},
 		{
 			name:        "options",
-			exec:        func(cfg *config.Config, user *user.User) { showOptions() },
+			exec:        func(prompter *utils.Prompter, cfg *config.Config, user *user.User) { showOptions(prompter) },
 			description: "Show options list",
 		},
 		{
 			name:        "about",
-			exec:        func(cfg *config.Config, user *user.User) { showAbout() },
+			exec:        func(prompter *utils.Prompter, cfg *config.Config, user *user.User) { showAbout(prompter) },
 			description: "Show options list",
 		},
 		{
-			name:        "quit",
-			exec:        func(cfg *config.Config, user *user.User) { fmt.Println("Quitting program"); os.Exit(0) },
+			name: "quit",
+			exec: func(prompter *utils.Prompter, cfg *config.Config, user *user.User) {
+				prompter.Println("Quitting program")
+			},
 			description: "Quit program",
 		},
 	}
@@ -93,38 +153,47 @@ func init() {
 }
 
 // Start launch application in console
+// returns when the user quits or when there is nothing more to read
 func (app Console) Start() {
-	fmt.Printf("Project: %s\n", colors.Yellow(app.Config.Name))
-	fmt.Printf("Version: %s\b", colors.Yellow(app.Config.Version))
+	var prompter = app.Prompter
+	prompter.Printf("Project: %s\n", colors.Yellow(app.Config.Name))
+	prompter.Printf("Version: %s\b", colors.Yellow(app.Config.Version))
 
 	// Loop so start program until user wants to exit
 	for {
 		// Show options to user
-		showOptions()
+		showOptions(prompter)
 
-		var optionEntered string = chooseOption()
+		optionEntered, err := chooseOption(prompter)
+		if err != nil {
+			prompter.Println()
+			prompter.Println("Quitting program")
+			return
+		}
 
 		optionVerified, cmd := verifyOption(optionEntered)
 
 		// if option doesn't exists
 		if !optionVerified {
-			fmt.Printf(colors.Red("Invalid option")+"'%s'. "+colors.Red("Try again\n"), colors.Yellow(optionEntered))
+			prompter.Printf(colors.Red("Invalid option")+"'%s'. "+colors.Red("Try again\n"), colors.Yellow(optionEntered))
 			continue
 		}
 
 		// If option is valid we execute the associate command
-		cmd.exec(app.Config, app.User)
-		fmt.Println("----------------------------------------")
-
-		time.Sleep(700 * time.Millisecond)
+		cmd.exec(prompter, app.Config, app.User)
+		if cmd.name == "quit" {
+			return
+		}
+		prompter.Println("----------------------------------------")
 
+		time.Sleep(app.Pause)
 	}
 }
 
 // showOptions show in the console the list of options which can be selected
-func showOptions() {
+func showOptions(prompter *utils.Prompter) {
 	// prepend example command
-	fmt.Println(colors.Yellow("\t\t\t List of options"))
+	prompter.Println(colors.Yellow("\t\t\t List of options"))
 	var exCommand Command = Command{index: 0, name: "Exemple Command", description: "Description"}
 
 	// Get all keys from console options list
@@ -134,21 +203,21 @@ func showOptions() {
 	cmdsName = append([]string{exCommand.name}, cmdsName...)
 
 	// Show example command
-	fmt.Printf(colors.Black("- [%d] - [%s] %s %s\n"), exCommand.index, exCommand.name, utils.SetPadding(cmdsName, exCommand.name), exCommand.description)
+	prompter.Printf(colors.Black("- [%d] - [%s] %s %s\n"), exCommand.index, exCommand.name, utils.SetPadding(cmdsName, exCommand.name), exCommand.description)
 	// Show each options
 	for _, cmd := range OPTIONS {
-		fmt.Printf("- [%s] - [%s] %s %s\n", colors.Black(cmd.index), colors.Magenta(cmd.name), utils.SetPadding(cmdsName, cmd.name), colors.Teal(cmd.description))
+		prompter.Printf("- [%s] - [%s] %s %s\n", colors.Black(cmd.index), colors.Magenta(cmd.name), utils.SetPadding(cmdsName, cmd.name), colors.Teal(cmd.description))
 	}
-	fmt.Println()
+	prompter.Println()
 }
 
 // showAbout show in the console the description of the application
-func showAbout() {
-	fmt.Printf("Application name: %s\n", colors.Yellow(config.APP_NAME))
-	fmt.Println("Description: Application to calculate taxes in France developped in Golang")
-	fmt.Printf("GitHub: %s\n", colors.Yellow(config.APP_LINK))
-	fmt.Printf("Version : %s\n", colors.Yellow(fmt.Sprintf("v%s", config.APP_VERSION)))
-	fmt.Printf("Author: %s\n", colors.Yellow(config.APP_AUTHOR))
+func showAbout(prompter *utils.Prompter) {
+	prompter.Printf("Application name: %s\n", colors.Yellow(config.APP_NAME))
+	prompter.Println("Description: Application to calculate taxes in France developped in Golang")
+	prompter.Printf("GitHub: %s\n", colors.Yellow(config.APP_LINK))
+	prompter.Printf("Version : %s\n", colors.Yellow(fmt.Sprintf("v%s", config.APP_VERSION)))
+	prompter.Printf("Author: %s\n", colors.Yellow(config.APP_AUTHOR))
 
 }
 
@@ -176,8 +245,8 @@ func verifyOption(optionEntered string) (bool, Command) {
 
 // chooseOption ask to the user which command he wants to execute in console
 // returns string seized in console by the user (define the command name)
-func chooseOption() string {
-	fmt.Print(colors.Green("Type an option > "))
-	var input string = utils.ReadValue()
-	return input
+// returns an error if there is nothing more to read
+func chooseOption(prompter *utils.Prompter) (string, error) {
+	prompter.Print(colors.Green("Type an option > "))
+	return prompter.ReadValue()
 }
@@ -0,0 +1,76 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd core
+// $ go test -v
+
+// runConsole run a session of the console with the lines of the script as answers
+// returns the user and the output of the session
+func runConsole(t *testing.T, script ...string) (*user.User, string) {
+	var out bytes.Buffer
+	var console = Console{
+		Config:   newTestConfig(t),
+		User:     new(user.User),
+		Prompter: utils.NewPrompter(strings.NewReader(strings.Join(script, "\n")+"\n"), &out),
+	}
+	console.Start()
+	return console.User, out.String()
+}
+
+// Calculate the tax of a session with an invalid answer asked again
+func TestConsoleTaxCalculator(t *testing.T) {
+	user, out := runConsole(t,
+		"select_tax_year", "1990", "2023",
+		"tax_calculator", "abc", "60000", "y", "2", "n", "n", "n",
+		"quit",
+	)
+	t.Logf("Function result:\t%+v", user)
+
+	if user.Tax.String() != "3043" || !user.IsInCouple || user.Children != 2 {
+		t.Errorf("Expected that the Tax %s should be equal to %s", colors.Red(3043), colors.Red(user.Tax))
+	}
+	if !strings.Contains(out, "'abc' is not an amount") || !strings.Contains(out, "1990 is not on the list") {
+		t.Errorf("Expected the invalid answers asked again, got %s", colors.Red(out))
+	}
+	if !strings.HasSuffix(out, "Quitting program\n") {
+		t.Errorf("Expected the session to quit, got %s", colors.Red(out))
+	}
+}
+
+// The session ends when there is nothing more to read, even in the middle of a command
+func TestConsoleEndOfInput(t *testing.T) {
+	_, out := runConsole(t, "about", "reverse_tax_calculator", "40000")
+
+	if !strings.Contains(out, "Application name") || !strings.HasSuffix(out, "Quitting program\n") {
+		t.Errorf("Expected the session to quit at the end of the input, got %s", colors.Red(out))
+	}
+}
+
+// The tax calculator doesn't keep the children and the particular cases of the household calculated before
+func TestConsoleHouseholdThenTaxCalculator(t *testing.T) {
+	user, out := runConsole(t,
+		"select_tax_year", "2023",
+		"household_calculator", "n", "30000", "", "", "", "n", "n", "3", "", "n", "n", "", "n", "n", "", "n", "n", "y",
+		"tax_calculator", "30000", "n", "1", "n", "n", "n",
+		"quit",
+	)
+	t.Logf("Function result:\t%+v", user)
+
+	// 1 share and 0.5 for the child and 0.5 for the isolated parent instead of the 3 children of a widowed declarant
+	if user.Shares != 2 || user.Children != 1 || len(user.Dependents) != 0 || user.Cases.IsWidowed {
+		t.Errorf("Expected %s shares for an isolated parent with a child, got %s:\n%s", colors.Red(2), colors.Red(user.Shares), out)
+	}
+}
@@ -6,16 +6,20 @@ package core
 
 import (
 	"os"
+	"time"
 
 	"github.com/LucasNoga/corpos-christie/config"
 	"github.com/LucasNoga/corpos-christie/gui"
 	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
 )
 
 // Enum for launched mode
 const (
 	GUI     string = "gui"
 	CONSOLE string = "console"
+	CLI_APP string = "cli"
+	SERVER  string = "server"
 )
 
 // Start Core program
@@ -28,14 +32,18 @@ func Start(cfg *config.Config, user *user.User) {
 	case GUI:
 		gui.GUI{Config: cfg, User: user}.Start()
 	case CONSOLE:
-		Console{Config: cfg, User: user}.Start()
+		Console{Config: cfg, User: user, Prompter: utils.NewPrompter(os.Stdin, os.Stdout), Pause: 700 * time.Millisecond}.Start()
+	case SERVER:
+		StartServer(cfg, os.Args[2:])
+	case CLI_APP:
+		os.Exit(CLI{Config: cfg, Stdout: os.Stdout, Stderr: os.Stderr}.Run(os.Args[1:]))
 	default:
 		gui.GUI{Config: cfg, User: user}.Start()
 	}
 }
 
 // selectMode Check args passed in launch
-// returns which mode app to launch between GUI or console
+// returns which mode app to launch between GUI, console, HTTP server or command line if a subcommand is given
 func selectMode(args []string) string {
 	// if no args specified launch GUI
 	if len(args) < 2 {
@@ -47,7 +55,12 @@ func selectMode(args []string) string {
 			return GUI
 		case "--console":
 			return CONSOLE
+		case "--server":
+			return SERVER
 		default:
+			if isSubcommand(m) {
+				return CLI_APP
+			}
 			return GUI
 		}
 	}
@@ -0,0 +1,88 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/LucasNoga/corpos-christie/calculator"
+	"github.com/LucasNoga/corpos-christie/export"
+	"github.com/LucasNoga/corpos-christie/i18n"
+	"github.com/LucasNoga/corpos-christie/settings"
+	"github.com/LucasNoga/corpos-christie/user"
+)
+
+// report write the PDF report of the tax calculated from the income given in flags
+func (app CLI) report(args []string) error {
+	var household householdFlags
+	var income amountFlag
+	var output, language, currency string
+	var flags = app.newFlagSet("report")
+	flags.Var(&income, "income", "Taxable income in euros (revenu net imposable), required")
+	flags.BoolVar(&household.couple, "couple", false, "Declaration of a couple (married or pacsed)")
+	flags.IntVar(&household.children, "children", 0, "Number of dependent children")
+	flags.IntVar(&household.year, "year", app.Config.GetTax().Year, "Year of the tax scale")
+	flags.StringVar(&output, "output", "", "Path of the PDF file, '-' for the standard output, required")
+	var saved = settings.Get()
+	flags.StringVar(&language, "lang", saved.Language, "Language of the report: en or fr (default from the GUI settings)")
+	flags.StringVar(&currency, "currency", saved.Currency, "Currency symbol of the amounts (default from the GUI settings)")
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if !income.set {
+		return fmt.Errorf("%w: flag --income is required", errUsage)
+	}
+	if output == "" {
+		return fmt.Errorf("%w: flag --output is required", errUsage)
+	}
+	texts, err := i18n.LoadLanguage(language)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	calc, err := calculator.NewFromConfig(app.Config)
+	if err != nil {
+		return err
+	}
+	result, err := calc.Calculate(household.toHousehold(income.value), household.year)
+	if err != nil {
+		return checkCalculation(err)
+	}
+
+	var document = export.Document{
+		User:     user.User{Income: income.value, IsInCouple: household.couple, Children: household.children},
+		Result:   result,
+		Language: texts,
+		Currency: currency,
+		Date:     time.Now(),
+	}
+	return app.writeFile(output, document.WritePDF)
+}
+
+// writeFile write a document into the file path with the function write, '-' is the standard output
+// the file is removed if the document can't be written
+func (app CLI) writeFile(path string, write func(w io.Writer) error) error {
+	if path == "-" {
+		return write(app.Stdout)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(app.Stderr, "Report written into %s\n", path)
+	return nil
+}
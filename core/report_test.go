@@ -0,0 +1,35 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+package core
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd core
+// $ go test -v
+
+// Write the PDF report into a file and into the standard output
+func TestCLIReport(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "report.pdf")
+	code, _, stderr := runCLI(t, "report", "--income", "60000", "--couple", "--children", "2", "--year", "2023", "--lang", "fr", "--output", path)
+	if code != EXIT_SUCCESS {
+		t.Fatalf("Expected the exit code %s, got %s (%s)", colors.Red(EXIT_SUCCESS), colors.Red(code), stderr)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !bytes.HasPrefix(data, []byte("%PDF-")) {
+		t.Errorf("Expected a PDF file, got %v", err)
+	}
+
+	code, stdout, _ := runCLI(t, "report", "--income", "30000", "--currency", "$", "--output", "-")
+	if code != EXIT_SUCCESS || !bytes.HasPrefix([]byte(stdout), []byte("%PDF-")) {
+		t.Errorf("Expected a PDF on the standard output, got %s", colors.Red(code))
+	}
+}
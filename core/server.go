@@ -0,0 +1,312 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+package core
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LucasNoga/corpos-christie/calculator"
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/tax"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPI is the OpenAPI document of the HTTP API
+//
+//go:embed openapi.yaml
+var openAPI []byte
+
+// Fields accepted in the body of the requests
+var (
+	TAX_FIELDS         = []string{"income", "couple", "children", "year", "donations", "aid_donations", "home_employment", "childcare_expenses", "young_children"}
+	REVERSE_TAX_FIELDS = []string{"net", "couple", "children", "year"}
+)
+
+// Server represents the HTTP API exposing the calculator
+// The calculator only reads its scales so it's shared by the requests
+type Server struct {
+	calculator *calculator.Calculator // Calculator with the scales of each year
+}
+
+// apiError is the body of the responses in error
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// yearsResponse is the body of the response of the list of years
+type yearsResponse struct {
+	Default int          `json:"default"`
+	Years   []yearReport `json:"years"`
+}
+
+// httpError is an error with the HTTP status to respond
+type httpError struct {
+	status  int
+	message string
+}
+
+// Error returns the message of the error
+func (err httpError) Error() string {
+	return err.message
+}
+
+// NewServer create the HTTP API with the scales of the configuration
+func NewServer(cfg *config.Config) (*Server, error) {
+	calculator, err := calculator.NewFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{calculator: calculator}, nil
+}
+
+// StartServer launch the HTTP API on the address given with --addr until the program is interrupted
+func StartServer(cfg *config.Config, args []string) {
+	var address string
+	var flags = flag.NewFlagSet("server", flag.ExitOnError)
+	flags.StringVar(&address, "addr", config.SERVER_ADDRESS, "Address to listen to")
+	flags.Parse(args)
+
+	server, err := NewServer(cfg)
+	if err != nil {
+		log.Fatalf("Error: creating server, details: %v", err)
+	}
+
+	var httpServer = &http.Server{
+		Addr:              address,
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+
+	// Stop the server properly on interruption
+	var stop = make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	go func() {
+		<-stop
+		var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			log.Printf("Error: stopping server, details: %v", err)
+		}
+	}()
+
+	log.Printf("Server listening on http://%s (OpenAPI document on /v1/openapi.yaml)", address)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Error: starting server, details: %v", err)
+	}
+	log.Println("Server stopped")
+}
+
+// Handler returns the routes of the HTTP API
+func (server *Server) Handler() http.Handler {
+	var mux = http.NewServeMux()
+	mux.HandleFunc("/v1/tax", server.handle(http.MethodPost, server.calculateTax))
+	mux.HandleFunc("/v1/reverse-tax", server.handle(http.MethodPost, server.calculateReverseTax))
+	mux.HandleFunc("/v1/years", server.handle(http.MethodGet, server.getYears))
+	mux.HandleFunc("/v1/years/", server.handle(http.MethodGet, server.getTranches))
+	mux.HandleFunc("/v1/openapi.yaml", serveOpenAPI)
+	mux.HandleFunc("/v1/openapi.json", server.handle(http.MethodGet, server.getOpenAPI))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, apiError{Error: fmt.Sprintf("route %s not found", r.URL.Path)})
+	})
+	return logRequests(mux)
+}
+
+// handle check the method of the request then write the response of the handler in JSON
+func (server *Server) handle(method string, handler func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeJSON(w, http.StatusMethodNotAllowed, apiError{Error: fmt.Sprintf("method %s not allowed, expected %s", r.Method, method)})
+			return
+		}
+
+		response, err := handler(r)
+		if err != nil {
+			var status = http.StatusInternalServerError
+			var httpErr httpError
+			if errors.As(err, &httpErr) {
+				status = httpErr.status
+			}
+			writeJSON(w, status, apiError{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// calculateTax calculate the tax of the household of the body
+func (server *Server) calculateTax(r *http.Request) (interface{}, error) {
+	fields, err := readBody(r, TAX_FIELDS)
+	if err != nil {
+		return nil, err
+	}
+	if fields["income"] == "" {
+		return nil, httpError{http.StatusBadRequest, "income is missing"}
+	}
+
+	household, year, err := parseHousehold(fields, 0)
+	if err != nil {
+		return nil, getHTTPError(err)
+	}
+	result, err := server.calculator.Calculate(household, year)
+	if err != nil {
+		return nil, getHTTPError(err)
+	}
+	return tax.NewReport(result), nil
+}
+
+// calculateReverseTax estimate the income of the household of the body from its remainder
+func (server *Server) calculateReverseTax(r *http.Request) (interface{}, error) {
+	fields, err := readBody(r, REVERSE_TAX_FIELDS)
+	if err != nil {
+		return nil, err
+	}
+	if fields["net"] == "" {
+		return nil, httpError{http.StatusBadRequest, "net is missing"}
+	}
+	net, err := parseAmountField(fields, "net")
+	if err != nil {
+		return nil, getHTTPError(err)
+	}
+
+	household, year, err := parseHousehold(fields, 0)
+	if err != nil {
+		return nil, getHTTPError(err)
+	}
+	result, err := server.calculator.CalculateReverse(household, net, year)
+	if err != nil {
+		return nil, getHTTPError(err)
+	}
+	return tax.NewReport(result), nil
+}
+
+// getYears returns the list of years available
+func (server *Server) getYears(r *http.Request) (interface{}, error) {
+	var years = server.calculator.Years()
+	var response = yearsResponse{Default: server.calculator.DefaultYear(), Years: make([]yearReport, 0, len(years))}
+	for _, year := range years {
+		scale, err := server.calculator.Scale(year)
+		if err != nil {
+			return nil, err
+		}
+		response.Years = append(response.Years, yearReport{Year: year, Source: scale.Source, Default: year == response.Default})
+	}
+	return response, nil
+}
+
+// getTranches returns the scale of the year of the path /v1/years/{year}/tranches
+func (server *Server) getTranches(r *http.Request) (interface{}, error) {
+	var parts = strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/years/"), "/")
+	if len(parts) != 2 || parts[1] != "tranches" {
+		return nil, httpError{http.StatusNotFound, fmt.Sprintf("route %s not found", r.URL.Path)}
+	}
+	year, err := strconv.Atoi(parts[0])
+	if err != nil || year == 0 {
+		return nil, httpError{http.StatusBadRequest, fmt.Sprintf("year %q is not a valid number", parts[0])}
+	}
+	scale, err := server.calculator.Scale(year)
+	if err != nil {
+		return nil, getHTTPError(err)
+	}
+	return tax.NewScaleReport(scale), nil
+}
+
+// getHTTPError returns the error with the status 404 if the year is not available, 400 otherwise
+func getHTTPError(err error) error {
+	var yearError *calculator.YearError
+	if errors.As(err, &yearError) {
+		return httpError{http.StatusNotFound, err.Error()}
+	}
+	return httpError{http.StatusBadRequest, err.Error()}
+}
+
+// serveOpenAPI write the OpenAPI document in YAML
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(openAPI)
+}
+
+// getOpenAPI returns the OpenAPI document converted in JSON
+func (server *Server) getOpenAPI(r *http.Request) (interface{}, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(openAPI, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// readBody read the JSON object of the body of the request into the fields of a household
+// returns an error with the status 400 if the body is not a JSON object or has unknown fields
+func readBody(r *http.Request, allowed []string) (map[string]string, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, config.SERVER_MAX_BODY))
+	if err != nil {
+		return nil, httpError{http.StatusRequestEntityTooLarge, fmt.Sprintf("body can't be read, details: %v", err)}
+	}
+
+	var object map[string]interface{}
+	var decoder = json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&object); err != nil {
+		return nil, httpError{http.StatusBadRequest, fmt.Sprintf("body should be a JSON object, details: %v", err)}
+	}
+	if object == nil {
+		return nil, httpError{http.StatusBadRequest, "body should be a JSON object"}
+	}
+
+	var fields = getJSONFields(object)
+	for name := range fields {
+		if !contains(allowed, name) {
+			return nil, httpError{http.StatusBadRequest, fmt.Sprintf("unknown field %q, expected %s", name, strings.Join(allowed, ", "))}
+		}
+	}
+	return fields, nil
+}
+
+// writeJSON write the response in JSON with the status
+func writeJSON(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error: writing response, details: %v", err)
+	}
+}
+
+// statusRecorder keep the status written by a handler to log it
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader keep the status then write it
+func (recorder *statusRecorder) WriteHeader(status int) {
+	recorder.status = status
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests log the method, the path, the status and the duration of each request
+func logRequests(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var start = time.Now()
+		var recorder = &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(recorder, r)
+		log.Printf("%s %s %d %s", r.Method, r.URL.Path, recorder.status, time.Since(start))
+	})
+}
@@ -0,0 +1,164 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/tax"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd core
+// $ go test -v
+
+// request send a request to the handler of the server
+// returns the status and the body of the response
+func request(t *testing.T, handler http.Handler, method string, path string, body string) (int, string) {
+	var r = httptest.NewRequest(method, path, strings.NewReader(body))
+	var w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	data, _ := io.ReadAll(w.Result().Body)
+	return w.Code, string(data)
+}
+
+// newTestServer create the server with the embedded scales
+func newTestServer(t *testing.T) *Server {
+	server, err := NewServer(newTestConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+// Calculate the tax of a household in JSON
+func TestServerTax(t *testing.T) {
+	var handler = newTestServer(t).Handler()
+	status, body := request(t, handler, http.MethodPost, "/v1/tax", `{"income": 60000, "couple": true, "children": 2, "year": 2023}`)
+	t.Logf("Function result:\t%s", body)
+
+	var report tax.Report
+	if err := json.Unmarshal([]byte(body), &report); err != nil || status != http.StatusOK {
+		t.Fatalf("Expected a valid JSON, got %d %v", status, err)
+	}
+	if report.Year != 2023 || report.Tax != 3043 || len(report.Tranches) != 5 || *report.Tranches[1].Tax != 3043.59 {
+		t.Errorf("Expected that the tax %s should be equal to %s", colors.Red(3043), colors.Red(report.Tax))
+	}
+}
+
+// Estimate the income of a household from its remainder
+func TestServerReverseTax(t *testing.T) {
+	var handler = newTestServer(t).Handler()
+	status, body := request(t, handler, http.MethodPost, "/v1/reverse-tax", `{"net": "40000"}`)
+
+	var report tax.Report
+	if err := json.Unmarshal([]byte(body), &report); err != nil || status != http.StatusOK {
+		t.Fatalf("Expected a valid JSON, got %d %v", status, err)
+	}
+	if report.Year != 2024 || report.Remainder < 40000 || report.Remainder >= 40001 {
+		t.Errorf("Expected that the remainder %s should be equal to %s", colors.Red(40000), colors.Red(report.Remainder))
+	}
+}
+
+// List the years and get the scale of a year
+func TestServerYears(t *testing.T) {
+	var handler = newTestServer(t).Handler()
+
+	status, body := request(t, handler, http.MethodGet, "/v1/years", "")
+	var years yearsResponse
+	if err := json.Unmarshal([]byte(body), &years); err != nil || status != http.StatusOK || years.Default != 2024 || len(years.Years) != 6 || years.Years[0].Year != 2024 {
+		t.Errorf("Expected the list of years, got %s", colors.Red(body))
+	}
+
+	status, body = request(t, handler, http.MethodGet, "/v1/years/2022/tranches", "")
+	var scale tax.ScaleReport
+	if err := json.Unmarshal([]byte(body), &scale); err != nil || status != http.StatusOK || scale.Year != 2022 || scale.Tranches[1].Min != 10226 {
+		t.Errorf("Expected the scale of %s, got %s", colors.Red(2022), colors.Red(body))
+	}
+}
+
+// Serve the OpenAPI document in YAML and in JSON
+func TestServerOpenAPI(t *testing.T) {
+	var handler = newTestServer(t).Handler()
+
+	status, body := request(t, handler, http.MethodGet, "/v1/openapi.yaml", "")
+	if status != http.StatusOK || !strings.HasPrefix(body, "openapi: 3") {
+		t.Errorf("Expected the OpenAPI document in YAML, got %s", colors.Red(status))
+	}
+
+	status, body = request(t, handler, http.MethodGet, "/v1/openapi.json", "")
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &document); err != nil || status != http.StatusOK || document["paths"] == nil {
+		t.Errorf("Expected the OpenAPI document in JSON, got %s %v", colors.Red(status), err)
+	}
+}
+
+// Invalid requests returns an error in JSON with the status
+func TestServerErrors(t *testing.T) {
+	var tests = []struct {
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{http.MethodGet, "/v1/tax", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/v1/tax", "", http.StatusBadRequest},
+		{http.MethodPost, "/v1/tax", "[1, 2]", http.StatusBadRequest},
+		{http.MethodPost, "/v1/tax", "null", http.StatusBadRequest},
+		{http.MethodPost, "/v1/tax", `{"couple": true}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/tax", `{"income": -1}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/tax", `{"income": 30000, "children": 1.5}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/tax", `{"income": 30000, "salary": 1}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/tax", `{"income": 30000, "year": 1990}`, http.StatusNotFound},
+		{http.MethodPost, "/v1/reverse-tax", `{"income": 30000}`, http.StatusBadRequest},
+		{http.MethodGet, "/v1/years/abc/tranches", "", http.StatusBadRequest},
+		{http.MethodGet, "/v1/years/1990/tranches", "", http.StatusNotFound},
+		{http.MethodGet, "/v1/years/2024", "", http.StatusNotFound},
+		{http.MethodGet, "/v2/tax", "", http.StatusNotFound},
+	}
+
+	var handler = newTestServer(t).Handler()
+	for _, test := range tests {
+		status, body := request(t, handler, test.method, test.path, test.body)
+		var response apiError
+		if err := json.Unmarshal([]byte(body), &response); err != nil || status != test.expected || response.Error == "" {
+			t.Errorf("Expected that the status of %s %s %s should be equal to %s, got %s", test.method, test.path, test.body, colors.Red(test.expected), colors.Red(status))
+		}
+	}
+}
+
+// Requests of different years at the same time don't share the year of the scale
+func TestServerConcurrentYears(t *testing.T) {
+	var handler = newTestServer(t).Handler()
+	var expected = map[int]float64{}
+	for _, year := range []int{2019, 2020, 2021, 2022, 2023, 2024} {
+		_, body := request(t, handler, http.MethodPost, "/v1/tax", fmt.Sprintf(`{"income": 45000, "year": %d}`, year))
+		var report tax.Report
+		json.Unmarshal([]byte(body), &report)
+		expected[year] = report.Tax
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 60; i++ {
+		wg.Add(1)
+		go func(year int) {
+			defer wg.Done()
+			_, body := request(t, handler, http.MethodPost, "/v1/tax", fmt.Sprintf(`{"income": 45000, "year": %d}`, year))
+			var report tax.Report
+			json.Unmarshal([]byte(body), &report)
+			if report.Year != year || report.Tax != expected[year] {
+				t.Errorf("Expected that the tax of %d %s should be equal to %s", year, colors.Red(expected[year]), colors.Red(report.Tax))
+			}
+		}(2019 + i%6)
+	}
+	wg.Wait()
+}
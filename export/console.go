@@ -0,0 +1,153 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package export writes the result of a tax simulation into documents to share (PDF, CSV, XLSX)
+package export
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/i18n"
+	"github.com/LucasNoga/corpos-christie/settings"
+	"github.com/LucasNoga/corpos-christie/tax"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// Contents which can be exported from the console
+const (
+	CONTENT_RESULTS string = "results" // Results of the household of the user
+	CONTENT_SCALES  string = "scales"  // Tranches of the tax scales
+)
+
+// StartExport export the results of the household of the user for the years asked or the tax scales
+// into a CSV or XLSX file with the headers in the language asked (the language of the GUI settings by default), each answer is asked again while it's not valid
+func StartExport(prompter *utils.Prompter, cfg *config.Config, user *user.User) {
+	var content string
+	err := prompter.Ask(fmt.Sprintf("What do you want to export (%s/%s) ? ", CONTENT_RESULTS, CONTENT_SCALES), func(input string) error {
+		if input != CONTENT_RESULTS && input != CONTENT_SCALES {
+			return fmt.Errorf("you have to answer by %s or %s", CONTENT_RESULTS, CONTENT_SCALES)
+		}
+		content = input
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error: asking content to export, details: %v", err)
+		return
+	}
+
+	// Years of the results or of the scales, the year selected by default
+	var scales []config.Tax
+	err = prompter.Ask(fmt.Sprintf("Years to export separated by spaces (empty for %d, 'all' for every year) ? ", cfg.GetTax().Year), func(input string) error {
+		scales = nil
+		switch input {
+		case "":
+			scales = []config.Tax{cfg.GetTax()}
+			return nil
+		case "all":
+			scales = cfg.TaxList
+			return nil
+		}
+		for _, field := range strings.Fields(input) {
+			year, err := strconv.Atoi(field)
+			if err != nil {
+				return fmt.Errorf("'%s' is not a year", field)
+			}
+			scale, err := cfg.FindTax(year)
+			if err != nil {
+				return err
+			}
+			scales = append(scales, scale)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error: asking years to export, details: %v", err)
+		return
+	}
+
+	// Language of the headers, the language saved in the GUI settings by default
+	var language i18n.Yaml
+	var defaultLanguage = settings.Get().Language
+	err = prompter.Ask(fmt.Sprintf("Language of the headers (%s/%s, empty for %s) ? ", i18n.ENGLISH, i18n.FRENCH, defaultLanguage), func(input string) error {
+		if input == "" {
+			input = defaultLanguage
+		}
+		if input != i18n.ENGLISH && input != i18n.FRENCH {
+			return fmt.Errorf("the language '%s' is not available", input)
+		}
+		var err error
+		language, err = i18n.LoadLanguage(input)
+		return err
+	})
+	if err != nil {
+		log.Printf("Error: asking language of export, details: %v", err)
+		return
+	}
+
+	var path string
+	err = prompter.Ask(fmt.Sprintf("Path of the file (.%s or .%s) ? ", FORMAT_CSV, FORMAT_XLSX), func(input string) error {
+		path = input
+		_, err := GetFormat(input)
+		return err
+	})
+	if err != nil {
+		log.Printf("Error: asking path of export, details: %v", err)
+		return
+	}
+	if _, err := os.Stat(path); err == nil {
+		replace, err := prompter.AskYesNo("The file already exists, do you want to replace it (Y/n) ? ")
+		if err != nil || !replace {
+			prompter.Println("Nothing exported")
+			return
+		}
+	}
+
+	var table Table
+	if content == CONTENT_RESULTS {
+		var results = make([]tax.Result, 0, len(scales))
+		for _, scale := range scales {
+			var yearConfig = *cfg
+			yearConfig.Tax = scale
+			results = append(results, tax.CalculateTax(*user, &yearConfig))
+		}
+		table = NewResultsTable(results, language)
+	} else {
+		table = NewScalesTable(scales, language)
+	}
+
+	if err := WriteFile(path, table); err != nil {
+		log.Printf("Error: exporting %s, details: %v", content, err)
+		prompter.Println(colors.Red("Nothing exported"))
+		return
+	}
+	prompter.Printf("%d rows of %s exported into %s\n", len(table.Rows), content, colors.Teal(path))
+}
+
+// WriteFile write the table into the file path in the format of its extension (.csv or .xlsx)
+// the file is removed if the table can't be written
+func WriteFile(path string, table Table) error {
+	format, err := GetFormat(path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = WriteTable(file, format, table)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package export writes the result of a tax simulation into documents to share (PDF, CSV, XLSX)
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/i18n"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/tax"
+	"github.com/LucasNoga/corpos-christie/user"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Layout of the PDF in millimeters on an A4 page
+const (
+	PDF_FONT        string  = "Helvetica" // Core font of PDF readers, no font file needed
+	PDF_LINE_HEIGHT float64 = 7           // Height of a line of text or of a row of table
+	PDF_LABEL_WIDTH float64 = 80          // Width of the labels of the summaries
+)
+
+// Document is a tax simulation to export with the language and the currency of the reader
+type Document struct {
+	User     user.User  // Household of the simulation
+	Result   tax.Result // Result of the tax calculation of the household
+	Language i18n.Yaml  // Texts of the language of the reader
+	Currency string     // Symbol of the currency of the amounts (ex: €)
+	Date     time.Time  // Date of generation of the document
+}
+
+// WritePDF write the document in PDF into w with the household, the results and the details of each tranche
+// returns an error if the PDF can't be generated
+func (document Document) WritePDF(w io.Writer) error {
+	var texts = document.Language.Report
+	var pdf = gofpdf.New("P", "mm", "A4", "")
+	var translate = pdf.UnicodeTranslatorFromDescriptor("") // cp1252 for the accents and the euro sign
+
+	pdf.SetTitle(texts.Title, true)
+	pdf.SetCreator(fmt.Sprintf("%s v%s", config.APP_NAME, config.APP_VERSION), true)
+	pdf.SetCreationDate(document.Date)
+	pdf.SetModificationDate(document.Date)
+	pdf.SetCatalogSort(true) // same file for the same document
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont(PDF_FONT, "I", 8)
+		pdf.SetTextColor(128, 128, 128)
+		var footer = fmt.Sprintf("%s %s - %s v%s", texts.Generated, document.Date.Format("2006-01-02 15:04"), config.APP_NAME, config.APP_VERSION)
+		pdf.CellFormat(0, 10, translate(footer), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	// Title
+	pdf.SetFont(PDF_FONT, "B", 18)
+	pdf.CellFormat(0, 12, translate(texts.Title), "", 1, "L", false, 0, "")
+	pdf.SetFont(PDF_FONT, "", 11)
+	pdf.CellFormat(0, PDF_LINE_HEIGHT, translate(fmt.Sprintf("%s %d", texts.Year, document.Result.Year)), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	// Household
+	var status = texts.Single
+	if document.User.IsInCouple {
+		status = texts.Couple
+	}
+	writePDFSection(pdf, translate, texts.Household, [][2]string{
+		{texts.Income, document.amount(document.Result.Income)},
+		{texts.Status, status},
+		{texts.Children, fmt.Sprintf("%d", document.User.Children)},
+		{texts.Shares, fmt.Sprintf("%g", document.Result.Shares)},
+	})
+
+	// Results
+	var results [][2]string
+	if document.Result.Decote > 0 {
+		results = append(results, [2]string{texts.Decote, "-" + document.amount(document.Result.Decote)})
+	}
+	results = append(results, [2]string{texts.Tax, document.amount(document.Result.Tax)})
+	if document.Result.HighIncomeTax > 0 {
+		results = append(results, [2]string{texts.HighIncomeTax, document.amount(document.Result.HighIncomeTax)})
+	}
+	if document.Result.NetTax != document.Result.Tax {
+		results = append(results, [2]string{texts.NetTax, document.amount(document.Result.NetTax)})
+	}
+	results = append(results,
+		[2]string{texts.Remainder, document.amount(document.Result.Remainder)},
+		[2]string{texts.MarginalRate, fmt.Sprintf("%g %%", document.Result.MarginalRate)},
+		[2]string{texts.AverageRate, fmt.Sprintf("%.1f %%", document.Result.AverageRate)},
+	)
+	writePDFSection(pdf, translate, texts.Results, results)
+
+	// Tranches like in the console
+	writePDFTitle(pdf, translate, texts.Tranches)
+	var headers = document.Language.GetTaxHeaders()
+	var widths = []float64{34, 38, 38, 30, 40}
+	pdf.SetFont(PDF_FONT, "B", 10)
+	pdf.SetFillColor(230, 230, 230)
+	for i, header := range headers {
+		pdf.CellFormat(widths[i], PDF_LINE_HEIGHT, translate(header), "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	for index, taxTranche := range document.Result.TaxTranches {
+		var max = "-"
+		if taxTranche.Tranche.Max != money.MAX {
+			max = document.amount(taxTranche.Tranche.Max)
+		}
+		var row = []string{
+			fmt.Sprintf("%s %d", texts.Tranche, index+1),
+			document.amount(taxTranche.Tranche.Min),
+			max,
+			fmt.Sprintf("%s %%", strings.TrimSuffix(taxTranche.Tranche.Rate, "%")),
+			document.amount(taxTranche.Tax),
+		}
+
+		// the tranche of the last euro of income is in bold
+		var style = ""
+		if index == document.Result.MarginalTranche {
+			style = "B"
+		}
+		pdf.SetFont(PDF_FONT, style, 10)
+		for i, value := range row {
+			var align = "R"
+			if i == 0 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[i], PDF_LINE_HEIGHT, translate(value), "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+// amount format the amount with the currency of the document (ex: 3043.59 €)
+func (document Document) amount(amount money.Money) string {
+	return fmt.Sprintf("%s %s", amount, document.Currency)
+}
+
+// writePDFSection write a title then the lines of label and value
+func writePDFSection(pdf *gofpdf.Fpdf, translate func(string) string, title string, lines [][2]string) {
+	writePDFTitle(pdf, translate, title)
+	pdf.SetFont(PDF_FONT, "", 11)
+	for _, line := range lines {
+		pdf.CellFormat(PDF_LABEL_WIDTH, PDF_LINE_HEIGHT, translate(line[0]), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, PDF_LINE_HEIGHT, translate(line[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+}
+
+// writePDFTitle write the title of a section underlined
+func writePDFTitle(pdf *gofpdf.Fpdf, translate func(string) string, title string) {
+	pdf.SetFont(PDF_FONT, "B", 13)
+	pdf.CellFormat(0, 9, translate(title), "B", 1, "L", false, 0, "")
+	pdf.Ln(2)
+}
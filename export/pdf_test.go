@@ -0,0 +1,106 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package export writes the result of a tax simulation into documents to share (PDF, CSV, XLSX)
+package export
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/LucasNoga/corpos-christie/calculator"
+	"github.com/LucasNoga/corpos-christie/i18n"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd export
+// $ go test -v
+
+// newTestDocument create the document of a couple with 2 children in 2023 in the language
+func newTestDocument(t *testing.T, code string) Document {
+	calc, err := calculator.NewDefault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := calc.Calculate(calculator.Household{Income: money.Euros(60000), IsInCouple: true, Children: 2}, 2023)
+	if err != nil {
+		t.Fatal(err)
+	}
+	language, err := i18n.LoadLanguage(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Document{
+		User:     user.User{Income: money.Euros(60000), IsInCouple: true, Children: 2},
+		Result:   result,
+		Language: language,
+		Currency: "€",
+		Date:     time.Date(2024, time.March, 1, 10, 30, 0, 0, time.UTC),
+	}
+}
+
+// readPDFText returns the content of the streams of the PDF uncompressed
+func readPDFText(t *testing.T, pdf []byte) string {
+	var text bytes.Buffer
+	for {
+		var start = bytes.Index(pdf, []byte("stream\n"))
+		if start < 0 {
+			return text.String()
+		}
+		pdf = pdf[start+len("stream\n"):]
+		var end = bytes.Index(pdf, []byte("\nendstream"))
+		if end < 0 {
+			return text.String()
+		}
+		if reader, err := zlib.NewReader(bytes.NewReader(pdf[:end])); err == nil {
+			io.Copy(&text, reader)
+		}
+		pdf = pdf[end+len("\nendstream"):]
+	}
+}
+
+// Write the PDF of a simulation in french with the household, the results and the tranches
+func TestWritePDF(t *testing.T) {
+	var document = newTestDocument(t, i18n.FRENCH)
+	var pdf bytes.Buffer
+	if err := document.WritePDF(&pdf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(pdf.Bytes(), []byte("%PDF-")) {
+		t.Fatalf("Expected a PDF, got %s", colors.Red(pdf.String()[:10]))
+	}
+
+	var text = readPDFText(t, pdf.Bytes())
+	var expected = []string{
+		"(Bar\xe8me de 2023)",                  // year in cp1252
+		"(Revenu net imposable)",               // household
+		"(60000 \x80)",                         // income with the euro sign
+		"(D\xe9cote)",                          // discount
+		"(3043 \x80)",                          // tax
+		"(Tranche 2)",                          // tranches
+		"(3043.59 \x80)",                       // tax of the tranche
+		"(G\xe9n\xe9r\xe9 le 2024-03-01 10:30", // date of generation
+	}
+	for _, value := range expected {
+		if !bytes.Contains([]byte(text), []byte(value)) {
+			t.Errorf("Expected the text %s in the PDF", colors.Red(value))
+		}
+	}
+}
+
+// The PDF is the same for the same document and date
+func TestWritePDFReproducible(t *testing.T) {
+	var document = newTestDocument(t, i18n.ENGLISH)
+	var first, second bytes.Buffer
+	document.WritePDF(&first)
+	document.WritePDF(&second)
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("Expected the same PDF for the same document")
+	}
+}
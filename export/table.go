@@ -0,0 +1,146 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package export writes the result of a tax simulation into documents to share (PDF, CSV, XLSX)
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/i18n"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/tax"
+)
+
+// Formats of the files of raw data
+const (
+	FORMAT_CSV  string = "csv"
+	FORMAT_XLSX string = "xlsx"
+)
+
+// ErrUnknownFormat is returned when the format of a file is not FORMAT_CSV nor FORMAT_XLSX
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Table is a sheet of raw data to export, the amounts are in euros without currency
+// so they can be read as numbers by the spreadsheets
+type Table struct {
+	Name   string     // Name of the sheet in a workbook
+	Header []string   // Localized names of the columns
+	Rows   [][]string // Values of each row in the order of the header
+}
+
+// NewResultsTable create the table of the results with a row for each result
+// and the tax of each tranche of the scale of the result in the last columns
+func NewResultsTable(results []tax.Result, language i18n.Yaml) Table {
+	var texts = language.Report
+	var table = Table{
+		Name: texts.Results,
+		Header: []string{
+			texts.TaxYear, texts.Income, texts.Shares, texts.Decote, texts.Tax, texts.HighIncomeTax,
+			texts.NetTax, texts.Remainder, texts.MarginalRate, texts.AverageRate,
+		},
+	}
+
+	// a column for the tax of each tranche of the longest scale
+	var tranches int
+	for _, result := range results {
+		if len(result.TaxTranches) > tranches {
+			tranches = len(result.TaxTranches)
+		}
+	}
+	for i := 1; i <= tranches; i++ {
+		table.Header = append(table.Header, fmt.Sprintf("%s %s %d", language.TaxHeaders.Header5, texts.Tranche, i))
+	}
+
+	for _, result := range results {
+		var row = []string{
+			strconv.Itoa(result.Year),
+			result.Income.String(),
+			strconv.FormatFloat(result.Shares, 'f', -1, 64),
+			result.Decote.String(),
+			result.Tax.String(),
+			result.HighIncomeTax.String(),
+			result.NetTax.String(),
+			result.Remainder.String(),
+			strconv.FormatFloat(result.MarginalRate, 'f', -1, 64),
+			strconv.FormatFloat(result.AverageRate, 'f', 2, 64),
+		}
+		for i := 0; i < tranches; i++ {
+			var value string
+			if i < len(result.TaxTranches) {
+				value = result.TaxTranches[i].Tax.String()
+			}
+			row = append(row, value)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// NewScalesTable create the table of the tranches of the scales with a row for each tranche of each year
+// the maximum of the last tranche is empty and the rates are in percent
+func NewScalesTable(scales []config.Tax, language i18n.Yaml) Table {
+	var headers = language.GetTaxHeaders() // the column of the tax is not used in a scale
+	var table = Table{
+		Name:   language.Report.Scales,
+		Header: append([]string{language.Report.TaxYear}, headers[:4]...),
+	}
+
+	for _, scale := range scales {
+		for index, tranche := range scale.Tranches {
+			var max string
+			if tranche.Max != money.MAX {
+				max = tranche.Max.String()
+			}
+			table.Rows = append(table.Rows, []string{
+				strconv.Itoa(scale.Year),
+				strconv.Itoa(index + 1),
+				tranche.Min.String(),
+				max,
+				strings.TrimSuffix(tranche.Rate, "%"),
+			})
+		}
+	}
+	return table
+}
+
+// GetFormat returns the format of the file from the extension of its path (ex: results.xlsx > xlsx)
+// returns ErrUnknownFormat if the extension is not .csv nor .xlsx
+func GetFormat(path string) (string, error) {
+	var format = strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	switch format {
+	case FORMAT_CSV, FORMAT_XLSX:
+		return format, nil
+	}
+	return "", fmt.Errorf("%w: '%s' (expected .%s or .%s)", ErrUnknownFormat, filepath.Ext(path), FORMAT_CSV, FORMAT_XLSX)
+}
+
+// WriteTable write the table into w in the format FORMAT_CSV or FORMAT_XLSX
+func WriteTable(w io.Writer, format string, table Table) error {
+	switch format {
+	case FORMAT_CSV:
+		return table.WriteCSV(w)
+	case FORMAT_XLSX:
+		return WriteXLSX(w, table)
+	}
+	return fmt.Errorf("%w: '%s'", ErrUnknownFormat, format)
+}
+
+// WriteCSV write the header and the rows of the table into w in CSV
+func (table Table) WriteCSV(w io.Writer) error {
+	var writer = csv.NewWriter(w)
+	if err := writer.Write(table.Header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(table.Rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
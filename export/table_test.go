@@ -0,0 +1,168 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package export writes the result of a tax simulation into documents to share (PDF, CSV, XLSX)
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/i18n"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/tax"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd export
+// $ go test -v
+
+// readCSV returns the records of the table written in CSV
+func readCSV(t *testing.T, table Table) [][]string {
+	var buffer bytes.Buffer
+	if err := table.WriteCSV(&buffer); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	records, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected a valid CSV, got %v", err)
+	}
+	return records
+}
+
+// The results are exported with the headers in french and the amounts in euros without currency
+func TestResultsTableCSV(t *testing.T) {
+	var document = newTestDocument(t, i18n.FRENCH)
+	var records = readCSV(t, NewResultsTable([]tax.Result{document.Result, document.Result}, document.Language))
+
+	if len(records) != 3 {
+		t.Fatalf("Expected %s rows with the header, got %s", colors.Red(3), colors.Red(len(records)))
+	}
+	var header = records[0]
+	if header[0] != "Année" || header[4] != "Impôt sur le revenu" || header[10] != "IMPÔT Tranche 1" {
+		t.Errorf("Expected the headers in french, got %s", colors.Red(header))
+	}
+
+	var row = records[1]
+	var expected = map[int]string{0: "2023", 1: "60000", 2: "3", 3: "1", 4: "3043", 11: "3043.59"}
+	for column, value := range expected {
+		if row[column] != value {
+			t.Errorf("Expected the column %s '%s' equal to %s, got %s", header[column], colors.Red(column), colors.Red(value), colors.Red(row[column]))
+		}
+	}
+	if len(row) != len(header) {
+		t.Errorf("Expected %s columns, got %s", colors.Red(len(header)), colors.Red(len(row)))
+	}
+}
+
+// The scales are exported with a row for each tranche and the last maximum empty
+func TestScalesTableCSV(t *testing.T) {
+	var language, _ = i18n.LoadLanguage(i18n.ENGLISH)
+	var scale = config.Tax{Year: 2024, Tranches: []config.Tranche{
+		{Min: 0, Max: money.Euros(11294), Rate: "0%"},
+		{Min: money.Euros(11295), Max: money.MAX, Rate: "11%"},
+	}}
+	var records = readCSV(t, NewScalesTable([]config.Tax{scale}, language))
+
+	var expected = [][]string{
+		{"Year", "TRANCHE", "MIN", "MAX", "RATE"},
+		{"2024", "1", "0", "11294", "0"},
+		{"2024", "2", "11295", "", "11"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %s rows, got %s", colors.Red(len(expected)), colors.Red(len(records)))
+	}
+	for i := range expected {
+		if strings.Join(records[i], ",") != strings.Join(expected[i], ",") {
+			t.Errorf("Expected the row %s, got %s", colors.Red(expected[i]), colors.Red(records[i]))
+		}
+	}
+}
+
+// The format is the extension of the file
+func TestGetFormat(t *testing.T) {
+	var tests = []struct {
+		path   string
+		format string
+	}{
+		{"results.csv", FORMAT_CSV},
+		{"dir/Results.XLSX", FORMAT_XLSX},
+		{"results.pdf", ""},
+		{"results", ""},
+	}
+
+	for _, test := range tests {
+		format, err := GetFormat(test.path)
+		if format != test.format || (test.format == "") != errors.Is(err, ErrUnknownFormat) {
+			t.Errorf("Expected the format '%s' of %s, got '%s' %v", colors.Red(test.format), test.path, colors.Red(format), err)
+		}
+	}
+}
+
+// The console exports the results of the household for the years asked after asking again the answers not valid
+func TestStartExport(t *testing.T) {
+	cfg, err := config.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var path = filepath.Join(t.TempDir(), "results.csv")
+	var script = strings.Join([]string{"everything", "results", "1990", "2023 2024", "de", "fr", "results.txt", path}, "\n")
+	var output bytes.Buffer
+	var household = user.User{Income: money.Euros(30000)}
+
+	StartExport(utils.NewPrompter(strings.NewReader(script), &output), cfg, &household)
+
+	if count := strings.Count(output.String(), "Invalid response"); count != 4 {
+		t.Errorf("Expected %s invalid responses, got %s:\n%s", colors.Red(4), colors.Red(count), output.String())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected the file %s, got %v", path, err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil || len(records) != 3 || records[1][0] != "2023" || records[2][0] != "2024" || records[2][4] != "2286" {
+		t.Errorf("Expected the results of 2023 and 2024, got %s %v", colors.Red(records), err)
+	}
+}
+
+// The console exports the headers in the language saved in the GUI settings by default
+func TestStartExportSettingsLanguage(t *testing.T) {
+	cfg, err := config.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dir = t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+	if err := os.WriteFile(config.SETTINGS_PATH, []byte(`{"language": "fr"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var path = filepath.Join(dir, "scales.csv")
+	var script = strings.Join([]string{"scales", "2024", "", path}, "\n")
+	StartExport(utils.NewPrompter(strings.NewReader(script), &bytes.Buffer{}), cfg, &user.User{})
+
+	french, err := i18n.LoadLanguage(i18n.FRENCH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.HasPrefix(string(data), french.Report.TaxYear+",") {
+		t.Errorf("Expected the headers in %s, got %s %v", colors.Red(i18n.FRENCH), colors.Red(string(data)), err)
+	}
+}
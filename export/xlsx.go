@@ -0,0 +1,165 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package export writes the result of a tax simulation into documents to share (PDF, CSV, XLSX)
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// XLSX_MAX_SHEET_NAME is the maximum length of the name of a sheet in a workbook
+const XLSX_MAX_SHEET_NAME int = 31
+
+// Parts of the workbook which don't depend on the tables
+const (
+	xlsxHeader        = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+	xlsxRelationships = xlsxHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxStyles = xlsxHeader + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="1"><fill><patternFill patternType="none"/></fill></fills>` +
+		`<borders count="1"><border/></borders>` +
+		`<cellStyleXfs count="1"><xf/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf/><xf fontId="1" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`
+)
+
+// WriteXLSX write the tables into w in a workbook (Office Open XML) with a sheet for each table
+// the header is in bold and the values which are numbers are written as numbers
+func WriteXLSX(w io.Writer, tables ...Table) error {
+	var archive = zip.NewWriter(w)
+	var names = getSheetNames(tables)
+
+	var contentTypes, workbook, workbookRelationships strings.Builder
+	contentTypes.WriteString(xlsxHeader + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	workbook.WriteString(xlsxHeader + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRelationships.WriteString(xlsxHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	for i, name := range names {
+		var id = i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, id)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeXML(name), id, id)
+		fmt.Fprintf(&workbookRelationships, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, id, id)
+	}
+	fmt.Fprintf(&workbookRelationships, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(names)+1)
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRelationships.WriteString(`</Relationships>`)
+
+	type part struct {
+		name    string // Path of the file in the archive
+		content string // XML of the file
+	}
+	var parts = []part{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xlsxRelationships},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRelationships.String()},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for i, table := range tables {
+		parts = append(parts, part{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), table.sheetXML()})
+	}
+
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// sheetXML returns the worksheet of the table with the header in the first row
+func (table Table) sheetXML() string {
+	var sheet strings.Builder
+	sheet.WriteString(xlsxHeader + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	var rows = append([][]string{table.Header}, table.Rows...)
+	for i, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, i+1)
+		for j, value := range row {
+			if value == "" {
+				continue
+			}
+			var reference = fmt.Sprintf("%s%d", getColumnName(j), i+1)
+			var style string
+			if i == 0 {
+				style = ` s="1"`
+			}
+			if _, err := strconv.ParseFloat(value, 64); err == nil && i > 0 {
+				fmt.Fprintf(&sheet, `<c r="%s"%s><v>%s</v></c>`, reference, style, value)
+			} else {
+				fmt.Fprintf(&sheet, `<c r="%s"%s t="inlineStr"><is><t>%s</t></is></c>`, reference, style, escapeXML(value))
+			}
+		}
+		sheet.WriteString(`</row>`)
+	}
+
+	sheet.WriteString(`</sheetData></worksheet>`)
+	return sheet.String()
+}
+
+// getSheetNames returns the names of the sheets of the tables valid in a workbook:
+// without the characters forbidden, not longer than XLSX_MAX_SHEET_NAME and unique
+func getSheetNames(tables []Table) []string {
+	var names = make([]string, 0, len(tables))
+	var used = make(map[string]bool, len(tables))
+	for i, table := range tables {
+		var name = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`[]:*?/\`, r) {
+				return '_'
+			}
+			return r
+		}, strings.TrimSpace(table.Name))
+		if name == "" {
+			name = fmt.Sprintf("Sheet%d", i+1)
+		}
+		if runes := []rune(name); len(runes) > XLSX_MAX_SHEET_NAME {
+			name = string(runes[:XLSX_MAX_SHEET_NAME])
+		}
+		var base = []rune(name)
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			var suffix = fmt.Sprintf(" (%d)", n)
+			var runes = base
+			if len(runes)+len(suffix) > XLSX_MAX_SHEET_NAME {
+				runes = runes[:XLSX_MAX_SHEET_NAME-len(suffix)]
+			}
+			name = string(runes) + suffix
+		}
+		used[strings.ToLower(name)] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// getColumnName returns the name of the column of the index from 0 (ex: 0 > A, 25 > Z, 26 > AA)
+func getColumnName(index int) string {
+	var name string
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// escapeXML returns the text with the special characters of XML escaped
+func escapeXML(text string) string {
+	var escaped strings.Builder
+	_ = xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package export writes the result of a tax simulation into documents to share (PDF, CSV, XLSX)
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd export
+// $ go test -v
+
+// readXLSX returns the content of each file of the workbook
+func readXLSX(t *testing.T, workbook []byte) map[string]string {
+	archive, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	if err != nil {
+		t.Fatalf("Expected a zip archive, got %v", err)
+	}
+	var files = make(map[string]string)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = string(content)
+	}
+	return files
+}
+
+// A workbook has a sheet for each table with the numbers as numbers and the texts escaped
+func TestWriteXLSX(t *testing.T) {
+	var tables = []Table{
+		{Name: "Results", Header: []string{"Year", "Tax & co"}, Rows: [][]string{{"2024", "2286.5"}}},
+		{Name: "Results", Header: []string{"Tranche"}, Rows: [][]string{{"<1>"}}},
+	}
+	var buffer bytes.Buffer
+	if err := WriteXLSX(&buffer, tables...); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var files = readXLSX(t, buffer.Bytes())
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet2.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected the file %s in the workbook", colors.Red(name))
+		}
+	}
+	if !strings.Contains(files["xl/workbook.xml"], `name="Results (2)"`) {
+		t.Errorf("Expected unique sheet names, got %s", colors.Red(files["xl/workbook.xml"]))
+	}
+
+	var sheet = files["xl/worksheets/sheet1.xml"]
+	for _, cell := range []string{
+		`<c r="B1" s="1" t="inlineStr"><is><t>Tax &amp; co</t></is></c>`,
+		`<c r="A2"><v>2024</v></c>`,
+		`<c r="B2"><v>2286.5</v></c>`,
+	} {
+		if !strings.Contains(sheet, cell) {
+			t.Errorf("Expected the cell %s in the sheet %s", colors.Red(cell), sheet)
+		}
+	}
+	if !strings.Contains(files["xl/worksheets/sheet2.xml"], "<t>&lt;1&gt;</t>") {
+		t.Errorf("Expected the text escaped in the sheet %s", colors.Red(files["xl/worksheets/sheet2.xml"]))
+	}
+}
+
+// The names of the columns are letters like in the spreadsheets
+func TestGetColumnName(t *testing.T) {
+	var tests = map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for index, name := range tests {
+		if got := getColumnName(index); got != name {
+			t.Errorf("Expected the column %d named %s, got %s", index, colors.Red(name), colors.Red(got))
+		}
+	}
+}
+
+// The names of the sheets are valid in a workbook
+func TestGetSheetNames(t *testing.T) {
+	var names = getSheetNames([]Table{{Name: "a/b"}, {Name: ""}, {Name: strings.Repeat("x", 40)}, {Name: strings.Repeat("x", 40)}})
+	var expected = []string{"a_b", "Sheet2", strings.Repeat("x", 31), strings.Repeat("x", 27) + " (2)"}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("Expected the sheet name %s, got %s", colors.Red(expected[i]), colors.Red(names[i]))
+		}
+	}
+}
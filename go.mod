@@ -4,6 +4,7 @@ go 1.16
 
 require (
 	fyne.io/fyne/v2 v2.2.3
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/olekukonko/tablewriter v0.0.5
 	go.uber.org/zap v1.21.0
 	gopkg.in/natefinch/lumberjack.v2 v2.0.0
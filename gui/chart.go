@@ -0,0 +1,75 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package gui defines component and script to launch gui application
+package gui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/LucasNoga/corpos-christie/gui/widgets"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/tax"
+	"go.uber.org/zap"
+)
+
+// Range of the incomes of the tax curve
+const (
+	CHART_STEPS      int = 60     // Number of incomes calculated on the curve
+	CHART_MAX_INCOME int = 100000 // Maximum income of the curve in euros, extended to twice the income for the high incomes
+)
+
+// createLayoutCharts Setup the tab with the tax curve and the tax paid in each tranche
+func (gui *GUI) createLayoutCharts() *fyne.Container {
+	gui.labelChartCurve = binding.NewString()
+	gui.labelChartCurve.Set(gui.Language.ChartCurve)
+	gui.labelChartTranches = binding.NewString()
+	gui.labelChartTranches.Set(gui.Language.ChartTranches)
+
+	gui.chartCurve = widgets.CreateLineChart()
+	gui.chartTranches = widgets.CreateStackedBar()
+
+	return container.New(layout.NewGridLayoutWithRows(2),
+		container.NewBorder(widget.NewLabelWithData(gui.labelChartCurve), nil, nil, nil, gui.chartCurve),
+		container.NewBorder(widget.NewLabelWithData(gui.labelChartTranches), nil, nil, nil, gui.chartTranches),
+	)
+}
+
+// setCharts draw the charts of the household of the user with the result of its income
+// the tax and the net income are calculated for CHART_STEPS incomes up to twice the income
+func (gui *GUI) setCharts(result tax.Result) {
+	var max = money.Euros(CHART_MAX_INCOME).Max(result.Income * 2)
+
+	points, err := tax.SimulateRange(*gui.User, gui.Config, money.ZERO, max, max.Mul(1, int64(CHART_STEPS)))
+	if err != nil {
+		gui.Logger.Error("Simulate range", zap.Error(err))
+		return
+	}
+
+	var taxes = make([]widgets.ChartPoint, 0, len(points))
+	var nets = make([]widgets.ChartPoint, 0, len(points))
+	for _, point := range points {
+		var income = point.Income.Euros()
+		taxes = append(taxes, widgets.ChartPoint{X: income, Y: (point.Tax + point.HighIncomeTax).Euros()})
+		nets = append(nets, widgets.ChartPoint{X: income, Y: point.Remainder.Euros()})
+	}
+	gui.chartCurve.SetData([]widgets.ChartSeries{
+		{Name: gui.Language.ChartNet, Color: theme.ColorNamePrimary, Points: nets},
+		{Name: gui.Language.ChartTax, Color: theme.ColorNameError, Points: taxes},
+	}, result.Income.Euros())
+
+	var segments = make([]widgets.ChartSegment, 0, len(result.TaxTranches))
+	for index, taxTranche := range result.TaxTranches {
+		segments = append(segments, widgets.ChartSegment{
+			Name:  fmt.Sprintf("Tranche %d (%s)", index+1, taxTranche.Tranche.Rate),
+			Value: taxTranche.Tax.Euros(),
+		})
+	}
+	gui.chartTranches.SetData(segments)
+}
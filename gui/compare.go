@@ -0,0 +1,207 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package gui defines component and script to launch gui application
+package gui
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"github.com/LucasNoga/corpos-christie/gui/widgets"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/tax"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"go.uber.org/zap"
+)
+
+// compareInputs is the inputs of a scenario in the comparison tab
+type compareInputs struct {
+	selectYear     *widget.Select      // Input Select to choose the year of the tax scale
+	entryIncome    *widget.Entry       // Input Entry to set income
+	radioStatus    *widget.RadioGroup  // Input Radio buttons to get status
+	selectChildren *widget.SelectEntry // Input Select to know how children
+}
+
+// createLayoutCompare Setup the tab comparing the tax of tax.COMPARE_MAX_SCENARIOS scenarios at most
+// a column for each scenario with its inputs then its results and its differences from the first scenario
+func (gui *GUI) createLayoutCompare() *fyne.Container {
+	gui.compareInputs = make([]compareInputs, tax.COMPARE_MAX_SCENARIOS)
+	for index := range gui.compareInputs {
+		var inputs = compareInputs{
+			selectYear:     widgets.CreateYearSelect(gui.getYears(), utils.ConvertIntToString(gui.Config.GetTax().Year)),
+			entryIncome:    widgets.CreateIncomeEntry(),
+			radioStatus:    widgets.CreateStatusRadio(),
+			selectChildren: widgets.CreateChildrenSelect(),
+		}
+		inputs.selectYear.OnChanged = func(input string) { gui.compare() }
+		inputs.entryIncome.OnChanged = func(input string) { gui.compare() }
+		inputs.radioStatus.OnChanged = func(input string) { gui.compare() }
+		inputs.selectChildren.OnChanged = func(input string) { gui.compare() }
+		gui.compareInputs[index] = inputs
+	}
+
+	var counts []string
+	for count := tax.COMPARE_MIN_SCENARIOS; count <= tax.COMPARE_MAX_SCENARIOS; count++ {
+		counts = append(counts, strconv.Itoa(count))
+	}
+	gui.selectCompareCount = widget.NewSelect(counts, nil)
+	gui.selectCompareCount.SetSelected(counts[0])
+	gui.labelCompareCount = binding.NewString()
+	gui.labelCompareCount.Set(gui.Language.Scenarios)
+
+	gui.gridCompareInputs = container.New(layout.NewGridLayout(tax.COMPARE_MIN_SCENARIOS + 1))
+	gui.gridCompareResults = container.New(layout.NewGridLayout(tax.COMPARE_MIN_SCENARIOS + 1))
+	gui.setCompareInputs()
+	gui.compare()
+	gui.selectCompareCount.OnChanged = func(input string) {
+		gui.setCompareInputs()
+		gui.compare()
+	}
+
+	return container.NewVBox(
+		container.NewHBox(widget.NewLabelWithData(gui.labelCompareCount), gui.selectCompareCount),
+		gui.gridCompareInputs,
+		widget.NewSeparator(),
+		gui.gridCompareResults,
+	)
+}
+
+// getCompareCount returns the number of scenarios selected
+func (gui *GUI) getCompareCount() int {
+	count, err := strconv.Atoi(gui.selectCompareCount.Selected)
+	if err != nil {
+		return tax.COMPARE_MIN_SCENARIOS
+	}
+	return count
+}
+
+// setCompareInputs rebuild the grid of the inputs with a column for each scenario selected
+func (gui *GUI) setCompareInputs() {
+	var count = gui.getCompareCount()
+	var rows = [][]fyne.CanvasObject{
+		{widget.NewLabel("")},
+		{widget.NewLabel(gui.Language.Year)},
+		{widget.NewLabel(gui.Language.IncomeResult)},
+		{widget.NewLabel(gui.Language.Status)},
+		{widget.NewLabel(gui.Language.Children)},
+	}
+	for index, inputs := range gui.compareInputs[:count] {
+		var name = fmt.Sprintf("%s %d", gui.Language.Scenario, index+1)
+		rows[0] = append(rows[0], widget.NewLabelWithStyle(name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		rows[1] = append(rows[1], inputs.selectYear)
+		rows[2] = append(rows[2], inputs.entryIncome)
+		rows[3] = append(rows[3], inputs.radioStatus)
+		rows[4] = append(rows[4], inputs.selectChildren)
+	}
+
+	var objects []fyne.CanvasObject
+	for _, row := range rows {
+		objects = append(objects, row...)
+	}
+	gui.gridCompareInputs.Layout = layout.NewGridLayout(count + 1)
+	gui.gridCompareInputs.Objects = objects
+	gui.gridCompareInputs.Refresh()
+}
+
+// compare calculate the scenarios of the comparison tab then rebuild the grid of the results
+// the results of the other scenarios are followed by their differences from the first scenario
+func (gui *GUI) compare() {
+	var scenarios = make([]tax.Scenario, 0, gui.getCompareCount())
+	for index, inputs := range gui.compareInputs[:gui.getCompareCount()] {
+		var scenario = tax.Scenario{Name: fmt.Sprintf("%s %d", gui.Language.Scenario, index+1)}
+		scenario.Year, _ = strconv.Atoi(inputs.selectYear.Selected)
+		scenario.User = user.User{
+			Income:     parseIncome(inputs.entryIncome.Text),
+			IsInCouple: inputs.radioStatus.Selected == "Couple",
+			Children:   parseChildren(inputs.selectChildren.Entry.Text),
+		}
+		scenarios = append(scenarios, scenario)
+	}
+
+	comparison, err := tax.CompareScenarios(scenarios, gui.Config)
+	if err != nil {
+		gui.Logger.Error("Compare scenarios", zap.Error(err))
+		return
+	}
+	gui.Logger.Sugar().Debugf("Comparison %#v", comparison.Diffs)
+
+	currency, _ := gui.Currency.Get()
+	var rows = [][]fyne.CanvasObject{
+		{widget.NewLabel(gui.Language.Share)},
+		{widget.NewLabel(gui.Language.Tax)},
+		{widget.NewLabel(gui.Language.Remainder)},
+	}
+	var tranches = comparison.CountTranches()
+	for index := 0; index < tranches; index++ {
+		rows = append(rows, []fyne.CanvasObject{widget.NewLabel(fmt.Sprintf("%s %d", gui.Language.Report.Tranche, index+1))})
+	}
+
+	for i, result := range comparison.Results {
+		var diff = comparison.Diffs[i]
+		rows[0] = append(rows[0], widget.NewLabel(formatCompareValue(fmt.Sprintf("%g", result.Shares), fmt.Sprintf("%+g", diff.Shares), i)))
+		rows[1] = append(rows[1], widget.NewLabel(formatCompareMoney(result.Tax+result.HighIncomeTax, diff.Tax, i, currency)))
+		rows[2] = append(rows[2], widget.NewLabel(formatCompareMoney(result.Remainder, diff.Remainder, i, currency)))
+		for index := 0; index < tranches; index++ {
+			var trancheTax = money.ZERO
+			if index < len(result.TaxTranches) {
+				trancheTax = result.TaxTranches[index].Tax
+			}
+			rows[3+index] = append(rows[3+index], widget.NewLabel(formatCompareMoney(trancheTax, diff.Tranches[index], i, currency)))
+		}
+	}
+
+	var objects []fyne.CanvasObject
+	for _, row := range rows {
+		objects = append(objects, row...)
+	}
+	gui.gridCompareResults.Layout = layout.NewGridLayout(len(comparison.Results) + 1)
+	gui.gridCompareResults.Objects = objects
+	gui.gridCompareResults.Refresh()
+}
+
+// formatCompareMoney returns the amount with the currency followed by its difference from the first scenario
+func formatCompareMoney(amount money.Money, diff money.Money, index int, currency string) string {
+	var sign string
+	if diff >= 0 {
+		sign = "+"
+	}
+	return formatCompareValue(amount.String()+" "+currency, sign+diff.String()+" "+currency, index)
+}
+
+// formatCompareValue returns the value followed by its difference from the first scenario at index 0
+// the first scenario has no difference
+func formatCompareValue(value string, diff string, index int) string {
+	if index == 0 {
+		return value
+	}
+	return fmt.Sprintf("%s (%s)", value, diff)
+}
+
+// parseIncome returns the income of the text of an entry, 0 if it's not valid
+func parseIncome(text string) money.Money {
+	income, err := money.Parse(text)
+	if err != nil {
+		return money.ZERO
+	}
+	return income
+}
+
+// parseChildren returns the number of children of the text of a select between 0 and user.MAX_CHILDREN
+// 0 if it's not a number
+func parseChildren(text string) int {
+	children, err := strconv.Atoi(text)
+	if err != nil || children < 0 {
+		return 0
+	}
+	if children > user.MAX_CHILDREN {
+		return user.MAX_CHILDREN
+	}
+	return children
+}
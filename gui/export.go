@@ -0,0 +1,89 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package gui defines component and script to launch gui application
+package gui
+
+import (
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"github.com/LucasNoga/corpos-christie/export"
+	"github.com/LucasNoga/corpos-christie/tax"
+	"go.uber.org/zap"
+)
+
+// showExportPDF show the dialog to select the file of the PDF report of the current simulation
+func (gui *GUI) showExportPDF() {
+	gui.calculate()
+	var document = export.Document{
+		User:     *gui.User,
+		Result:   gui.result,
+		Language: gui.Language,
+		Currency: gui.Settings.Currency,
+		Date:     time.Now(),
+	}
+
+	var save = dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, gui.Window)
+			return
+		}
+		if writer == nil { // canceled
+			return
+		}
+		defer writer.Close()
+
+		gui.Logger.Info("Export PDF", zap.String("path", writer.URI().Path()))
+		if err := document.WritePDF(writer); err != nil {
+			gui.Logger.Error("Export PDF", zap.Error(err))
+			dialog.ShowError(err, gui.Window)
+		}
+	}, gui.Window)
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".pdf"}))
+	save.SetFileName(gui.getProfileName() + ".pdf")
+	save.Show()
+}
+
+// showExportResults show the dialog to select the CSV or XLSX file of the results of the current simulation
+func (gui *GUI) showExportResults() {
+	gui.calculate()
+	var table = export.NewResultsTable([]tax.Result{gui.result}, gui.Language)
+	gui.showExportTable(table, gui.getProfileName())
+}
+
+// showExportScales show the dialog to select the CSV or XLSX file of the tranches of all the tax scales
+func (gui *GUI) showExportScales() {
+	var table = export.NewScalesTable(gui.Config.TaxList, gui.Language)
+	gui.showExportTable(table, strings.ToLower(gui.Language.Report.Scales))
+}
+
+// showExportTable show the dialog to select the file of the table, its format is the extension of the file (.csv or .xlsx)
+func (gui *GUI) showExportTable(table export.Table, name string) {
+	var save = dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, gui.Window)
+			return
+		}
+		if writer == nil { // canceled
+			return
+		}
+		defer writer.Close()
+
+		gui.Logger.Info("Export table", zap.String("name", table.Name), zap.String("path", writer.URI().Path()))
+		format, err := export.GetFormat(writer.URI().Path())
+		if err == nil {
+			err = export.WriteTable(writer, format, table)
+		}
+		if err != nil {
+			gui.Logger.Error("Export table", zap.Error(err))
+			dialog.ShowError(err, gui.Window)
+		}
+	}, gui.Window)
+	save.SetFilter(storage.NewExtensionFileFilter([]string{"." + export.FORMAT_XLSX, "." + export.FORMAT_CSV}))
+	save.SetFileName(name + "." + export.FORMAT_XLSX)
+	save.Show()
+}
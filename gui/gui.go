@@ -9,7 +9,6 @@ import (
 	"fmt"
 	"image/color"
 	"log"
-	"math"
 	"net/url"
 	"os"
 	"strconv"
@@ -22,15 +21,17 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 	"github.com/LucasNoga/corpos-christie/config"
-	"github.com/LucasNoga/corpos-christie/gui/settings"
 	"github.com/LucasNoga/corpos-christie/gui/themes"
+	"github.com/LucasNoga/corpos-christie/gui/widgets"
+	"github.com/LucasNoga/corpos-christie/i18n"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/settings"
 	"github.com/LucasNoga/corpos-christie/tax"
 	"github.com/LucasNoga/corpos-christie/user"
 	"github.com/LucasNoga/corpos-christie/utils"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
-	"gopkg.in/yaml.v3"
 )
 
 // GUI represents the program parameters to launch in gui the application
@@ -44,31 +45,67 @@ type GUI struct {
 
 	// Settings
 	Theme    themes.Theme   // Fyne theme for the application
-	Language settings.Yaml  // Yaml struct with all language data
+	Language i18n.Yaml      // Yaml struct with all language data
 	Currency binding.String // Currency to display
 
 	// Widgets
-	entryIncome    *widget.Entry       // Input Entry to set income
-	radioStatus    *widget.RadioGroup  // Input Radio buttons to get status
-	selectChildren *widget.SelectEntry // Input Select to know how children
-
-	// buttonSave *widget.Button // Label for save button
+	radioMode       *widget.RadioGroup  // Input Radio buttons to choose the mode of calculation
+	selectYear      *widget.Select      // Input Select to choose the year of the tax scale
+	entryIncome     *widget.Entry       // Input Entry to set income, or the remainder wished in reverse mode
+	radioStatus     *widget.RadioGroup  // Input Radio buttons to get status
+	selectChildren  *widget.SelectEntry // Input Select to know how children
+	isReverse       bool                // True if the income is estimated from the remainder wished (net > income)
+	buttonSave      *widget.Button      // Button to save the household into the current profile
+	buttonHousehold *widget.Button      // Button to open the details of the household
+	household       user.Household      // Details of the declarants, of the children and the particular cases
+
+	profilePath string     // Path of the profile opened or saved, empty if none
+	result      tax.Result // Result of the last calculation to export
 
 	// Bindings
-	Tax                binding.String     // Bind for tax value
-	Remainder          binding.String     // Bind for remainder value
-	Shares             binding.String     // Bind for shares value
-	labelShares        binding.String     // Bind for shares label
-	labelIncome        binding.String     // Bind for income label
-	labelStatus        binding.String     // Bind for status label
-	labelChildren      binding.String     // Bind for children label
-	labelTax           binding.String     // Bind for tax label
-	labelRemainder     binding.String     // Bind for remainder label
-	labelsAbout        binding.StringList // List of label in about modal
-	labelsTaxHeaders   binding.StringList // List of label for tax details headers
-	labelsMinTranche   binding.StringList // List of labels for min tranche in grid
-	labelsMaxTranche   binding.StringList // List of labels for max tranche in grid
-	labelsTrancheTaxes binding.StringList // List of tranches tax label
+	Income               binding.String      // Bind for taxable income value (estimated in reverse mode)
+	Tax                  binding.String      // Bind for tax value
+	Remainder            binding.String      // Bind for remainder value
+	Shares               binding.String      // Bind for shares value
+	HighIncomeTax        binding.String      // Bind for high income contribution value
+	MarginalRate         binding.String      // Bind for marginal rate value
+	AverageRate          binding.String      // Bind for average rate value
+	NextIncomeCost       binding.String      // Bind for tax on the next income value
+	WithholdingRate      binding.String      // Bind for withholding rate value
+	NeutralRate          binding.String      // Bind for neutral withholding rate value
+	labelShares          binding.String      // Bind for shares label
+	labelMode            binding.String      // Bind for mode label
+	labelYear            binding.String      // Bind for year label
+	labelIncome          binding.String      // Bind for income label
+	labelIncomeResult    binding.String      // Bind for taxable income label
+	labelStatus          binding.String      // Bind for status label
+	labelChildren        binding.String      // Bind for children label
+	labelTax             binding.String      // Bind for tax label
+	labelRemainder       binding.String      // Bind for remainder label
+	labelHighIncomeTax   binding.String      // Bind for high income contribution label
+	labelMarginalRate    binding.String      // Bind for marginal rate label
+	labelAverageRate     binding.String      // Bind for average rate label
+	labelNextIncomeCost  binding.String      // Bind for tax on the next income label
+	labelWithholdingRate binding.String      // Bind for withholding rate label
+	labelNeutralRate     binding.String      // Bind for neutral withholding rate label
+	labelsAbout          binding.StringList  // List of label in about modal
+	labelsTaxHeaders     binding.StringList  // List of label for tax details headers
+	labelsMinTranche     binding.StringList  // List of labels for min tranche in grid
+	labelsMaxTranche     binding.StringList  // List of labels for max tranche in grid
+	labelsRateTranche    binding.StringList  // List of labels for rate tranche in grid
+	labelsTrancheTaxes   binding.StringList  // List of tranches tax label
+	trancheRows          [][]*widget.Label   // Labels of each row of the tranches grid to highlight the marginal tranche
+	gridTaxDetails       *fyne.Container     // Grid of the tranches rebuilt when the year changes
+	tabs                 *container.AppTabs  // Tabs of the simulation, of the charts and of the comparison
+	chartCurve           *widgets.LineChart  // Chart of the tax and the net income by taxable income
+	chartTranches        *widgets.StackedBar // Chart of the tax paid in each tranche
+	labelChartCurve      binding.String      // Bind for tax curve chart label
+	labelChartTranches   binding.String      // Bind for tranches chart label
+	compareInputs        []compareInputs     // Inputs of each scenario of the comparison tab
+	selectCompareCount   *widget.Select      // Input Select to choose the number of scenarios to compare
+	gridCompareInputs    *fyne.Container     // Grid of the inputs of the scenarios compared
+	gridCompareResults   *fyne.Container     // Grid of the results of the scenarios rebuilt on each comparison
+	labelCompareCount    binding.String      // Bind for number of scenarios label
 }
 
 // Start Launch GUI application
@@ -105,7 +142,7 @@ func (gui GUI) Start() {
 	// Set Icon
 	var iconName string = "logo.ico"
 	var iconPath string = fmt.Sprintf("%s/%s", config.ASSETS_PATH, iconName)
-	var icon fyne.Resource = settings.GetIcon(iconPath)
+	icon, _ := fyne.LoadResourceFromPath(iconPath)
 	gui.Logger.Info("Load icon", zap.String("name", iconName), zap.String("path", iconPath))
 	gui.Window.SetIcon(icon)
 
@@ -145,17 +182,11 @@ func (gui *GUI) setTheme(theme int) {
 func (gui *GUI) setLanguage(code string) {
 	gui.Logger.Info("Set language", zap.String("code", code))
 
-	var languageFile string = fmt.Sprintf("%s/%s.yaml", config.LANGUAGES_PATH, code)
-	gui.Logger.Debug("Load file for language", zap.String("file", languageFile))
-
-	yamlFile, _ := os.ReadFile(languageFile)
-	err := yaml.Unmarshal(yamlFile, &gui.Language)
-
-	gui.Language.Code = code
-
+	language, err := i18n.LoadLanguage(code)
 	if err != nil {
-		gui.Logger.Sugar().Fatalf("Unmarshal language file %s: %v", languageFile, err)
+		gui.Logger.Sugar().Fatalf("Load language %s: %v", code, err)
 	}
+	gui.Language = language
 
 	gui.Logger.Sugar().Debugf("Language Yaml %v", gui.Language)
 }
@@ -168,6 +199,16 @@ func (gui *GUI) setCurrency(currency string) {
 
 // setEvents Set the events/trigger of gui widgets
 func (gui *GUI) setEvents() {
+	gui.radioMode.OnChanged = func(input string) {
+		gui.setReverse(input == gui.Language.ModeReverse)
+	}
+	gui.selectYear.OnChanged = func(input string) {
+		year, err := strconv.Atoi(input)
+		if err != nil {
+			return
+		}
+		gui.setYear(year)
+	}
 	gui.entryIncome.OnChanged = func(input string) {
 		gui.calculate()
 	}
@@ -180,13 +221,38 @@ func (gui *GUI) setEvents() {
 
 }
 
-// getIncome Get value of widget entry
-func (gui *GUI) getIncome() int {
-	intVal, err := strconv.Atoi(gui.entryIncome.Text)
+// setReverse change the mode of calculation, the entry is the remainder wished if reverse is true
+func (gui *GUI) setReverse(reverse bool) {
+	gui.Logger.Info("Set mode", zap.Bool("reverse", reverse))
+	gui.isReverse = reverse
+	gui.labelIncome.Set(gui.getIncomeLabel())
+	gui.calculate()
+}
+
+// setYear change the tax scale used by the year selected then update the tranches and the results
+func (gui *GUI) setYear(year int) {
+	tax, err := gui.Config.FindTax(year)
 	if err != nil {
-		return 0
+		gui.Logger.Error("Set year", zap.Int("year", year), zap.Error(err))
+		return
+	}
+	gui.Logger.Info("Set year", zap.Int("year", year))
+	gui.Config.Tax = tax
+	gui.setTaxDetails()
+	gui.calculate()
+}
+
+// getIncomeLabel returns the label of the entry for the mode of calculation
+func (gui *GUI) getIncomeLabel() string {
+	if gui.isReverse {
+		return gui.Language.RemainderWished
 	}
-	return intVal
+	return gui.Language.Income
+}
+
+// getIncome Get value of widget entry
+func (gui *GUI) getIncome() money.Money {
+	return parseIncome(gui.entryIncome.Text)
 }
 
 // getStatus Get value of widget radioGroup
@@ -196,25 +262,53 @@ func (gui *GUI) getStatus() bool {
 
 // getChildren get value of widget select
 func (gui *GUI) getChildren() int {
-	children, err := strconv.Atoi(gui.selectChildren.Entry.Text)
-	if err != nil {
-		return 0
+	return parseChildren(gui.selectChildren.Entry.Text)
+}
+
+// getYears returns the years of the tax scales of config.TaxList
+func (gui *GUI) getYears() []string {
+	var years = make([]string, 0, len(gui.Config.TaxList))
+	for _, tax := range gui.Config.TaxList {
+		years = append(years, utils.ConvertIntToString(tax.Year))
 	}
-	return children
+	return years
 }
 
 // reload Refresh widget who needed specially when language changed
 func (gui *GUI) Reload() {
 	// Simple data bind
-	gui.labelIncome.Set(gui.Language.Income)
+	gui.labelMode.Set(gui.Language.Mode)
+	gui.labelYear.Set(gui.Language.Year)
+	gui.labelIncome.Set(gui.getIncomeLabel())
+	gui.labelIncomeResult.Set(gui.Language.IncomeResult)
 	gui.labelStatus.Set(gui.Language.Status)
 	gui.labelChildren.Set(gui.Language.Children)
 	gui.labelTax.Set(gui.Language.Tax)
 	gui.labelRemainder.Set(gui.Language.Remainder)
 	gui.labelShares.Set(gui.Language.Share)
+	gui.labelHighIncomeTax.Set(gui.Language.HighIncomeTax)
+	gui.labelMarginalRate.Set(gui.Language.MarginalRate)
+	gui.labelAverageRate.Set(gui.Language.AverageRate)
+	gui.labelNextIncomeCost.Set(gui.Language.NextIncomeCost)
+	gui.labelWithholdingRate.Set(gui.Language.WithholdingRate)
+	gui.labelNeutralRate.Set(gui.Language.NeutralRate)
+	gui.labelChartCurve.Set(gui.Language.ChartCurve)
+	gui.labelChartTranches.Set(gui.Language.ChartTranches)
+	gui.tabs.Items[0].Text = gui.Language.Simulation
+	gui.tabs.Items[1].Text = gui.Language.Charts
+	gui.tabs.Items[2].Text = gui.Language.Compare
+	gui.labelCompareCount.Set(gui.Language.Scenarios)
+	gui.tabs.Refresh()
+	gui.buttonHousehold.SetText(gui.Language.Household.Details)
 
 	// Handle widget
-	// gui.buttonSave.SetText(gui.Language.Save) // TODO
+	gui.buttonSave.SetText(gui.Language.Save)
+	gui.radioMode.Options = []string{gui.Language.ModeTax, gui.Language.ModeReverse}
+	gui.radioMode.Selected = gui.radioMode.Options[0]
+	if gui.isReverse {
+		gui.radioMode.Selected = gui.radioMode.Options[1]
+	}
+	gui.radioMode.Refresh()
 
 	// Reload about content
 	gui.labelsAbout.Set(gui.Language.GetAbouts())
@@ -222,54 +316,76 @@ func (gui *GUI) Reload() {
 	// Reload header tax details
 	gui.labelsTaxHeaders.Set(gui.Language.GetTaxHeaders())
 
-	// Reload grid header
-	currency, _ := gui.Currency.Get()
-	gui.labelsTrancheTaxes.Set(*createTrancheTaxesLabels(gui.labelsTrancheTaxes.Length(), currency))
+	// Rebuild grid tranches of the year with the currency then set their taxes
+	gui.setTaxDetails()
+	gui.calculate()
 
-	// Reload grid min tranches
-	var minList []string
-	for index := 0; index < gui.labelsMinTranche.Length(); index++ {
-		var min string = utils.ConvertIntToString(gui.Config.Tax.Tranches[index].Min) + " " + currency
-		minList = append(minList, min)
-	}
-	gui.labelsMinTranche.Set(minList)
-
-	// Reload grid max tranches
-	var maxList []string
-	for index := 0; index < gui.labelsMaxTranche.Length(); index++ {
-		var max string = utils.ConvertIntToString(gui.Config.Tax.Tranches[index].Max) + " " + currency
-		if gui.Config.Tax.Tranches[index].Max == math.MaxInt64 {
-			max = "-"
-		}
-		maxList = append(maxList, max)
-	}
-	gui.labelsMaxTranche.Set(maxList)
+	// Rebuild the comparison with the language and the currency
+	gui.setCompareInputs()
+	gui.compare()
 }
 
 // calculate Get values of gui to calculate tax
 func (gui *GUI) calculate() {
-	gui.User.Income = gui.getIncome()
 	gui.User.IsInCouple = gui.getStatus()
 	gui.User.Children = gui.getChildren()
-
-	result := tax.CalculateTax(gui.User, gui.Config)
+	gui.setHouseholdDetails()
+
+	// In reverse mode the entry is the remainder wished and the income is estimated
+	var result tax.Result
+	if gui.isReverse {
+		var err error
+		gui.User.Remainder = gui.getIncome()
+		result, err = tax.CalculateReverseTax(*gui.User, gui.Config)
+		if err != nil {
+			gui.Logger.Error("Calculate reverse tax", zap.Error(err))
+			return
+		}
+		gui.User.Income = result.Income
+	} else {
+		gui.User.Income = gui.getIncome()
+		result = tax.CalculateTax(*gui.User, gui.Config)
+	}
+	gui.result = result
+	withholding := tax.CalculateWithholding(result, *gui.User, gui.Config)
 	gui.Logger.Sugar().Debugf("Result taxes %#v", result)
 
-	var tax string = utils.ConvertInt64ToString(int64(result.Tax))
-	var remainder string = utils.ConvertInt64ToString(int64(result.Remainder))
-	var shares string = utils.ConvertInt64ToString(int64(result.Shares))
+	var tax string = result.Tax.String()
+	var remainder string = result.Remainder.String()
+	var shares string = fmt.Sprintf("%g", result.Shares)
+	var highIncomeTax string = result.HighIncomeTax.String()
 
 	// Set data in tax layout
+	gui.Income.Set(result.Income.String())
 	gui.Tax.Set(tax)
 	gui.Remainder.Set(remainder)
 	gui.Shares.Set(shares)
+	gui.HighIncomeTax.Set(highIncomeTax)
+	gui.MarginalRate.Set(fmt.Sprintf("%g", result.MarginalRate))
+	gui.AverageRate.Set(fmt.Sprintf("%.1f", result.AverageRate))
+	gui.NextIncomeCost.Set(result.NextIncomeCost.String())
+
+	// Set withholding rates
+	gui.WithholdingRate.Set(fmt.Sprintf("%.1f", withholding.Rate))
+	gui.NeutralRate.Set(fmt.Sprintf("%.1f", withholding.NeutralRates[0]))
 
 	// Set Tax details
 	currency, _ := gui.Currency.Get()
-	for index := 0; index < gui.labelsTrancheTaxes.Length(); index++ {
-		var taxTranche string = utils.ConvertIntToString(int(result.TaxTranches[index].Tax))
+	for index := 0; index < gui.labelsTrancheTaxes.Length() && index < len(result.TaxTranches); index++ {
+		var taxTranche string = result.TaxTranches[index].Tax.Round().String()
 		gui.labelsTrancheTaxes.SetValue(index, taxTranche+" "+currency)
 	}
+
+	// Draw the charts of the household
+	gui.setCharts(result)
+
+	// Highlight the marginal tranche
+	for index, row := range gui.trancheRows {
+		for _, label := range row {
+			label.TextStyle.Bold = index == result.MarginalTranche
+			label.Refresh()
+		}
+	}
 }
 
 // createMenu create mainMenu for window
@@ -282,7 +398,8 @@ func (gui *GUI) setMenu() *fyne.MainMenu {
 
 // createFileMenu create file item in toolbar to handle app settings
 func (gui *GUI) createFileMenu() *fyne.Menu {
-	fileMenu := fyne.NewMenu(gui.Language.File,
+	var items = gui.createProfileMenuItems()
+	items = append(items,
 		fyne.NewMenuItem(gui.Language.Settings, func() {
 			dialog.ShowCustom(gui.Language.Settings, gui.Language.Close,
 				container.NewVBox(
@@ -297,7 +414,7 @@ func (gui *GUI) createFileMenu() *fyne.Menu {
 		}),
 		fyne.NewMenuItem(gui.Language.Quit, func() { gui.App.Quit() }),
 	)
-	return fileMenu
+	return fyne.NewMenu(gui.Language.File, items...)
 }
 
 // createSelectTheme create select to change theme
@@ -325,11 +442,11 @@ func (gui *GUI) createSelectLanguage() *fyne.Container {
 		var getLanguage = func() string {
 			switch index {
 			case 0:
-				return settings.ENGLISH
+				return i18n.ENGLISH
 			case 1:
-				return settings.FRENCH
+				return i18n.FRENCH
 			default:
-				return settings.ENGLISH
+				return i18n.ENGLISH
 			}
 		}
 
@@ -347,7 +464,7 @@ func (gui *GUI) createSelectLanguage() *fyne.Container {
 
 // createSelectCurrency create select to change currency
 func (gui *GUI) createSelectCurrency() *fyne.Container {
-	selectCurrency := widget.NewSelect(settings.GetCurrencies(), func(currency string) {
+	selectCurrency := widget.NewSelect(i18n.GetCurrencies(), func(currency string) {
 		gui.setCurrency(currency)
 		gui.Settings.Set("currency", currency)
 		gui.Reload()
@@ -415,9 +532,9 @@ func (gui *GUI) createHelpMenu() *fyne.Menu {
 // getLanguageIndex get index to selectLanguage in settings from language of the app
 func getLanguageIndex(langue string) int {
 	switch langue {
-	case settings.ENGLISH:
+	case i18n.ENGLISH:
 		return 0
-	case settings.FRENCH:
+	case i18n.FRENCH:
 		return 1
 	default:
 		return 0
@@ -0,0 +1,193 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package gui defines component and script to launch gui application
+package gui
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+)
+
+// createLayoutHousehold Setup the button opening the details of the household
+func (gui *GUI) createLayoutHousehold() *fyne.Container {
+	gui.household = user.Household{Declarants: make([]user.Declarant, 2), IsIsolated: true}
+	gui.buttonHousehold = widget.NewButton(gui.Language.Household.Details, gui.showHousehold)
+	return container.NewHBox(gui.buttonHousehold)
+}
+
+// showHousehold show the dialog of the details of the household for the status and the children selected
+// the incomes of each declarant by category, their particular cases and the details of each child
+func (gui *GUI) showHousehold() {
+	var texts = gui.Language.Household
+	var content = container.NewVBox()
+
+	var declarants = gui.getDeclarants()
+	for index := range declarants {
+		var declarant = &gui.household.Declarants[index]
+		var name = fmt.Sprintf("%s %d", texts.Declarant, index+1)
+		content.Add(widget.NewLabelWithStyle(name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+
+		var form = container.New(layout.NewFormLayout())
+		var incomes = []struct {
+			label string
+			value *money.Money
+		}{
+			{texts.Salaries, &declarant.Incomes.Salaries},
+			{texts.Pensions, &declarant.Incomes.Pensions},
+			{texts.Business, &declarant.Incomes.Business},
+			{texts.Property, &declarant.Incomes.Property},
+		}
+		for _, income := range incomes {
+			var value = income.value
+			var entry = widget.NewEntry()
+			if *value > 0 {
+				entry.SetText(value.String())
+			}
+			entry.OnChanged = func(input string) {
+				*value = parseIncome(input).Max(money.ZERO)
+				gui.setHouseholdIncome()
+			}
+			form.Add(widget.NewLabel(income.label))
+			form.Add(entry)
+		}
+		content.Add(form)
+		content.Add(container.NewHBox(
+			gui.createHouseholdCheck(texts.Disabled, &declarant.IsDisabled),
+			gui.createHouseholdCheck(texts.Veteran, &declarant.IsVeteran),
+		))
+	}
+
+	// The particular cases of a single declarant
+	if len(declarants) == 1 {
+		content.Add(widget.NewSeparator())
+		content.Add(container.NewHBox(
+			gui.createHouseholdCheck(texts.Widowed, &gui.household.IsWidowed),
+			gui.createHouseholdCheck(texts.Isolated, &gui.household.IsIsolated),
+		))
+	}
+
+	var dependents = gui.getDependents()
+	if len(dependents) > 0 {
+		content.Add(widget.NewSeparator())
+		var grid = container.New(layout.NewGridLayout(4))
+		for index := range dependents {
+			var dependent = &gui.household.Dependents[index]
+			var entry = widget.NewEntry()
+			entry.SetPlaceHolder(texts.BirthYear)
+			if dependent.BirthYear > 0 {
+				entry.SetText(strconv.Itoa(dependent.BirthYear))
+			}
+			entry.OnChanged = func(input string) {
+				dependent.BirthYear = parseBirthYear(input)
+				gui.calculate()
+			}
+			grid.Add(widget.NewLabel(fmt.Sprintf("%s %d", texts.Child, index+1)))
+			grid.Add(entry)
+			grid.Add(gui.createHouseholdCheck(texts.Alternating, &dependent.IsAlternating))
+			grid.Add(gui.createHouseholdCheck(texts.Disabled, &dependent.IsDisabled))
+		}
+		content.Add(grid)
+	}
+
+	var details = dialog.NewCustom(texts.Details, gui.Language.Close, container.NewVScroll(content), gui.Window)
+	details.Resize(fyne.NewSize(600, 500))
+	details.Show()
+}
+
+// createHouseholdCheck returns a check of the details of the household calculating the tax on change
+func (gui *GUI) createHouseholdCheck(label string, value *bool) *widget.Check {
+	var check = widget.NewCheck(label, nil)
+	check.SetChecked(*value)
+	check.OnChanged = func(checked bool) {
+		*value = checked
+		gui.calculate()
+	}
+	return check
+}
+
+// setHouseholdIncome set the entry of the income to the total of the incomes of the declarants
+// the entry is the remainder wished in reverse mode so it's not changed
+func (gui *GUI) setHouseholdIncome() {
+	if gui.isReverse {
+		return
+	}
+	var income money.Money
+	for _, declarant := range gui.getDeclarants() {
+		income += declarant.GetIncome()
+	}
+	gui.entryIncome.SetText(income.String())
+}
+
+// getDeclarants returns the declarants of the household for the status selected
+func (gui *GUI) getDeclarants() []user.Declarant {
+	if gui.getStatus() {
+		return gui.household.Declarants
+	}
+	return gui.household.Declarants[:1]
+}
+
+// getDependents returns the details of the children of the household for the number of children selected
+// the details of the children added are empty
+func (gui *GUI) getDependents() []user.Dependent {
+	var children = gui.getChildren()
+	for len(gui.household.Dependents) < children {
+		gui.household.Dependents = append(gui.household.Dependents, user.Dependent{})
+	}
+	return gui.household.Dependents[:children]
+}
+
+// setHouseholdDetails apply the details of the household to the user for the status and the children selected
+func (gui *GUI) setHouseholdDetails() {
+	var household = user.Household{
+		Declarants: gui.getDeclarants(),
+		Dependents: gui.getDependents(),
+	}
+	if len(household.Declarants) == 1 {
+		household.IsWidowed = gui.household.IsWidowed
+		household.IsIsolated = gui.household.IsIsolated && !household.IsWidowed
+	}
+
+	var details = household.GetUser(gui.Config.GetTax().Year)
+	gui.User.Declarants = details.Declarants
+	gui.User.Dependents = details.Dependents
+	gui.User.Cases = details.Cases
+	gui.User.IsCohabiting = details.IsCohabiting
+}
+
+// setHousehold set the details of the household from the user of a profile
+// Without the details of the declarants the income is the salaries of the first declarant
+// and the invalids are the first declarants with a disability card
+func (gui *GUI) setHousehold(u user.User) {
+	gui.household = user.Household{
+		Declarants: make([]user.Declarant, 2),
+		Dependents: append([]user.Dependent(nil), u.Dependents...),
+		IsWidowed:  u.Cases.IsWidowed,
+		IsIsolated: !u.IsCohabiting,
+	}
+	if len(u.Declarants) > 0 {
+		copy(gui.household.Declarants, u.Declarants)
+		return
+	}
+	gui.household.Declarants[0].Incomes.Salaries = u.Income
+	for index := 0; index < u.Cases.Invalids && index < len(gui.household.Declarants); index++ {
+		gui.household.Declarants[index].IsDisabled = true
+	}
+}
+
+// parseBirthYear returns the birth year of the text of an entry, 0 if it's not a valid year
+func parseBirthYear(text string) int {
+	year, err := strconv.Atoi(text)
+	if err != nil || year < 0 {
+		return 0
+	}
+	return year
+}
@@ -5,8 +5,6 @@
 package gui
 
 import (
-	"math"
-
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
@@ -14,32 +12,66 @@ import (
 	"fyne.io/fyne/v2/widget"
 	"github.com/LucasNoga/corpos-christie/config"
 	"github.com/LucasNoga/corpos-christie/gui/widgets"
+	"github.com/LucasNoga/corpos-christie/money"
 	"github.com/LucasNoga/corpos-christie/utils"
 )
 
 // setLayouts Setup components/widget in the window
+// a tab with the form and the results, a tab with the charts, a tab comparing scenarios
 func (gui *GUI) setLayouts() {
 	content := container.New(layout.NewGridLayout(2),
 		gui.createLayoutForm(),
 		gui.createLayoutTax(),
 	)
-	gui.Window.SetContent(content)
+	gui.tabs = container.NewAppTabs(
+		container.NewTabItem(gui.Language.Simulation, content),
+		container.NewTabItem(gui.Language.Charts, gui.createLayoutCharts()),
+		container.NewTabItem(gui.Language.Compare, container.NewVScroll(gui.createLayoutCompare())),
+	)
+	gui.Window.SetContent(gui.tabs)
 }
 
 // createLayoutForm Setup left side of window
 func (gui *GUI) createLayoutForm() *fyne.Container {
 	return container.New(layout.NewVBoxLayout(),
+		gui.createLayoutMode(),
+		gui.createLayoutYear(),
 		gui.createLayoutIncome(),
 		gui.createLayoutStatus(),
 		gui.createLayoutChildren(),
-		// gui.createLayoutSave(), // TODO
+		gui.createLayoutHousehold(),
+		gui.createLayoutSave(),
+	)
+}
+
+// createLayoutMode Setup layouts and widget to choose the mode of calculation (income > tax or net > income)
+func (gui *GUI) createLayoutMode() *fyne.Container {
+	gui.radioMode = widgets.CreateModeRadio([]string{gui.Language.ModeTax, gui.Language.ModeReverse})
+	gui.labelMode = binding.NewString()
+	gui.labelMode.Set(gui.Language.Mode)
+	return container.NewHBox(
+		widget.NewLabelWithData(gui.labelMode),
+		gui.radioMode,
+	)
+}
+
+// createLayoutYear Setup layouts and widget to select the year of the tax scale among config.TaxList
+func (gui *GUI) createLayoutYear() *fyne.Container {
+	gui.selectYear = widgets.CreateYearSelect(gui.getYears(), utils.ConvertIntToString(gui.Config.GetTax().Year))
+	gui.labelYear = binding.NewString()
+	gui.labelYear.Set(gui.Language.Year)
+	return container.NewHBox(
+		widget.NewLabelWithData(gui.labelYear),
+		gui.selectYear,
 	)
 }
 
 // createLayoutIncome Setup layouts and widget for income layout
+// the label is the remainder wished in reverse mode
 func (gui *GUI) createLayoutIncome() *fyne.Container {
 	gui.entryIncome = widgets.CreateIncomeEntry()
-	gui.labelIncome = binding.BindString(&gui.Language.Income)
+	gui.labelIncome = binding.NewString()
+	gui.labelIncome.Set(gui.getIncomeLabel())
 	return container.New(
 		layout.NewFormLayout(),
 		widget.NewLabelWithData(gui.labelIncome),
@@ -75,21 +107,19 @@ func (gui *GUI) createLayoutChildren() *fyne.Container {
 	)
 }
 
-// createLayoutSave Setup layouts and widget for save button layout
-// func (gui *GUI) createLayoutSave() *fyne.Container {
-// 	gui.buttonSave = widget.NewButton(gui.Language.Save, func() {
-// 		gui.calculate()
-// 		gui.Logger.Info("Save Taxes")
-// 		// TODO Export taxes data in csv and/or pdf
-// 	})
-// 	return container.NewHBox(gui.buttonSave)
-// }
+// createLayoutSave Setup the button saving the household into the current profile
+func (gui *GUI) createLayoutSave() *fyne.Container {
+	gui.buttonSave = widget.NewButton(gui.Language.Save, gui.saveProfile)
+	return container.NewHBox(gui.buttonSave)
+}
 
 // createLayoutTax Setup right side of window
 func (gui *GUI) createLayoutTax() *fyne.Container {
 	return container.New(
 		layout.NewVBoxLayout(),
 		gui.createLayoutTaxResult(),
+		widget.NewSeparator(),
+		gui.createLayoutWithholding(),
 		container.NewVBox(widget.NewLabel(""), widget.NewSeparator(), widget.NewLabel("")),
 		gui.createLayoutTaxDetails(),
 	)
@@ -97,6 +127,10 @@ func (gui *GUI) createLayoutTax() *fyne.Container {
 
 // createLayoutTaxResult Setup right top side of window
 func (gui *GUI) createLayoutTaxResult() *fyne.Container {
+	gui.labelIncomeResult = binding.NewString()
+	gui.labelIncomeResult.Set(gui.Language.IncomeResult)
+	gui.Income = binding.NewString()
+
 	gui.labelTax = binding.BindString(&gui.Language.Tax)
 	gui.Tax = binding.NewString()
 
@@ -106,7 +140,23 @@ func (gui *GUI) createLayoutTaxResult() *fyne.Container {
 	gui.labelRemainder = binding.BindString(&gui.Language.Remainder)
 	gui.Remainder = binding.NewString()
 
+	gui.labelHighIncomeTax = binding.BindString(&gui.Language.HighIncomeTax)
+	gui.HighIncomeTax = binding.NewString()
+
+	gui.labelMarginalRate = binding.BindString(&gui.Language.MarginalRate)
+	gui.MarginalRate = binding.NewString()
+
+	gui.labelAverageRate = binding.BindString(&gui.Language.AverageRate)
+	gui.AverageRate = binding.NewString()
+
+	gui.labelNextIncomeCost = binding.BindString(&gui.Language.NextIncomeCost)
+	gui.NextIncomeCost = binding.NewString()
+
 	return container.New(layout.NewGridLayout(3),
+		widget.NewLabelWithData(gui.labelIncomeResult),
+		widget.NewLabelWithData(gui.Income),
+		widget.NewLabelWithData(gui.Currency),
+
 		widget.NewLabelWithData(gui.labelTax),
 		widget.NewLabelWithData(gui.Tax),
 		widget.NewLabelWithData(gui.Currency),
@@ -118,48 +168,101 @@ func (gui *GUI) createLayoutTaxResult() *fyne.Container {
 		widget.NewLabelWithData(gui.labelRemainder),
 		widget.NewLabelWithData(gui.Remainder),
 		widget.NewLabelWithData(gui.Currency),
+
+		widget.NewLabelWithData(gui.labelHighIncomeTax),
+		widget.NewLabelWithData(gui.HighIncomeTax),
+		widget.NewLabelWithData(gui.Currency),
+
+		widget.NewLabelWithData(gui.labelMarginalRate),
+		widget.NewLabelWithData(gui.MarginalRate),
+		widget.NewLabel("%"),
+
+		widget.NewLabelWithData(gui.labelAverageRate),
+		widget.NewLabelWithData(gui.AverageRate),
+		widget.NewLabel("%"),
+
+		widget.NewLabelWithData(gui.labelNextIncomeCost),
+		widget.NewLabelWithData(gui.NextIncomeCost),
+		widget.NewLabelWithData(gui.Currency),
 	)
 
 }
 
+// createLayoutWithholding Setup panel with rates of withholding tax
+func (gui *GUI) createLayoutWithholding() *fyne.Container {
+	gui.labelWithholdingRate = binding.BindString(&gui.Language.WithholdingRate)
+	gui.WithholdingRate = binding.NewString()
+
+	gui.labelNeutralRate = binding.BindString(&gui.Language.NeutralRate)
+	gui.NeutralRate = binding.NewString()
+
+	return container.New(layout.NewGridLayout(3),
+		widget.NewLabelWithData(gui.labelWithholdingRate),
+		widget.NewLabelWithData(gui.WithholdingRate),
+		widget.NewLabel("%"),
+
+		widget.NewLabelWithData(gui.labelNeutralRate),
+		widget.NewLabelWithData(gui.NeutralRate),
+		widget.NewLabel("%"),
+	)
+}
+
 // createLayoutTax Setup right bottom side of window
+// the rows of the tranches are built by setTaxDetails from the tax scale of the year used
 func (gui *GUI) createLayoutTaxDetails() *fyne.Container {
-	var trancheNumber int = 5
+	gui.labelsTaxHeaders = binding.NewStringList()
+	gui.labelsTaxHeaders.Set(gui.Language.GetTaxHeaders())
+
+	// A column for each header
+	gui.gridTaxDetails = container.New(layout.NewGridLayout(gui.labelsTaxHeaders.Length()))
+	gui.setTaxDetails()
+
+	return container.New(
+		layout.NewMaxLayout(),
+		gui.gridTaxDetails,
+	)
+}
+
+// setTaxDetails rebuild the tranches grid with a row for each tranche of the tax scale of the year used
+// the taxes of the tranches are set by calculate
+func (gui *GUI) setTaxDetails() {
+	var tranches = gui.Config.Tax.Tranches
 	currency, _ := gui.Currency.Get()
 
-	// Add header columns in grid
-	grid := container.New(layout.NewGridLayout(trancheNumber))
+	// Setup binding for min, max, rates and taxes columns
+	gui.labelsMinTranche = binding.BindStringList(createMinTrancheLabels(currency, tranches))
+	gui.labelsMaxTranche = binding.BindStringList(createMaxTrancheLabels(currency, tranches))
+	gui.labelsRateTranche = binding.BindStringList(createRateTrancheLabels(tranches))
+	gui.labelsTrancheTaxes = binding.BindStringList(createTrancheTaxesLabels(len(tranches), currency))
 
-	gui.labelsTaxHeaders = binding.NewStringList()
-	for index, header := range gui.Language.GetTaxHeaders() {
-		gui.labelsTaxHeaders.Append(header)
-		h, _ := gui.labelsTaxHeaders.GetItem(index)
-		grid.Add(widget.NewLabelWithData(h.(binding.String)))
+	// Add header columns in grid
+	gui.gridTaxDetails.Objects = nil
+	for index := 0; index < gui.labelsTaxHeaders.Length(); index++ {
+		header, _ := gui.labelsTaxHeaders.GetItem(index)
+		gui.gridTaxDetails.Add(widget.NewLabelWithData(header.(binding.String)))
 	}
 
-	// Setup binding for min, max and taxes columns
-	gui.labelsMinTranche = binding.BindStringList(createMinTrancheLabels(currency, gui.Config.Tax.Tranches))
-	gui.labelsMaxTranche = binding.BindStringList(createMaxTrancheLabels(currency, gui.Config.Tax.Tranches))
-	gui.labelsTrancheTaxes = binding.BindStringList(createTrancheTaxesLabels(trancheNumber, currency))
-
 	// Add Tranche rows in grid
-	for index := 0; index < gui.labelsTrancheTaxes.Length(); index++ {
+	gui.trancheRows = make([][]*widget.Label, 0, len(tranches))
+	for index := range tranches {
 		minItem, _ := gui.labelsMinTranche.GetItem(index)
 		maxItem, _ := gui.labelsMaxTranche.GetItem(index)
+		rateItem, _ := gui.labelsRateTranche.GetItem(index)
 		taxItem, _ := gui.labelsTrancheTaxes.GetItem(index)
-		var rate string = gui.Config.Tax.Tranches[index].Rate
 
-		grid.Add(widget.NewLabel("Tranche " + utils.ConvertIntToString(index+1)))
-		grid.Add(widget.NewLabelWithData(minItem.(binding.String)))
-		grid.Add(widget.NewLabelWithData(maxItem.(binding.String)))
-		grid.Add(widget.NewLabel(rate))
-		grid.Add(widget.NewLabelWithData(taxItem.(binding.String)))
+		var row = []*widget.Label{
+			widget.NewLabel("Tranche " + utils.ConvertIntToString(index+1)),
+			widget.NewLabelWithData(minItem.(binding.String)),
+			widget.NewLabelWithData(maxItem.(binding.String)),
+			widget.NewLabelWithData(rateItem.(binding.String)),
+			widget.NewLabelWithData(taxItem.(binding.String)),
+		}
+		for _, label := range row {
+			gui.gridTaxDetails.Add(label)
+		}
+		gui.trancheRows = append(gui.trancheRows, row)
 	}
-
-	return container.New(
-		layout.NewMaxLayout(),
-		grid,
-	)
+	gui.gridTaxDetails.Refresh()
 }
 
 // CreateTrancheLabels create widgets labels for tranche taxes value into an array
@@ -180,7 +283,7 @@ func createMinTrancheLabels(currency string, tranches []config.Tranche) *[]strin
 	var labels []string = make([]string, 0, len(tranches))
 
 	for _, tranche := range tranches {
-		var min string = utils.ConvertIntToString(tranche.Min) + " " + currency
+		var min string = tranche.Min.String() + " " + currency
 		labels = append(labels, min)
 	}
 
@@ -193,11 +296,22 @@ func createMaxTrancheLabels(currency string, tranches []config.Tranche) *[]strin
 	var labels []string = make([]string, 0, len(tranches))
 
 	for _, tranche := range tranches {
-		var max = utils.ConvertIntToString(tranche.Max) + " " + currency
-		if tranche.Max == math.MaxInt64 {
+		var max = tranche.Max.String() + " " + currency
+		if tranche.Max == money.MAX {
 			max = "-"
 		}
 		labels = append(labels, max)
 	}
 	return &labels
 }
+
+// createRateTrancheLabels create string from config.Tranche to create binding
+// Returns Array string with rate tranches value
+func createRateTrancheLabels(tranches []config.Tranche) *[]string {
+	var labels []string = make([]string, 0, len(tranches))
+
+	for _, tranche := range tranches {
+		labels = append(labels, tranche.Rate)
+	}
+	return &labels
+}
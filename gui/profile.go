@@ -0,0 +1,192 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package gui defines component and script to launch gui application
+package gui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/profile"
+	"go.uber.org/zap"
+)
+
+// createProfileMenuItems create the items of the file menu to open and save profiles and to export the simulation
+func (gui *GUI) createProfileMenuItems() []*fyne.MenuItem {
+	var recent = fyne.NewMenuItem(gui.Language.OpenRecent, nil)
+	recent.ChildMenu = fyne.NewMenu("")
+	for _, path := range gui.Settings.Recent {
+		var path = path
+		recent.ChildMenu.Items = append(recent.ChildMenu.Items, fyne.NewMenuItem(path, func() { gui.openProfile(path) }))
+	}
+	recent.Disabled = len(gui.Settings.Recent) == 0
+
+	return []*fyne.MenuItem{
+		fyne.NewMenuItem(gui.Language.Open, gui.showOpenProfile),
+		recent,
+		fyne.NewMenuItem(gui.Language.Save, gui.saveProfile),
+		fyne.NewMenuItem(gui.Language.SaveAs, gui.showSaveProfile),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem(gui.Language.Report.ExportPDF, gui.showExportPDF),
+		fyne.NewMenuItem(gui.Language.Report.ExportResults, gui.showExportResults),
+		fyne.NewMenuItem(gui.Language.Report.ExportScales, gui.showExportScales),
+		fyne.NewMenuItemSeparator(),
+	}
+}
+
+// showOpenProfile show the dialog to select the profile to open in the profiles directory
+func (gui *GUI) showOpenProfile() {
+	var open = dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, gui.Window)
+			return
+		}
+		if reader == nil { // canceled
+			return
+		}
+		reader.Close()
+		gui.openProfile(reader.URI().Path())
+	}, gui.Window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{profile.EXTENSION}))
+	if location, err := gui.getProfilesLocation(); err == nil {
+		open.SetLocation(location)
+	}
+	open.Show()
+}
+
+// showSaveProfile show the dialog to select the file of the profile to save, named from the current profile
+// the profile is written into the file created by the dialog with the name chosen
+func (gui *GUI) showSaveProfile() {
+	var save = dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, gui.Window)
+			return
+		}
+		if writer == nil { // canceled
+			return
+		}
+
+		var path = writer.URI().Path()
+		gui.Logger.Info("Save profile", zap.String("path", path))
+		data, err := profile.Marshal(gui.newProfile(path))
+		if err == nil {
+			_, err = writer.Write(data)
+		}
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			gui.showProfileError("Save profile", path, err)
+			return
+		}
+		gui.setProfilePath(path)
+	}, gui.Window)
+	save.SetFilter(storage.NewExtensionFileFilter([]string{profile.EXTENSION}))
+	save.SetFileName(gui.getProfileName() + profile.EXTENSION)
+	if location, err := gui.getProfilesLocation(); err == nil {
+		save.SetLocation(location)
+	}
+	save.Show()
+}
+
+// saveProfile save the household into the current profile, or ask the file if no profile is opened
+func (gui *GUI) saveProfile() {
+	if gui.profilePath == "" {
+		gui.showSaveProfile()
+		return
+	}
+	gui.writeProfile(gui.profilePath)
+}
+
+// openProfile load the profile of the file path into the widgets and calculate its tax
+func (gui *GUI) openProfile(path string) {
+	gui.Logger.Info("Open profile", zap.String("path", path))
+	p, err := profile.Load(path)
+	if err == nil {
+		err = p.Apply(gui.User)
+	}
+	if err != nil {
+		gui.showProfileError("Open profile", path, err)
+		return
+	}
+
+	if tax, err := gui.Config.FindTax(p.Year); err == nil {
+		gui.Config.Tax = tax
+	}
+
+	// Set the widgets which calculate the tax on change, the income of the profile is not a remainder
+	// the household is copied because each change reads the widgets not set yet into the user
+	var household = *gui.User
+	gui.setHousehold(household)
+	var status = "Single"
+	if household.IsInCouple {
+		status = "Couple"
+	}
+	gui.radioMode.SetSelected(gui.Language.ModeTax)
+	gui.selectYear.SetSelected(strconv.Itoa(gui.Config.GetTax().Year))
+	gui.entryIncome.SetText(household.Income.String())
+	gui.radioStatus.SetSelected(status)
+	gui.selectChildren.SetText(strconv.Itoa(household.Children))
+	gui.Reload()
+
+	gui.setProfilePath(path)
+}
+
+// writeProfile save the household of the widgets with the year used into the file path
+func (gui *GUI) writeProfile(path string) {
+	gui.Logger.Info("Save profile", zap.String("path", path))
+	if err := profile.Save(path, gui.newProfile(path)); err != nil {
+		gui.showProfileError("Save profile", path, err)
+		return
+	}
+	gui.setProfilePath(path)
+}
+
+// newProfile returns the profile of the household of the widgets with the year used, named from the file path
+func (gui *GUI) newProfile(path string) profile.Profile {
+	gui.calculate()
+	var name = strings.TrimSuffix(filepath.Base(path), profile.EXTENSION)
+	return profile.New(name, *gui.User, gui.Config.GetTax().Year)
+}
+
+// showProfileError log the error of the action on the profile file and show it in a dialog
+func (gui *GUI) showProfileError(action string, path string, err error) {
+	gui.Logger.Error(action, zap.String("path", path), zap.Error(err))
+	dialog.ShowError(fmt.Errorf("%s: %v", gui.Language.ProfileError, err), gui.Window)
+}
+
+// setProfilePath set the current profile in the title of the window and in the recent profiles
+func (gui *GUI) setProfilePath(path string) {
+	gui.profilePath = path
+	gui.Window.SetTitle(fmt.Sprintf("%s - %s", config.APP_NAME, gui.getProfileName()))
+	gui.Settings.AddRecent(path)
+	gui.Window.SetMainMenu(gui.setMenu())
+}
+
+// getProfileName returns the name of the current profile, "profile" if no profile is opened
+func (gui *GUI) getProfileName() string {
+	if gui.profilePath == "" {
+		return "profile"
+	}
+	return strings.TrimSuffix(filepath.Base(gui.profilePath), profile.EXTENSION)
+}
+
+// getProfilesLocation returns the directory of the profiles for the file dialogs, created if needed
+func (gui *GUI) getProfilesLocation() (fyne.ListableURI, error) {
+	var dir = profile.GetProfilesPath()
+	if gui.profilePath != "" {
+		dir = filepath.Dir(gui.profilePath)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return storage.ListerForURI(storage.NewFileURI(dir))
+}
@@ -0,0 +1,301 @@
+package widgets
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Layout of the charts in pixels
+const (
+	CHART_MIN_WIDTH   float32 = 320 // Minimum width of a chart
+	CHART_MIN_HEIGHT  float32 = 160 // Minimum height of a line chart
+	CHART_BAR_HEIGHT  float32 = 36  // Height of the bar of a stacked bar
+	CHART_AXIS_MARGIN float32 = 70  // Space on the left of the plot for the labels of the Y axis
+	CHART_TEXT_SIZE   float32 = 11  // Size of the texts of the axis and the legend
+)
+
+// ChartPoint is a point of a series in the units of the data (ex: income and tax in euros)
+type ChartPoint struct {
+	X float64
+	Y float64
+}
+
+// ChartSeries is a line of a chart drawn with a color of the current theme
+type ChartSeries struct {
+	Name   string              // Name shown in the legend
+	Color  fyne.ThemeColorName // Color of the theme to draw the line (ex: theme.ColorNamePrimary)
+	Points []ChartPoint        // Points sorted by X
+}
+
+// ChartSegment is a part of a stacked bar
+type ChartSegment struct {
+	Name  string  // Name shown in the legend
+	Value float64 // Value of the part, the parts of 0 are only in the legend
+}
+
+// LineChart is a widget drawing series of points from 0 with a vertical marker, in the colors of the current theme
+type LineChart struct {
+	widget.BaseWidget
+	Series []ChartSeries // Lines of the chart
+	Marker float64       // X of the vertical marker (ex: income entered), negative to hide it
+}
+
+// CreateLineChart Create widget line chart without series
+// Returns line chart in fyne object
+func CreateLineChart() *LineChart {
+	var chart = &LineChart{Marker: -1}
+	chart.ExtendBaseWidget(chart)
+	return chart
+}
+
+// SetData change the series and the marker of the chart then draw it again
+func (chart *LineChart) SetData(series []ChartSeries, marker float64) {
+	chart.Series = series
+	chart.Marker = marker
+	chart.Refresh()
+}
+
+// CreateRenderer returns the renderer drawing the chart, implements fyne.Widget
+func (chart *LineChart) CreateRenderer() fyne.WidgetRenderer {
+	return &chartRenderer{
+		widget:  chart,
+		draw:    chart.draw,
+		minSize: func() fyne.Size { return fyne.NewSize(CHART_MIN_WIDTH, CHART_MIN_HEIGHT) },
+	}
+}
+
+// draw returns the objects of the chart for the size: axis, lines, marker and legend
+func (chart *LineChart) draw(size fyne.Size) []fyne.CanvasObject {
+	var objects []fyne.CanvasObject
+	var maxX, maxY float64
+	for _, series := range chart.Series {
+		for _, point := range series.Points {
+			maxX = math.Max(maxX, point.X)
+			maxY = math.Max(maxY, point.Y)
+		}
+	}
+	if maxX <= 0 || maxY <= 0 {
+		return objects
+	}
+
+	// Plot area under the legend and above the labels of the X axis
+	var left, top = CHART_AXIS_MARGIN, CHART_TEXT_SIZE * 2
+	var width, height = size.Width - left - 10, size.Height - top - CHART_TEXT_SIZE*2
+	if width <= 0 || height <= 0 {
+		return objects
+	}
+	var position = func(point ChartPoint) fyne.Position {
+		return fyne.NewPos(left+float32(point.X/maxX)*width, top+height-float32(point.Y/maxY)*height)
+	}
+
+	// Axis with the maximums
+	var foreground = getThemeColor(theme.ColorNameForeground)
+	objects = append(objects,
+		newLine(foreground, 1, fyne.NewPos(left, top), fyne.NewPos(left, top+height)),
+		newLine(foreground, 1, fyne.NewPos(left, top+height), fyne.NewPos(left+width, top+height)),
+		newText(formatChartValue(maxY), foreground, fyne.NewPos(0, top-CHART_TEXT_SIZE/2), fyne.TextAlignTrailing, left-6),
+		newText("0", foreground, fyne.NewPos(0, top+height-CHART_TEXT_SIZE/2), fyne.TextAlignTrailing, left-6),
+		newText(formatChartValue(maxX), foreground, fyne.NewPos(left+width-100, top+height+2), fyne.TextAlignTrailing, 100),
+	)
+
+	// Marker behind the lines
+	if chart.Marker >= 0 && chart.Marker <= maxX {
+		var x = position(ChartPoint{X: chart.Marker}).X
+		objects = append(objects, newLine(getThemeColor(theme.ColorNameDisabled), 1, fyne.NewPos(x, top), fyne.NewPos(x, top+height)))
+	}
+
+	var legend = left
+	for _, series := range chart.Series {
+		var lineColor = getThemeColor(series.Color)
+		for i := 1; i < len(series.Points); i++ {
+			objects = append(objects, newLine(lineColor, 2, position(series.Points[i-1]), position(series.Points[i])))
+		}
+
+		// Point of the series at the marker
+		if y, ok := interpolate(series.Points, chart.Marker); ok {
+			var dot = canvas.NewCircle(lineColor)
+			var center = position(ChartPoint{X: chart.Marker, Y: y})
+			dot.Move(fyne.NewPos(center.X-4, center.Y-4))
+			dot.Resize(fyne.NewSize(8, 8))
+			objects = append(objects, dot)
+		}
+
+		// Legend on the top
+		var box = canvas.NewRectangle(lineColor)
+		box.Move(fyne.NewPos(legend, CHART_TEXT_SIZE/2))
+		box.Resize(fyne.NewSize(CHART_TEXT_SIZE, CHART_TEXT_SIZE))
+		var name = newText(series.Name, foreground, fyne.NewPos(legend+CHART_TEXT_SIZE+4, 0), fyne.TextAlignLeading, 0)
+		objects = append(objects, box, name)
+		legend += CHART_TEXT_SIZE + 16 + name.MinSize().Width
+	}
+	return objects
+}
+
+// StackedBar is a widget drawing the parts of a total in a bar with a legend, in the colors of the current theme
+type StackedBar struct {
+	widget.BaseWidget
+	Segments []ChartSegment // Parts of the bar from the left
+}
+
+// CreateStackedBar Create widget stacked bar without segments
+// Returns stacked bar in fyne object
+func CreateStackedBar() *StackedBar {
+	var bar = &StackedBar{}
+	bar.ExtendBaseWidget(bar)
+	return bar
+}
+
+// SetData change the segments of the bar then draw it again
+func (bar *StackedBar) SetData(segments []ChartSegment) {
+	bar.Segments = segments
+	bar.Refresh()
+}
+
+// CreateRenderer returns the renderer drawing the bar, implements fyne.Widget
+func (bar *StackedBar) CreateRenderer() fyne.WidgetRenderer {
+	return &chartRenderer{
+		widget: bar,
+		draw:   bar.draw,
+		minSize: func() fyne.Size {
+			return fyne.NewSize(CHART_MIN_WIDTH, CHART_BAR_HEIGHT+6+CHART_TEXT_SIZE*2*float32(len(bar.Segments)))
+		},
+	}
+}
+
+// draw returns the objects of the bar for the size: a rectangle for each segment then the legend
+// the segments have the primary color of the theme darker from the first to the last
+func (bar *StackedBar) draw(size fyne.Size) []fyne.CanvasObject {
+	var objects []fyne.CanvasObject
+	var foreground = getThemeColor(theme.ColorNameForeground)
+	var total float64
+	for _, segment := range bar.Segments {
+		total += math.Max(segment.Value, 0)
+	}
+
+	var outline = canvas.NewRectangle(color.Transparent)
+	outline.StrokeColor = getThemeColor(theme.ColorNameDisabled)
+	outline.StrokeWidth = 1
+	outline.Resize(fyne.NewSize(size.Width, CHART_BAR_HEIGHT))
+	objects = append(objects, outline)
+
+	var x float32
+	for i, segment := range bar.Segments {
+		var segmentColor = getSegmentColor(i, len(bar.Segments))
+		if total > 0 && segment.Value > 0 {
+			var width = float32(segment.Value/total) * size.Width
+			var rectangle = canvas.NewRectangle(segmentColor)
+			rectangle.Move(fyne.NewPos(x, 0))
+			rectangle.Resize(fyne.NewSize(width, CHART_BAR_HEIGHT))
+			objects = append(objects, rectangle)
+			x += width
+		}
+
+		// Legend under the bar, a line for each segment
+		var y = CHART_BAR_HEIGHT + 6 + float32(i)*CHART_TEXT_SIZE*2
+		var box = canvas.NewRectangle(segmentColor)
+		box.Move(fyne.NewPos(0, y+CHART_TEXT_SIZE/2))
+		box.Resize(fyne.NewSize(CHART_TEXT_SIZE, CHART_TEXT_SIZE))
+		var text = segment.Name + ": " + formatChartValue(segment.Value)
+		objects = append(objects, box, newText(text, foreground, fyne.NewPos(CHART_TEXT_SIZE+6, y), fyne.TextAlignLeading, 0))
+	}
+	return objects
+}
+
+// chartRenderer draws a chart again with its size on each layout or refresh
+type chartRenderer struct {
+	widget  fyne.Widget                              // Chart drawn
+	draw    func(size fyne.Size) []fyne.CanvasObject // Returns the objects of the chart for the size
+	minSize func() fyne.Size                         // Returns the minimum size of the chart for its data
+	objects []fyne.CanvasObject                      // Objects drawn for the last size
+}
+
+// Layout draw the chart for the size, implements fyne.WidgetRenderer
+func (renderer *chartRenderer) Layout(size fyne.Size) {
+	renderer.objects = renderer.draw(size)
+}
+
+// MinSize returns the minimum size of the chart, implements fyne.WidgetRenderer
+func (renderer *chartRenderer) MinSize() fyne.Size {
+	return renderer.minSize()
+}
+
+// Refresh draw the chart again with the data and the colors of the theme, implements fyne.WidgetRenderer
+func (renderer *chartRenderer) Refresh() {
+	renderer.Layout(renderer.widget.Size())
+	canvas.Refresh(renderer.widget)
+}
+
+// Objects returns the objects drawn, implements fyne.WidgetRenderer
+func (renderer *chartRenderer) Objects() []fyne.CanvasObject {
+	return renderer.objects
+}
+
+// Destroy does nothing, implements fyne.WidgetRenderer
+func (renderer *chartRenderer) Destroy() {}
+
+// interpolate returns the Y of the points at x on the line between the two points around it
+// returns false if x is out of the points
+func interpolate(points []ChartPoint, x float64) (float64, bool) {
+	for i := 1; i < len(points); i++ {
+		var a, b = points[i-1], points[i]
+		if x < a.X || x > b.X {
+			continue
+		}
+		if b.X == a.X {
+			return b.Y, true
+		}
+		return a.Y + (b.Y-a.Y)*(x-a.X)/(b.X-a.X), true
+	}
+	return 0, false
+}
+
+// getThemeColor returns the color of the current theme of the application (dark or light)
+func getThemeColor(name fyne.ThemeColorName) color.Color {
+	var settings = fyne.CurrentApp().Settings()
+	return settings.Theme().Color(name, settings.ThemeVariant())
+}
+
+// getSegmentColor returns the primary color of the theme more opaque for each segment from the first to the last
+func getSegmentColor(index int, count int) color.Color {
+	var primary = color.NRGBAModel.Convert(getThemeColor(theme.ColorNamePrimary)).(color.NRGBA)
+	var alpha float64 = 1
+	if count > 1 {
+		alpha = 0.3 + 0.7*float64(index)/float64(count-1)
+	}
+	primary.A = uint8(float64(primary.A) * alpha)
+	return primary
+}
+
+// formatChartValue returns the value rounded to the unit (ex: 3043.59 > 3044)
+func formatChartValue(value float64) string {
+	return strconv.FormatFloat(math.Round(value), 'f', 0, 64)
+}
+
+// newLine returns a line between the positions
+func newLine(lineColor color.Color, width float32, from fyne.Position, to fyne.Position) *canvas.Line {
+	var line = canvas.NewLine(lineColor)
+	line.StrokeWidth = width
+	line.Position1 = from
+	line.Position2 = to
+	return line
+}
+
+// newText returns a text of the size of the charts at the position, aligned in the width if it's not 0
+func newText(text string, textColor color.Color, position fyne.Position, align fyne.TextAlign, width float32) *canvas.Text {
+	var label = canvas.NewText(text, textColor)
+	label.TextSize = CHART_TEXT_SIZE
+	label.Alignment = align
+	label.Move(position)
+	if width > 0 {
+		label.Resize(fyne.NewSize(width, CHART_TEXT_SIZE*1.5))
+	} else {
+		label.Resize(label.MinSize())
+	}
+	return label
+}
@@ -12,3 +12,13 @@ func CreateStatusRadio() *widget.RadioGroup {
 	radio.Horizontal = true
 	return radio
 }
+
+// CreateModeRadio Create widget radioGroup for the mode of calculation (income > tax or net > income)
+// Returns radioGroup in fyne object with the first mode selected
+func CreateModeRadio(modes []string) *widget.RadioGroup {
+	var radio = widget.NewRadioGroup(modes, nil)
+	radio.SetSelected(modes[0])
+	radio.Horizontal = true
+	radio.Required = true
+	return radio
+}
@@ -13,3 +13,11 @@ func CreateChildrenSelect() *widget.SelectEntry {
 	sel.Validator = validation.NewRegexp("^[0-9]{1,}$", "Not a number")
 	return sel
 }
+
+// CreateYearSelect Create widget select for the year of the tax scale
+// Returns select in fyne object with the year selected
+func CreateYearSelect(years []string, selected string) *widget.Select {
+	var sel = widget.NewSelect(years, nil)
+	sel.SetSelected(selected)
+	return sel
+}
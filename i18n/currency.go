@@ -1,4 +1,8 @@
-package settings
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package i18n loads the texts of each language of the GUI and of the reports without depending on the GUI toolkit
+package i18n
 
 // Enum for currency
 const (
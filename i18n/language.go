@@ -0,0 +1,209 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package i18n loads the texts of each language of the GUI and of the reports without depending on the GUI toolkit
+package i18n
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/LucasNoga/corpos-christie/resources"
+	"gopkg.in/yaml.v3"
+)
+
+// Enum for languages
+const (
+	FRENCH  string = "fr"
+	ENGLISH string = "en"
+)
+
+// ThemeYaml Yaml struct for theme's app
+type ThemeYaml struct {
+	Dark  string `yaml:"dark"`
+	Light string `yaml:"light"`
+}
+
+// Languages yaml struct for theme's app
+type LanguageYaml struct {
+	English string `yaml:"english"`
+	French  string `yaml:"french"`
+}
+
+// About text yaml struct for theme's app
+type AboutYaml struct {
+	Text1 string `yaml:"text_1"`
+	Text2 string `yaml:"text_2"`
+	Text3 string `yaml:"text_3"`
+	Text4 string `yaml:"text_4"`
+	Text5 string `yaml:"text_5"`
+}
+
+// Headers yaml for tax detail
+type TaxHeadersYaml struct {
+	Header1 string `yaml:"header_1"`
+	Header2 string `yaml:"header_2"`
+	Header3 string `yaml:"header_3"`
+	Header4 string `yaml:"header_4"`
+	Header5 string `yaml:"header_5"`
+}
+
+// Texts yaml for the report of a tax simulation (PDF) and the exports of raw data (CSV, XLSX)
+type ReportYaml struct {
+	Title         string `yaml:"title"`
+	Year          string `yaml:"year"`
+	TaxYear       string `yaml:"tax_year"`
+	Household     string `yaml:"household"`
+	Results       string `yaml:"results"`
+	Tranches      string `yaml:"tranches"`
+	Scales        string `yaml:"scales"`
+	Income        string `yaml:"income"`
+	Status        string `yaml:"status"`
+	Single        string `yaml:"single"`
+	Couple        string `yaml:"couple"`
+	Children      string `yaml:"children"`
+	Shares        string `yaml:"shares"`
+	Decote        string `yaml:"decote"`
+	Tax           string `yaml:"tax"`
+	HighIncomeTax string `yaml:"high_income_tax"`
+	NetTax        string `yaml:"net_tax"`
+	Remainder     string `yaml:"remainder"`
+	MarginalRate  string `yaml:"marginal_rate"`
+	AverageRate   string `yaml:"average_rate"`
+	Tranche       string `yaml:"tranche"`
+	Generated     string `yaml:"generated"`
+	ExportPDF     string `yaml:"export_pdf"`
+	ExportResults string `yaml:"export_results"`
+	ExportScales  string `yaml:"export_scales"`
+}
+
+// Texts yaml for the details of the household: incomes of each declarant, children and particular cases
+type HouseholdYaml struct {
+	Details     string `yaml:"details"`
+	Declarant   string `yaml:"declarant"`
+	Salaries    string `yaml:"salaries"`
+	Pensions    string `yaml:"pensions"`
+	Business    string `yaml:"business"`
+	Property    string `yaml:"property"`
+	Disabled    string `yaml:"disabled"`
+	Veteran     string `yaml:"veteran"`
+	Widowed     string `yaml:"widowed"`
+	Isolated    string `yaml:"isolated"`
+	Child       string `yaml:"child"`
+	BirthYear   string `yaml:"birth_year"`
+	Alternating string `yaml:"alternating"`
+}
+
+// Handle all data about language data
+type Yaml struct {
+	Code            string         // code of the language (fr, en, etc...)
+	Theme           ThemeYaml      `yaml:"themes"`
+	Languages       LanguageYaml   `yaml:"languages"`
+	Abouts          AboutYaml      `yaml:"abouts"`
+	TaxHeaders      TaxHeadersYaml `yaml:"tax_headers"`
+	Report          ReportYaml     `yaml:"report"`
+	Household       HouseholdYaml  `yaml:"household"`
+	File            string         `yaml:"file"`
+	Settings        string         `yaml:"settings"`
+	Income          string         `yaml:"income"`
+	Mode            string         `yaml:"mode"`
+	ModeTax         string         `yaml:"mode_tax"`
+	ModeReverse     string         `yaml:"mode_reverse"`
+	RemainderWished string         `yaml:"remainder_wished"`
+	Year            string         `yaml:"year"`
+	IncomeResult    string         `yaml:"income_result"`
+	Status          string         `yaml:"status"`
+	Children        string         `yaml:"children"`
+	Tax             string         `yaml:"tax"`
+	Remainder       string         `yaml:"remainder"`
+	Share           string         `yaml:"share"`
+	HighIncomeTax   string         `yaml:"high_income_tax"`
+	MarginalRate    string         `yaml:"marginal_rate"`
+	AverageRate     string         `yaml:"average_rate"`
+	NextIncomeCost  string         `yaml:"next_income_cost"`
+	WithholdingRate string         `yaml:"withholding_rate"`
+	NeutralRate     string         `yaml:"neutral_rate"`
+	Simulation      string         `yaml:"simulation"`
+	Charts          string         `yaml:"charts"`
+	ChartCurve      string         `yaml:"chart_curve"`
+	ChartTranches   string         `yaml:"chart_tranches"`
+	ChartTax        string         `yaml:"chart_tax"`
+	ChartNet        string         `yaml:"chart_net"`
+	Compare         string         `yaml:"compare"`
+	Scenarios       string         `yaml:"scenarios"`
+	Scenario        string         `yaml:"scenario"`
+	Save            string         `yaml:"save"`
+	SaveAs          string         `yaml:"save_as"`
+	Open            string         `yaml:"open"`
+	OpenRecent      string         `yaml:"open_recent"`
+	ProfileError    string         `yaml:"profile_error"`
+	ThemeCode       string         `yaml:"theme"`
+	LanguageCode    string         `yaml:"language"`
+	Currency        string         `yaml:"currency"`
+	Logs            string         `yaml:"logs"`
+	Help            string         `yaml:"help"`
+	About           string         `yaml:"about"`
+	Author          string         `yaml:"author"`
+	Close           string         `yaml:"close"`
+	Quit            string         `yaml:"quit"`
+}
+
+// LoadLanguage read the texts of the language code (fr, en) embedded in the program
+// returns an error if the language doesn't exist
+func LoadLanguage(code string) (Yaml, error) {
+	var language Yaml
+	data, err := resources.Languages.ReadFile(fmt.Sprintf("languages/%s.yaml", code))
+	if err != nil {
+		return language, fmt.Errorf("unknown language %s", code)
+	}
+	if err := yaml.Unmarshal(data, &language); err != nil {
+		return language, fmt.Errorf("language file %s: %w", code, err)
+	}
+	language.Code = code
+	return language, nil
+}
+
+// GetLanguage get value of last language selected (fr, en)
+func GetDefaultLanguage() string {
+	return ENGLISH
+}
+
+// GetThemes parse ThemeYaml struct to get value of each field
+func (yaml *Yaml) GetThemes() []string {
+	v := reflect.ValueOf(yaml.Theme)
+	themes := make([]string, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		themes[i] = v.Field(i).String()
+	}
+	return themes
+}
+
+// GetLanguages parse LanguageYaml struct to get value of each field
+func (yaml *Yaml) GetLanguages() []string {
+	v := reflect.ValueOf(yaml.Languages)
+	languages := make([]string, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		languages[i] = v.Field(i).String()
+	}
+	return languages
+}
+
+// GetAbouts parse AboutYaml struct to get value of each field
+func (yaml *Yaml) GetAbouts() []string {
+	v := reflect.ValueOf(yaml.Abouts)
+	abouts := make([]string, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		abouts[i] = v.Field(i).String()
+	}
+	return abouts
+}
+
+// GetTaxHeaders parse TaxHeadersYaml struct to get value of each field
+func (yaml *Yaml) GetTaxHeaders() []string {
+	v := reflect.ValueOf(yaml.TaxHeaders)
+	taxHeaders := make([]string, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		taxHeaders[i] = v.Field(i).String()
+	}
+	return taxHeaders
+}
@@ -20,7 +20,11 @@ func init() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile) // get line and file log
 
 	// Setup config
-	cfg = config.New()
+	var err error
+	cfg, err = config.New()
+	if err != nil {
+		log.Fatalf("Error: loading configuration, details: %v", err)
+	}
 }
 
 // Launching program
@@ -0,0 +1,212 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package money define an exact amount of money to calculate taxes without float errors
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount of money in cents of euro
+type Money int64
+
+// Rate is a percentage in hundredths of percent (ex: 45.25% is 4525)
+type Rate int64
+
+// Limits of amounts
+const (
+	ZERO Money = 0             // No money
+	MAX  Money = math.MaxInt64 // Amount without limit, used for the last tranche of a scale
+
+	// MAX_AMOUNT is the largest amount parsed, 1000 billions of euros
+	// far over any income and small enough for the calculations of rates not to overflow
+	MAX_AMOUNT Money = 1000000000000 * 100
+)
+
+// Euros create an amount from a number of euros
+func Euros(euros int) Money {
+	return Money(euros) * 100
+}
+
+// Cents create an amount from a number of cents
+func Cents(cents int64) Money {
+	return Money(cents)
+}
+
+// Parse convert a string like '1234', '1234.5' or '1234,56' in euros into an amount
+// returns an error if the string is not a valid amount or is over MAX_AMOUNT
+func Parse(str string) (Money, error) {
+	var s = strings.ReplaceAll(strings.TrimSpace(str), ",", ".")
+	cents, err := parseHundredths(s, int64(MAX_AMOUNT))
+	if err != nil {
+		return ZERO, fmt.Errorf("invalid amount %q: %v", str, err)
+	}
+	return Money(cents), nil
+}
+
+// ParseRate convert a string percentage like '11%' or '45.25%' into a rate
+// returns an error if the string is not a valid percentage
+func ParseRate(str string) (Rate, error) {
+	var s = strings.TrimSuffix(strings.TrimSpace(str), "%")
+	hundredths, err := parseHundredths(s, math.MaxInt64)
+	if err != nil {
+		return 0, errors.New("invalid rate " + str + ": " + err.Error())
+	}
+	return Rate(hundredths), nil
+}
+
+// parseHundredths convert a decimal number with an optional leading sign and at most 2 decimals
+// into a number of hundredths (ex: '-12.5' is -1250)
+// returns an error if the number has other characters than digits or its absolute value is over max hundredths
+func parseHundredths(s string, max int64) (int64, error) {
+	var negative = strings.HasPrefix(s, "-")
+	if negative || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+
+	var parts = strings.SplitN(s, ".", 2)
+	if !isDigits(parts[0]) {
+		return 0, errors.New("expected a number")
+	}
+	units, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || units > max/100 {
+		return 0, fmt.Errorf("expected at most %d", max/100)
+	}
+
+	var hundredths int64
+	if len(parts) == 2 {
+		var decimals = parts[1]
+		if len(decimals) == 0 || len(decimals) > 2 || !isDigits(decimals) {
+			return 0, errors.New("expected at most 2 decimals")
+		}
+		if len(decimals) == 1 {
+			decimals += "0"
+		}
+		hundredths, _ = strconv.ParseInt(decimals, 10, 64)
+	}
+
+	var number = units * 100
+	if number > max-hundredths {
+		return 0, fmt.Errorf("expected at most %d", max/100)
+	}
+	number += hundredths
+	if negative {
+		number = -number
+	}
+	return number, nil
+}
+
+// isDigits returns true if the string is not empty and has only the digits 0 to 9
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Percent returns the rate in percent (ex: 45.25)
+func (r Rate) Percent() float64 {
+	return float64(r) / 100
+}
+
+// Euros returns the amount in euros with cents as decimals
+func (m Money) Euros() float64 {
+	return float64(m) / 100
+}
+
+// Int returns the amount in whole euros, cents are truncated
+func (m Money) Int() int {
+	return int(m / 100)
+}
+
+// Round round the amount to the nearest euro, half an euro is rounded up (CGI art. 1657)
+func (m Money) Round() Money {
+	if m < 0 {
+		return -(-m).Round()
+	}
+	return (m + 50) / 100 * 100
+}
+
+// Floor round the amount to the euro below
+func (m Money) Floor() Money {
+	if m < 0 && m%100 != 0 {
+		return (m/100 - 1) * 100
+	}
+	return m / 100 * 100
+}
+
+// ApplyRate multiply the amount by the rate
+// returns the amount rounded to the nearest cent
+func (m Money) ApplyRate(rate Rate) Money {
+	return mulDivRound(int64(m), int64(rate), 10000)
+}
+
+// Mul multiply the amount by the fraction numerator/denominator
+// returns the amount rounded to the nearest cent
+func (m Money) Mul(numerator int64, denominator int64) Money {
+	return mulDivRound(int64(m), numerator, denominator)
+}
+
+// Min returns the lowest amount between m and other
+func (m Money) Min(other Money) Money {
+	if m < other {
+		return m
+	}
+	return other
+}
+
+// Max returns the greatest amount between m and other
+func (m Money) Max(other Money) Money {
+	if m > other {
+		return m
+	}
+	return other
+}
+
+// String returns the amount in euros, with cents only if there are some (ex: 1234 or 1234.50)
+func (m Money) String() string {
+	if m == MAX {
+		return "-"
+	}
+	if m%100 == 0 {
+		return strconv.FormatInt(int64(m/100), 10)
+	}
+	return fmt.Sprintf("%.2f", m.Euros())
+}
+
+// mulDivRound multiply value by numerator then divide by denominator and round half away from zero
+// The product is calculated on 128 bits so it can't overflow before the division
+func mulDivRound(value int64, numerator int64, denominator int64) Money {
+	var negative = (value < 0) != (numerator < 0) != (denominator < 0)
+	hi, lo := bits.Mul64(abs(value), abs(numerator))
+
+	// Add the half of the denominator to round
+	var carry uint64
+	lo, carry = bits.Add64(lo, abs(denominator)/2, 0)
+	hi += carry
+
+	quotient, _ := bits.Div64(hi, lo, abs(denominator))
+	if negative {
+		return -Money(quotient)
+	}
+	return Money(quotient)
+}
+
+// abs returns the absolute value of n
+func abs(n int64) uint64 {
+	if n < 0 {
+		return uint64(-n)
+	}
+	return uint64(n)
+}
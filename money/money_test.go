@@ -0,0 +1,145 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package money define an exact amount of money to calculate taxes without float errors
+package money
+
+import (
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd money
+// $ go test -v
+
+// Parse amounts with or without cents
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected Money
+	}{
+		{"30000", Euros(30000)},
+		{"1234.5", Cents(123450)},
+		{"1234,56", Cents(123456)},
+		{" 42 ", Euros(42)},
+		{"-0.05", Cents(-5)},
+		{"+12", Euros(12)},
+		{"1000000000000", MAX_AMOUNT},
+	}
+
+	for _, test := range tests {
+		amount, err := Parse(test.input)
+		if err != nil || amount != test.expected {
+			t.Errorf("Expected that the amount of %s %s should be equal to %s", test.input, colors.Red(test.expected), colors.Red(amount))
+		}
+	}
+}
+
+// Parse invalid amounts returns an error
+func TestParseInvalid(t *testing.T) {
+	for _, input := range []string{"", "abc", "12.345", "12.", "--5", "-+5", "+-5", "1.-5", "1.+5", "1.a", "- 5", "99999999999999999", "1000000000000.01"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Expected an error for the amount %s", colors.Red(input))
+		}
+	}
+}
+
+// Parse rates with decimals
+func TestParseRate(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected Rate
+	}{
+		{"11%", 1100},
+		{"45.25%", 4525},
+		{"0.5%", 50},
+	}
+
+	for _, test := range tests {
+		rate, err := ParseRate(test.input)
+		if err != nil || rate != test.expected {
+			t.Errorf("Expected that the rate of %s %s should be equal to %s", test.input, colors.Red(test.expected), colors.Red(rate))
+		}
+	}
+}
+
+// Parse invalid rates returns an error, the sign is only allowed before the number
+func TestParseRateInvalid(t *testing.T) {
+	for _, input := range []string{"", "%", "--5%", "1.-5%", "1.+5%", "5a%", "99999999999999999999%", "92233720368547758.08%"} {
+		if _, err := ParseRate(input); err == nil {
+			t.Errorf("Expected an error for the rate %s", colors.Red(input))
+		}
+	}
+}
+
+// Round to the nearest euro, half an euro is rounded up
+func TestRound(t *testing.T) {
+	var tests = []struct {
+		amount   Money
+		expected Money
+	}{
+		{Cents(322549), Euros(3225)},
+		{Cents(322550), Euros(3226)},
+		{Cents(-322550), Euros(-3226)},
+		{Euros(3225), Euros(3225)},
+	}
+
+	for _, test := range tests {
+		if test.amount.Round() != test.expected {
+			t.Errorf("Expected that the rounded amount %s should be equal to %s", colors.Red(test.expected), colors.Red(test.amount.Round()))
+		}
+	}
+}
+
+// Floor to the euro below
+func TestFloor(t *testing.T) {
+	if Cents(3000099).Floor() != Euros(30000) {
+		t.Errorf("Expected that the amount %s should be equal to %s", colors.Red(Euros(30000)), colors.Red(Cents(3000099).Floor()))
+	}
+	if Cents(-50).Floor() != Euros(-1) {
+		t.Errorf("Expected that the amount %s should be equal to %s", colors.Red(Euros(-1)), colors.Red(Cents(-50).Floor()))
+	}
+}
+
+// Apply a rate on an amount without float errors
+// 11% of 29325 is 3225.75 exactly
+func TestApplyRate(t *testing.T) {
+	var amount = Euros(29325).ApplyRate(1100)
+	if amount != Cents(322575) {
+		t.Errorf("Expected that the amount %s should be equal to %s", colors.Red(Cents(322575)), colors.Red(amount))
+	}
+
+	// 45.25% of 0.01 is rounded to 0
+	if Cents(1).ApplyRate(4525) != ZERO {
+		t.Errorf("Expected that the amount %s should be equal to %s", colors.Red(ZERO), colors.Red(Cents(1).ApplyRate(4525)))
+	}
+}
+
+// Multiply an amount by a fraction like the shares in quarters
+func TestMul(t *testing.T) {
+	var amount = Euros(10225).Mul(10, 4)
+	if amount != Cents(2556250) {
+		t.Errorf("Expected that the amount %s should be equal to %s", colors.Red(Cents(2556250)), colors.Red(amount))
+	}
+}
+
+// Show amounts with cents only if needed
+func TestString(t *testing.T) {
+	var tests = []struct {
+		amount   Money
+		expected string
+	}{
+		{Euros(3225), "3225"},
+		{Cents(322575), "3225.75"},
+		{Cents(-5), "-0.05"},
+		{MAX, "-"},
+	}
+
+	for _, test := range tests {
+		if test.amount.String() != test.expected {
+			t.Errorf("Expected that the string %s should be equal to %s", colors.Red(test.expected), colors.Red(test.amount.String()))
+		}
+	}
+}
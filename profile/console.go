@@ -0,0 +1,94 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package profile saves and loads the households of the users into versioned JSON files
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/tax"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// StartSaveProfile save the household of the user with the year used into a profile of the directory dir
+// the name is asked again while it's not valid, an existing profile is replaced only if the user confirms
+func StartSaveProfile(prompter *utils.Prompter, dir string, cfg *config.Config, user *user.User) {
+	var name, path string
+	err := prompter.Ask("Name of the profile ? ", func(input string) error {
+		var err error
+		name = input
+		path, err = GetPath(dir, input)
+		return err
+	})
+	if err != nil {
+		log.Printf("Error: asking profile name, details: %v", err)
+		return
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		replace, err := prompter.AskYesNo("The profile already exists, do you want to replace it (Y/n) ? ")
+		if err != nil || !replace {
+			prompter.Println("Profile not saved")
+			return
+		}
+	}
+
+	if err := Save(path, New(name, *user, cfg.GetTax().Year)); err != nil {
+		log.Printf("Error: saving profile, details: %v", err)
+		prompter.Println(colors.Red("Profile not saved"))
+		return
+	}
+	prompter.Printf("Profile %s saved into %s\n", colors.Teal(name), colors.Teal(path))
+}
+
+// StartLoadProfile load a profile of the directory dir into the user and select the year of the profile
+// the name is asked again while the profile can't be loaded
+func StartLoadProfile(prompter *utils.Prompter, dir string, cfg *config.Config, user *user.User) {
+	names, err := List(dir)
+	if err != nil {
+		log.Printf("Error: listing profiles, details: %v", err)
+		return
+	}
+	if len(names) == 0 {
+		prompter.Printf("No profile saved in %s\n", colors.Teal(dir))
+		return
+	}
+	prompter.Printf("List of profiles: %s\n", strings.Join(names, " "))
+
+	var profile Profile
+	err = prompter.Ask("Which profile do you want ? ", func(input string) error {
+		path, err := GetPath(dir, input)
+		if err != nil {
+			return err
+		}
+		profile, err = Load(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("the profile '%s' doesn't exist", input)
+		}
+		if err != nil {
+			return err
+		}
+		return profile.Apply(user)
+	})
+	if err != nil {
+		log.Printf("Error: asking profile, details: %v", err)
+		return
+	}
+
+	if tax, err := cfg.FindTax(profile.Year); err == nil {
+		cfg.Tax = tax
+	}
+	prompter.Printf("Profile %s loaded, income of %s € in %s\n", colors.Teal(profile.Name), colors.Teal(user.Income), colors.Teal(cfg.GetTax().Year))
+
+	var result = tax.CalculateTax(*user, cfg)
+	prompter.Printf("Tax:\t\t%s €\n", colors.Green(result.Tax))
+	prompter.Printf("Remainder:\t%s €\n", colors.Green(result.Remainder))
+}
@@ -0,0 +1,356 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package profile saves and loads the households of the users into versioned JSON files
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+)
+
+// SCHEMA_VERSION is the version of the format of the profile files written by the program
+// Increase it when the format changes and convert the old versions in migrate
+// Version 2 adds the details of the declarants and the isolated flag replacing the cohabitation
+const SCHEMA_VERSION int = 2
+
+// EXTENSION is the extension of the profile files
+const EXTENSION string = ".json"
+
+// ErrInvalidProfile is returned when a profile file can't be read
+var ErrInvalidProfile = errors.New("invalid profile")
+
+// ErrInvalidName is returned when the name of a profile can't be used as a file name
+var ErrInvalidName = errors.New("invalid profile name")
+
+// Profile is a named household saved in a file, the amounts are in euros
+type Profile struct {
+	SchemaVersion   int         `json:"schema_version"`
+	Name            string      `json:"name"`
+	Year            int         `json:"year,omitempty"` // Year of the tax scale, 0 to keep the year used
+	Income          float64     `json:"income"`
+	IsInCouple      bool        `json:"couple"`
+	Children        int         `json:"children"`
+	PreviousIncomes [2]float64  `json:"previous_incomes"`
+	Credits         Credits     `json:"credits"`
+	Declarants      []Declarant `json:"declarants,omitempty"` // Details of each declarant, empty if only the income is known
+	Dependents      []Dependent `json:"dependents,omitempty"` // Details of each child, empty if unknown
+	IsIsolated      bool        `json:"isolated,omitempty"`   // Single declarant living alone with the children (case T)
+	IsWidowed       bool        `json:"widowed,omitempty"`
+	Invalids        int         `json:"invalids,omitempty"` // Disabled declarants and veterans
+}
+
+// Declarant is the incomes by category and the particular cases of a declarant of the household
+type Declarant struct {
+	Salaries   float64 `json:"salaries"`
+	Pensions   float64 `json:"pensions"`
+	Business   float64 `json:"business"`
+	Property   float64 `json:"property"`
+	IsDisabled bool    `json:"disabled,omitempty"`
+	IsVeteran  bool    `json:"veteran,omitempty"`
+}
+
+// Dependent is the details of a child of the household
+type Dependent struct {
+	BirthYear     int  `json:"birth_year,omitempty"`
+	IsAlternating bool `json:"alternating,omitempty"`
+	IsDisabled    bool `json:"disabled,omitempty"`
+}
+
+// Credits is the expenses of the household giving right to tax reductions and tax credits
+type Credits struct {
+	Donations         float64 `json:"donations"`
+	AidDonations      float64 `json:"aid_donations"`
+	HomeEmployment    float64 `json:"home_employment"`
+	ChildcareExpenses float64 `json:"childcare_expenses"`
+	YoungChildren     int     `json:"young_children"`
+}
+
+// New create the profile of the household of the user with the year of the tax scale
+func New(name string, user user.User, year int) Profile {
+	return Profile{
+		SchemaVersion: SCHEMA_VERSION,
+		Name:          name,
+		Year:          year,
+		Income:        user.Income.Euros(),
+		IsInCouple:    user.IsInCouple,
+		Children:      user.Children,
+		PreviousIncomes: [2]float64{
+			user.PreviousIncomes[0].Euros(),
+			user.PreviousIncomes[1].Euros(),
+		},
+		Credits: Credits{
+			Donations:         user.Credits.Donations.Euros(),
+			AidDonations:      user.Credits.AidDonations.Euros(),
+			HomeEmployment:    user.Credits.HomeEmployment.Euros(),
+			ChildcareExpenses: user.Credits.ChildcareExpenses.Euros(),
+			YoungChildren:     user.Credits.YoungChildren,
+		},
+		Declarants: newDeclarants(user.Declarants),
+		Dependents: newDependents(user.Dependents),
+		IsIsolated: !user.IsInCouple && !user.IsCohabiting && !user.Cases.IsWidowed,
+		IsWidowed:  user.Cases.IsWidowed,
+		Invalids:   user.Cases.Invalids,
+	}
+}
+
+// newDeclarants returns the details of the declarants of the household to save
+func newDeclarants(declarants []user.Declarant) []Declarant {
+	var details []Declarant
+	for _, declarant := range declarants {
+		details = append(details, Declarant{
+			Salaries:   declarant.Incomes.Salaries.Euros(),
+			Pensions:   declarant.Incomes.Pensions.Euros(),
+			Business:   declarant.Incomes.Business.Euros(),
+			Property:   declarant.Incomes.Property.Euros(),
+			IsDisabled: declarant.IsDisabled,
+			IsVeteran:  declarant.IsVeteran,
+		})
+	}
+	return details
+}
+
+// newDependents returns the details of the children of the household to save
+func newDependents(dependents []user.Dependent) []Dependent {
+	var details []Dependent
+	for _, dependent := range dependents {
+		details = append(details, Dependent{
+			BirthYear:     dependent.BirthYear,
+			IsAlternating: dependent.IsAlternating,
+			IsDisabled:    dependent.IsDisabled,
+		})
+	}
+	return details
+}
+
+// Apply set the household of the profile into the user, the results of the user are reset
+// A single declarant neither widowed nor isolated lives with a partner
+// returns an error if an amount is negative or the number of declarants, of children or of invalids is not valid
+func (profile Profile) Apply(u *user.User) error {
+	var household user.User
+	household.IsInCouple = profile.IsInCouple
+	household.Children = profile.Children
+	household.Credits.YoungChildren = profile.Credits.YoungChildren
+	household.IsCohabiting = !profile.IsInCouple && !profile.IsWidowed && !profile.IsIsolated
+	household.Cases = user.Cases{IsWidowed: profile.IsWidowed, Invalids: profile.Invalids}
+	for _, declarant := range profile.Declarants {
+		household.Declarants = append(household.Declarants, user.Declarant{
+			IsDisabled: declarant.IsDisabled,
+			IsVeteran:  declarant.IsVeteran,
+		})
+	}
+	for _, dependent := range profile.Dependents {
+		household.Dependents = append(household.Dependents, user.Dependent{
+			BirthYear:     dependent.BirthYear,
+			IsAlternating: dependent.IsAlternating,
+			IsDisabled:    dependent.IsDisabled,
+		})
+	}
+
+	type amountField struct {
+		field string
+		value float64
+		money *money.Money
+	}
+	var amounts = []amountField{
+		{"income", profile.Income, &household.Income},
+		{"previous_incomes", profile.PreviousIncomes[0], &household.PreviousIncomes[0]},
+		{"previous_incomes", profile.PreviousIncomes[1], &household.PreviousIncomes[1]},
+		{"donations", profile.Credits.Donations, &household.Credits.Donations},
+		{"aid_donations", profile.Credits.AidDonations, &household.Credits.AidDonations},
+		{"home_employment", profile.Credits.HomeEmployment, &household.Credits.HomeEmployment},
+		{"childcare_expenses", profile.Credits.ChildcareExpenses, &household.Credits.ChildcareExpenses},
+	}
+	for index, declarant := range profile.Declarants {
+		var incomes = &household.Declarants[index].Incomes
+		amounts = append(amounts, []amountField{
+			{"declarants.salaries", declarant.Salaries, &incomes.Salaries},
+			{"declarants.pensions", declarant.Pensions, &incomes.Pensions},
+			{"declarants.business", declarant.Business, &incomes.Business},
+			{"declarants.property", declarant.Property, &incomes.Property},
+		}...)
+	}
+	for _, amount := range amounts {
+		value, err := money.Parse(strconv.FormatFloat(amount.value, 'f', -1, 64))
+		if err != nil || value < 0 {
+			return fmt.Errorf("%w: %s should be a positive amount", ErrInvalidProfile, amount.field)
+		}
+		*amount.money = value
+	}
+
+	var declarants = 1
+	if household.IsInCouple {
+		declarants = 2
+	}
+	if len(household.Declarants) > 0 && len(household.Declarants) != declarants {
+		return fmt.Errorf("%w: declarants should have a detail for each declarant", ErrInvalidProfile)
+	}
+	if household.Children < 0 || household.Children > user.MAX_CHILDREN {
+		return fmt.Errorf("%w: children should be between 0 and %d", ErrInvalidProfile, user.MAX_CHILDREN)
+	}
+	if household.Credits.YoungChildren < 0 || household.Credits.YoungChildren > household.Children {
+		return fmt.Errorf("%w: young_children should be between 0 and the number of children", ErrInvalidProfile)
+	}
+	if len(household.Dependents) > 0 && len(household.Dependents) != household.Children {
+		return fmt.Errorf("%w: dependents should have a detail for each child", ErrInvalidProfile)
+	}
+	if household.Cases.Invalids < 0 || household.Cases.Invalids > 2 {
+		return fmt.Errorf("%w: invalids should be between 0 and 2", ErrInvalidProfile)
+	}
+
+	*u = household
+	return nil
+}
+
+// GetProfilesPath returns the directory of the profiles in the user config directory
+// (ex: ~/.config/corpos-christie/profiles on Linux)
+func GetProfilesPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return config.PROFILES_PATH
+	}
+	return filepath.Join(dir, config.APP_NAME, config.PROFILES_PATH)
+}
+
+// GetPath returns the path of the file of the profile named name in the directory dir
+// returns ErrInvalidName if the name is empty or contains a path separator
+func GetPath(dir string, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("%w: '%s'", ErrInvalidName, name)
+	}
+	return filepath.Join(dir, name+EXTENSION), nil
+}
+
+// List returns the names of the profiles saved in the directory dir sorted by name
+// returns no name if the directory doesn't exist
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != EXTENSION {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(entry.Name(), EXTENSION))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Save write the profile into the file path, the directory of the file is created if needed
+func Save(path string, profile Profile) error {
+	profile.SchemaVersion = SCHEMA_VERSION
+	data, err := Marshal(profile)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Load read the profile of the file path
+// returns an error wrapping ErrInvalidProfile if the file is not a valid profile
+func Load(path string) (Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Profile{}, err
+	}
+	profile, err := Unmarshal(data)
+	if err != nil {
+		return Profile{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if profile.Name == "" {
+		profile.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return profile, nil
+}
+
+// Marshal convert the profile into indented JSON with the current schema version
+func Marshal(profile Profile) ([]byte, error) {
+	profile.SchemaVersion = SCHEMA_VERSION
+	data, err := json.MarshalIndent(profile, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Unmarshal read a profile in JSON of any schema version known and convert it into the current version
+// The fields unknown are ignored so a profile written by a newer program with the same version can be read
+// returns an error wrapping ErrInvalidProfile if the JSON or its schema version is not valid
+func Unmarshal(data []byte) (Profile, error) {
+	var header struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return Profile{}, fmt.Errorf("%w: %v", ErrInvalidProfile, err)
+	}
+	if header.SchemaVersion < 1 || header.SchemaVersion > SCHEMA_VERSION {
+		return Profile{}, fmt.Errorf("%w: schema version %d is not supported (supported: 1 to %d)", ErrInvalidProfile, header.SchemaVersion, SCHEMA_VERSION)
+	}
+
+	data, err := migrate(data, header.SchemaVersion)
+	if err != nil {
+		return Profile{}, fmt.Errorf("%w: %v", ErrInvalidProfile, err)
+	}
+
+	var profile Profile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return Profile{}, fmt.Errorf("%w: %v", ErrInvalidProfile, err)
+	}
+	profile.SchemaVersion = SCHEMA_VERSION
+	return profile, nil
+}
+
+// migrate convert the JSON of a profile from its schema version to the current schema version
+// Add a case for each new version converting the previous one
+func migrate(data []byte, version int) ([]byte, error) {
+	for ; version < SCHEMA_VERSION; version++ {
+		switch version {
+		case 1:
+			var err error
+			if data, err = migrateIsolated(data); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("no migration from the schema version %d", version)
+		}
+	}
+	return data, nil
+}
+
+// migrateIsolated convert a profile of the version 1 into the version 2
+// the cohabitation of a single declarant is replaced by the isolated flag: a single declarant
+// neither widowed nor living with a partner is isolated as the version 1 calculated it
+func migrateIsolated(data []byte) ([]byte, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	isInCouple, _ := fields["couple"].(bool)
+	isWidowed, _ := fields["widowed"].(bool)
+	isCohabiting, _ := fields["cohabiting"].(bool)
+	delete(fields, "cohabiting")
+	fields["isolated"] = !isInCouple && !isWidowed && !isCohabiting
+	fields["schema_version"] = 2
+	return json.Marshal(fields)
+}
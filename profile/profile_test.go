@@ -0,0 +1,219 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package profile saves and loads the households of the users into versioned JSON files
+package profile
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/tax"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd profile
+// $ go test -v
+
+// newTestUser returns a household with all the fields of a profile
+func newTestUser() user.User {
+	return user.User{
+		Income:          money.Cents(5200050),
+		IsInCouple:      true,
+		Children:        2,
+		PreviousIncomes: [2]money.Money{money.Euros(48000), money.Euros(50000)},
+		Credits:         user.Credits{Donations: money.Euros(300), ChildcareExpenses: money.Euros(2000), YoungChildren: 1},
+		Declarants: []user.Declarant{
+			{Incomes: user.Incomes{Salaries: money.Cents(4000050), Property: money.Euros(2000)}, IsDisabled: true},
+			{Incomes: user.Incomes{Pensions: money.Euros(10000)}},
+		},
+		Dependents: []user.Dependent{{BirthYear: 2020}, {IsAlternating: true, IsDisabled: true}},
+		Cases:      user.Cases{Invalids: 1},
+		Tax:        money.Euros(1765),
+	}
+}
+
+// Save a profile then load it gives the same household without the results
+func TestSaveLoad(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "family", "dupont.json")
+	if err := Save(path, New("dupont", newTestUser(), 2023)); err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := Load(path)
+	t.Logf("Function result:\t%+v", profile)
+	if err != nil || profile.SchemaVersion != SCHEMA_VERSION || profile.Name != "dupont" || profile.Year != 2023 {
+		t.Fatalf("Expected the profile dupont of 2023, got %+v %v", profile, err)
+	}
+
+	var loaded user.User
+	if err := profile.Apply(&loaded); err != nil {
+		t.Fatal(err)
+	}
+	var expected = newTestUser()
+	expected.Tax = money.ZERO
+	if !reflect.DeepEqual(loaded, expected) {
+		t.Errorf("Expected that the user %s should be equal to %s", colors.Red(loaded), colors.Red(expected))
+	}
+}
+
+// A single parent living with a partner is still not an isolated parent after a save and a load
+func TestSaveLoadCohabiting(t *testing.T) {
+	scales, err := config.LoadScales("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg = &config.Config{TaxList: scales}
+	if cfg.Tax, err = cfg.FindTax(2024); err != nil {
+		t.Fatal(err)
+	}
+	var household = user.Household{
+		Declarants: []user.Declarant{{Incomes: user.Incomes{Salaries: money.Euros(40000)}}},
+		Dependents: make([]user.Dependent, 1),
+	}
+
+	var path = filepath.Join(t.TempDir(), "cohabiting.json")
+	if err := Save(path, New("cohabiting", household.GetUser(2024), 2024)); err != nil {
+		t.Fatal(err)
+	}
+	profile, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded user.User
+	if err := profile.Apply(&loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []user.User{household.GetUser(2024), loaded} {
+		var result = tax.CalculateTax(u, cfg)
+		if result.Shares != 1.5 || result.Tax != money.Euros(3527) {
+			t.Errorf("Expected 1.5 shares and a tax of 3527 €, got %s shares and %s", colors.Red(result.Shares), colors.Red(result.Tax))
+		}
+	}
+}
+
+// A profile of an unknown version or not in JSON is not valid, the unknown fields are ignored
+func TestUnmarshal(t *testing.T) {
+	var tests = []struct {
+		data  string
+		valid bool
+	}{
+		{`{"schema_version": 1, "name": "a", "income": 30000, "pets": 2}`, true},
+		{`{"schema_version": 2, "name": "a", "income": 30000, "declarants": [{"salaries": 30000}]}`, true},
+		{`{"name": "a", "income": 30000}`, false},
+		{`{"schema_version": 99, "name": "a"}`, false},
+		{`{"schema_version": 1, "income": "abc"}`, false},
+		{`not json`, false},
+	}
+
+	for _, test := range tests {
+		profile, err := Unmarshal([]byte(test.data))
+		if test.valid && (err != nil || profile.Income != 30000) {
+			t.Errorf("Expected the profile %s to be valid, got %s", test.data, colors.Red(err))
+		}
+		if !test.valid && !errors.Is(err, ErrInvalidProfile) {
+			t.Errorf("Expected the error %s for %s, got %s", colors.Red(ErrInvalidProfile), test.data, colors.Red(err))
+		}
+	}
+}
+
+// A profile of the version 1 is isolated if it's a single declarant neither widowed nor living with a partner
+func TestMigrateIsolated(t *testing.T) {
+	var tests = []struct {
+		data         string
+		isIsolated   bool
+		isCohabiting bool
+	}{
+		{`{"schema_version": 1, "income": 30000, "children": 1}`, true, false},
+		{`{"schema_version": 1, "income": 30000, "children": 1, "cohabiting": true}`, false, true},
+		{`{"schema_version": 1, "income": 30000, "children": 1, "widowed": true}`, false, false},
+		{`{"schema_version": 1, "income": 30000, "couple": true, "children": 1}`, false, false},
+	}
+
+	for _, test := range tests {
+		profile, err := Unmarshal([]byte(test.data))
+		if err != nil || profile.SchemaVersion != SCHEMA_VERSION || profile.IsIsolated != test.isIsolated {
+			t.Errorf("Expected the profile %s isolated %t, got %+v %v", test.data, test.isIsolated, profile, err)
+			continue
+		}
+		var u user.User
+		if err := profile.Apply(&u); err != nil || u.IsCohabiting != test.isCohabiting || u.Income != money.Euros(30000) {
+			t.Errorf("Expected the user of %s cohabiting %t, got %+v %v", test.data, test.isCohabiting, u, err)
+		}
+	}
+}
+
+// A profile with a negative amount, too many children or young children or details of the wrong number
+// of declarants or of children is not applied
+func TestApplyInvalid(t *testing.T) {
+	var tests = []Profile{
+		{Income: -1},
+		{PreviousIncomes: [2]float64{0, -5}},
+		{Children: -1},
+		{Children: user.MAX_CHILDREN + 1},
+		{Children: 1, Credits: Credits{YoungChildren: 2}},
+		{Children: 2, Dependents: []Dependent{{}}},
+		{Declarants: []Declarant{{}, {}}},
+		{IsInCouple: true, Declarants: []Declarant{{}}},
+		{IsInCouple: true, Declarants: []Declarant{{Salaries: -1}, {}}},
+		{Invalids: 3},
+	}
+
+	for _, profile := range tests {
+		var u = newTestUser()
+		if err := profile.Apply(&u); !errors.Is(err, ErrInvalidProfile) || !reflect.DeepEqual(u, newTestUser()) {
+			t.Errorf("Expected the error %s for %+v, got %s", colors.Red(ErrInvalidProfile), profile, colors.Red(err))
+		}
+	}
+}
+
+// The name of a profile can't be a path
+func TestGetPath(t *testing.T) {
+	if path, err := GetPath("profiles", " dupont "); err != nil || path != filepath.Join("profiles", "dupont.json") {
+		t.Errorf("Expected the path of dupont, got %s %v", colors.Red(path), err)
+	}
+	for _, name := range []string{"", "..", "../dupont", `a\b`} {
+		if _, err := GetPath("profiles", name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Expected the error %s for '%s', got %s", colors.Red(ErrInvalidName), name, colors.Red(err))
+		}
+	}
+}
+
+// Save a profile from the console then load it into a new user with its year
+func TestStartSaveLoadProfile(t *testing.T) {
+	var dir = t.TempDir()
+	scales, err := config.LoadScales("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg = &config.Config{Tax: scales[1], TaxList: scales}
+	var saved = newTestUser()
+
+	StartSaveProfile(utils.NewPrompter(strings.NewReader("../x\ndupont\n"), &bytes.Buffer{}), dir, cfg, &saved)
+	if names, _ := List(dir); len(names) != 1 || names[0] != "dupont" {
+		t.Fatalf("Expected the profile dupont saved, got %s", colors.Red(names))
+	}
+
+	var loaded user.User
+	var out bytes.Buffer
+	cfg.Tax = scales[0]
+	StartLoadProfile(utils.NewPrompter(strings.NewReader("durand\ndupont\n"), &out), dir, cfg, &loaded)
+	t.Logf("Function result:\t%s", out.String())
+
+	if loaded.Income != saved.Income || cfg.GetTax().Year != scales[1].Year {
+		t.Errorf("Expected the income %s in %d, got %s in %d", colors.Red(saved.Income), scales[1].Year, colors.Red(loaded.Income), cfg.GetTax().Year)
+	}
+	if !strings.Contains(out.String(), "'durand' doesn't exist") {
+		t.Errorf("Expected the unknown profile asked again, got %s", colors.Red(out.String()))
+	}
+}
@@ -0,0 +1,12 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package resources embeds the files of the resources folder needed without the folder (ex: command line)
+package resources
+
+import "embed"
+
+// Languages is the folder languages with the texts of each language (ex: languages/en.yaml)
+//
+//go:embed languages/*.yaml
+var Languages embed.FS
@@ -1,3 +1,8 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package settings reads and writes the settings chosen in the GUI (theme, language, currency and recent profiles)
+// without depending on the GUI toolkit so the console and the subcommands use them too
 package settings
 
 import (
@@ -6,15 +11,17 @@ import (
 	"path/filepath"
 
 	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/i18n"
 	"go.uber.org/zap"
 )
 
 // Settings data store in settings file
 type Settings struct {
 	logger   *zap.Logger
-	Theme    int    `json:"theme"`
-	Language string `json:"language"`
-	Currency string `json:"currency"`
+	Theme    int      `json:"theme"`
+	Language string   `json:"language"`
+	Currency string   `json:"currency"`
+	Recent   []string `json:"recent"` // Paths of the profiles opened recently from the most recent
 }
 
 // Load gui settings from settings file
@@ -37,13 +44,32 @@ func Load(logger *zap.Logger) (Settings, error) {
 	return settings, settingsFile.Close()
 }
 
-// createDefaultSettings create settings file with default value
-func createDefaultSettings() Settings {
-	var settingsDefault = Settings{
+// Get returns the settings of the settings file without creating it
+// the default settings are returned if the file can't be read
+func Get() Settings {
+	data, err := os.ReadFile(config.SETTINGS_PATH)
+	if err != nil {
+		return getDefaultSettings()
+	}
+	var settings = getDefaultSettings()
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return getDefaultSettings()
+	}
+	return settings
+}
+
+// getDefaultSettings returns the settings with default value
+func getDefaultSettings() Settings {
+	return Settings{
 		Theme:    GetDefaultTheme(),
-		Language: GetDefaultLanguage(),
-		Currency: GetDefaultCurrency(),
+		Language: i18n.GetDefaultLanguage(),
+		Currency: i18n.GetDefaultCurrency(),
 	}
+}
+
+// createDefaultSettings create settings file with default value
+func createDefaultSettings() Settings {
+	var settingsDefault = getDefaultSettings()
 	file, _ := json.MarshalIndent(settingsDefault, "", " ")
 	_ = os.WriteFile(config.SETTINGS_PATH, file, 0644)
 	return settingsDefault
@@ -58,10 +84,24 @@ func (s *Settings) Set(key string, value interface{}) {
 		s.Language = value.(string)
 	case "currency":
 		s.Currency = value.(string)
+	case "recent":
+		s.Recent = value.([]string)
 	}
 	s.save()
 }
 
+// AddRecent put the path of a profile at the top of the recent profiles and write file with settings data
+// keeps only the config.RECENT_PROFILES most recent paths
+func (s *Settings) AddRecent(path string) {
+	var recent = []string{path}
+	for _, p := range s.Recent {
+		if p != path && len(recent) < config.RECENT_PROFILES {
+			recent = append(recent, p)
+		}
+	}
+	s.Set("recent", recent)
+}
+
 // Save write file with settings data
 func (s *Settings) save() {
 	settingsPath, err := filepath.Abs(config.SETTINGS_PATH)
@@ -0,0 +1,58 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package settings reads and writes the settings chosen in the GUI (theme, language, currency and recent profiles)
+// without depending on the GUI toolkit so the console and the subcommands use them too
+package settings
+
+import (
+	"os"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/i18n"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd settings
+// $ go test -v
+
+// chdirTemp change the working directory to a temporary directory for the settings file during the test
+func chdirTemp(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+// Get the language and the currency saved from the GUI, the missing settings keep their default value
+func TestGet(t *testing.T) {
+	chdirTemp(t)
+	if err := os.WriteFile(config.SETTINGS_PATH, []byte(`{"language": "fr", "recent": ["dupont.json"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var settings = Get()
+	t.Logf("Function result:\t%+v", settings)
+	if settings.Language != i18n.FRENCH || settings.Currency != i18n.GetDefaultCurrency() || len(settings.Recent) != 1 {
+		t.Errorf("Expected the language %s and the default currency, got %+v", colors.Red(i18n.FRENCH), settings)
+	}
+}
+
+// Without settings file the default settings are returned and the file is not created
+func TestGetDefault(t *testing.T) {
+	chdirTemp(t)
+
+	var settings = Get()
+	if settings.Language != i18n.GetDefaultLanguage() || settings.Currency != i18n.GetDefaultCurrency() {
+		t.Errorf("Expected the default settings, got %+v", settings)
+	}
+	if _, err := os.Stat(config.SETTINGS_PATH); !os.IsNotExist(err) {
+		t.Errorf("Expected no settings file created, got %v", colors.Red(err))
+	}
+}
@@ -0,0 +1,16 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package settings reads and writes the settings chosen in the GUI (theme, language, currency and recent profiles)
+// without depending on the GUI toolkit so the console and the subcommands use them too
+package settings
+
+const (
+	DARK  int = 0
+	LIGHT int = 1
+)
+
+// GetTheme Get value of last theme selected
+func GetDefaultTheme() int {
+	return DARK
+}
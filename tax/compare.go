@@ -0,0 +1,259 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package tax is the algorithm to calculate taxes
+package tax
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Number of scenarios of a comparison
+const (
+	COMPARE_MIN_SCENARIOS int = 2 // Minimum number of scenarios to compare
+	COMPARE_MAX_SCENARIOS int = 4 // Maximum number of scenarios to compare
+)
+
+// ErrInvalidComparison is returned when the scenarios can't be compared
+var ErrInvalidComparison = errors.New("invalid comparison")
+
+// Scenario is a household of a user to calculate with the scale of a year
+type Scenario struct {
+	Name string    // Name of the scenario shown in the comparison (ex: "PACS")
+	User user.User // Household of the scenario, only its inputs are used
+	Year int       // Year of the tax scale, 0 for the year selected in the configuration
+}
+
+// Comparison is the result of each scenario with its differences from the first scenario
+type Comparison struct {
+	Scenarios []Scenario // Scenarios compared, the first is the reference
+	Results   []Result   // Result of each scenario
+	Diffs     []Diff     // Differences of each result from the result of the first scenario
+}
+
+// Diff is the differences of a result from the result of reference, positive if the result is greater
+type Diff struct {
+	Tax       money.Money   // Tax with the contribution on high incomes
+	Shares    float64       // Shares of the family quotient
+	Remainder money.Money   // Remainder after tax
+	Tranches  []money.Money // Tax in each tranche, a tranche missing in a scale counts for 0
+}
+
+// CompareScenarios calculate the tax of each scenario with the scale of its year
+// then the differences of each result from the result of the first scenario
+// The users of the scenarios are not changed
+// returns ErrInvalidComparison if there are not between COMPARE_MIN_SCENARIOS and COMPARE_MAX_SCENARIOS scenarios
+// or an error wrapping config.ErrUnknownYear if the year of a scenario is not on the list
+func CompareScenarios(scenarios []Scenario, cfg *config.Config) (Comparison, error) {
+	if len(scenarios) < COMPARE_MIN_SCENARIOS || len(scenarios) > COMPARE_MAX_SCENARIOS {
+		return Comparison{}, fmt.Errorf("%w: %d scenarios, expected between %d and %d", ErrInvalidComparison, len(scenarios), COMPARE_MIN_SCENARIOS, COMPARE_MAX_SCENARIOS)
+	}
+
+	var comparison = Comparison{
+		Scenarios: scenarios,
+		Results:   make([]Result, 0, len(scenarios)),
+		Diffs:     make([]Diff, 0, len(scenarios)),
+	}
+	for _, scenario := range scenarios {
+		var yearConfig = *cfg
+		if scenario.Year != 0 {
+			scale, err := cfg.FindTax(scenario.Year)
+			if err != nil {
+				return Comparison{}, fmt.Errorf("scenario %s: %w", scenario.Name, err)
+			}
+			yearConfig.Tax = scale
+		}
+		comparison.Results = append(comparison.Results, CalculateTax(scenario.User, &yearConfig))
+	}
+
+	var tranches = comparison.CountTranches()
+	var reference = comparison.Results[0]
+	for _, result := range comparison.Results {
+		var diff = Diff{
+			Tax:       getTotalTax(result) - getTotalTax(reference),
+			Shares:    result.Shares - reference.Shares,
+			Remainder: result.Remainder - reference.Remainder,
+			Tranches:  make([]money.Money, tranches),
+		}
+		for index := range diff.Tranches {
+			diff.Tranches[index] = getTrancheTax(result, index) - getTrancheTax(reference, index)
+		}
+		comparison.Diffs = append(comparison.Diffs, diff)
+	}
+	return comparison, nil
+}
+
+// CountTranches returns the greatest number of tranches among the results
+func (comparison Comparison) CountTranches() int {
+	var count int
+	for _, result := range comparison.Results {
+		if len(result.TaxTranches) > count {
+			count = len(result.TaxTranches)
+		}
+	}
+	return count
+}
+
+// StartCompare compare the tax of scenarios seized by user
+// the first scenario is the reference of the differences shown
+func StartCompare(prompter *utils.Prompter, cfg *config.Config, user *user.User) {
+	prompter.Printf("The scenarios are based on %s unless another year is entered\n", colors.Teal(cfg.GetTax().Year))
+
+	// Ask number of scenarios
+	var count int
+	err := prompter.Ask(fmt.Sprintf("How many scenarios do you want to compare (%d-%d) ? ", COMPARE_MIN_SCENARIOS, COMPARE_MAX_SCENARIOS), func(input string) error {
+		number, err := strconv.Atoi(input)
+		if err != nil || number < COMPARE_MIN_SCENARIOS || number > COMPARE_MAX_SCENARIOS {
+			return fmt.Errorf("'%s' is not a number between %d and %d", input, COMPARE_MIN_SCENARIOS, COMPARE_MAX_SCENARIOS)
+		}
+		count = number
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error: asking number of scenarios, details: %v", err)
+		return
+	}
+
+	var scenarios = make([]Scenario, 0, count)
+	for index := 1; index <= count; index++ {
+		prompter.Println(colors.Yellow(fmt.Sprintf("Scenario %d", index)))
+		var scenario = Scenario{Name: fmt.Sprintf("Scenario %d", index), User: *user}
+		if err := askScenario(prompter, cfg, &scenario); err != nil {
+			log.Printf("Error: asking scenario %d, details: %v", index, err)
+			return
+		}
+		scenarios = append(scenarios, scenario)
+	}
+
+	comparison, err := CompareScenarios(scenarios, cfg)
+	if err != nil {
+		prompter.Printf("%s %v\n", colors.Red("Comparison failed:"), err)
+		return
+	}
+	ShowComparison(prompter.Writer(), comparison)
+}
+
+// askScenario ask the year, the income, the status and the children of the scenario
+func askScenario(prompter *utils.Prompter, cfg *config.Config, scenario *Scenario) error {
+	err := prompter.Ask(fmt.Sprintf("1. Year of the scale (empty for %d) ? ", cfg.GetTax().Year), func(input string) error {
+		if input == "" {
+			scenario.Year = 0
+			return nil
+		}
+		year, err := strconv.Atoi(input)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a year", input)
+		}
+		if _, err := cfg.FindTax(year); err != nil {
+			return fmt.Errorf("%d is not on the list", year)
+		}
+		scenario.Year = year
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := scenario.User.AskIncome(prompter, "2. Enter the income ? "); err != nil {
+		return err
+	}
+	if err := scenario.User.AskIsInCouple(prompter, "3. In couple (Y/n) ? "); err != nil {
+		return err
+	}
+	return scenario.User.AskHasChildren(prompter, "4. How many children ? ")
+}
+
+// ShowComparison show a table with a column for each scenario and a line for each amount compared
+// the differences from the first scenario are shown next to the amounts of the other scenarios
+func ShowComparison(w io.Writer, comparison Comparison) {
+	table := tablewriter.NewWriter(w)
+	table.SetBorder(true)
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+	var header = []string{""}
+	for _, scenario := range comparison.Scenarios {
+		header = append(header, scenario.Name)
+	}
+	table.SetHeader(header)
+
+	var lines = [][]string{{"Year"}, {"Income"}, {"Status"}, {"Children"}, {"Shares"}, {"Tax"}, {"Remainder"}}
+	var tranches = comparison.CountTranches()
+	for index := 0; index < tranches; index++ {
+		lines = append(lines, []string{fmt.Sprintf("Tranche %d", index+1)})
+	}
+
+	for i, result := range comparison.Results {
+		var scenario, diff = comparison.Scenarios[i], comparison.Diffs[i]
+		var status = "Single"
+		if scenario.User.IsInCouple {
+			status = "Couple"
+		}
+		lines[0] = append(lines[0], strconv.Itoa(result.Year))
+		lines[1] = append(lines[1], fmt.Sprintf("%s €", result.Income))
+		lines[2] = append(lines[2], status)
+		lines[3] = append(lines[3], strconv.Itoa(scenario.User.Children))
+		lines[4] = append(lines[4], formatDiff(fmt.Sprintf("%g", result.Shares), fmt.Sprintf("%+g", diff.Shares), i, diff.Shares))
+		lines[5] = append(lines[5], formatDiff(fmt.Sprintf("%s €", getTotalTax(result)), formatMoneyDiff(diff.Tax), i, -diff.Tax.Euros()))
+		lines[6] = append(lines[6], formatDiff(fmt.Sprintf("%s €", result.Remainder), formatMoneyDiff(diff.Remainder), i, diff.Remainder.Euros()))
+		for index := 0; index < tranches; index++ {
+			var tax = fmt.Sprintf("%s €", getTrancheTax(result, index))
+			lines[7+index] = append(lines[7+index], formatDiff(tax, formatMoneyDiff(diff.Tranches[index]), i, -diff.Tranches[index].Euros()))
+		}
+	}
+	table.AppendBulk(lines)
+
+	fmt.Fprintln(w, colors.Yellow("\t\t\t Scenarios Comparison \t\t\t"))
+	table.Render()
+	fmt.Fprintf(w, "The differences from %s are in %s when they are better and in %s when they are worse\n",
+		colors.Teal(comparison.Scenarios[0].Name), colors.Green("green"), colors.Red("red"))
+}
+
+// formatDiff returns the value followed by its difference from the reference
+// in green if the gain for the household is positive or in red if it's negative
+// the reference at index 0 has no difference
+func formatDiff(value string, diff string, index int, gain float64) string {
+	switch {
+	case index == 0:
+		return value
+	case gain == 0:
+		return fmt.Sprintf("%s (=)", value)
+	case gain > 0:
+		return fmt.Sprintf("%s (%s)", value, colors.Green(diff))
+	default:
+		return fmt.Sprintf("%s (%s)", value, colors.Red(diff))
+	}
+}
+
+// formatMoneyDiff returns the difference with its sign (ex: +500 € or -20.50 €)
+func formatMoneyDiff(diff money.Money) string {
+	if diff < 0 {
+		return fmt.Sprintf("%s €", diff)
+	}
+	return fmt.Sprintf("+%s €", diff)
+}
+
+// getTotalTax returns the tax of the result with the contribution on high incomes
+func getTotalTax(result Result) money.Money {
+	return result.Tax + result.HighIncomeTax
+}
+
+// getTrancheTax returns the tax of the result in the tranche at index, 0 if the scale has less tranches
+func getTrancheTax(result Result, index int) money.Money {
+	if index >= len(result.TaxTranches) {
+		return money.ZERO
+	}
+	return result.TaxTranches[index].Tax
+}
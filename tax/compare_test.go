@@ -0,0 +1,109 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package tax is the algorithm to calculate taxes
+package tax
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd tax
+// $ go test -v
+
+// Compare a single person, the same income in couple and the same couple with the scale of 2021
+func TestCompareScenarios(t *testing.T) {
+	var single = user.User{Income: money.Euros(50000)}
+	var couple = user.User{Income: money.Euros(50000), IsInCouple: true}
+	comparison, err := CompareScenarios([]Scenario{
+		{Name: "Single", User: single},
+		{Name: "PACS", User: couple},
+		{Name: "PACS 2021", User: couple, Year: 2021},
+	}, CONFIG)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var reference = CalculateTax(single, CONFIG)
+	var pacs = CalculateTax(couple, CONFIG)
+	if comparison.Results[0].Tax != reference.Tax || comparison.Results[1].Tax != pacs.Tax {
+		t.Errorf("Expected the taxes %s and %s, got %s and %s", colors.Red(reference.Tax), colors.Red(pacs.Tax), colors.Red(comparison.Results[0].Tax), colors.Red(comparison.Results[1].Tax))
+	}
+	if comparison.Results[2].Year != 2021 {
+		t.Errorf("Expected the scale of %s, got %s", colors.Red(2021), colors.Red(comparison.Results[2].Year))
+	}
+
+	var diff = comparison.Diffs[1]
+	if diff.Tax != pacs.Tax-reference.Tax || diff.Remainder != pacs.Remainder-reference.Remainder || diff.Shares != 1 {
+		t.Errorf("Expected the differences of the couple from the single person, got %+v", diff)
+	}
+	if len(diff.Tranches) != 5 || diff.Tranches[2] != pacs.TaxTranches[2].Tax-reference.TaxTranches[2].Tax {
+		t.Errorf("Expected the differences of each tranche, got %s", colors.Red(diff.Tranches))
+	}
+	if comparison.Diffs[0].Tax != 0 || comparison.Diffs[0].Shares != 0 {
+		t.Errorf("Expected no difference for the first scenario, got %+v", comparison.Diffs[0])
+	}
+}
+
+// Less than 2 scenarios, more than 4 scenarios or an unknown year can't be compared
+func TestCompareScenariosInvalid(t *testing.T) {
+	var tests = []struct {
+		scenarios []Scenario
+		err       error
+	}{
+		{make([]Scenario, 1), ErrInvalidComparison},
+		{make([]Scenario, 5), ErrInvalidComparison},
+		{[]Scenario{{}, {Year: 1990}}, config.ErrUnknownYear},
+	}
+
+	for _, test := range tests {
+		if _, err := CompareScenarios(test.scenarios, CONFIG); !errors.Is(err, test.err) {
+			t.Errorf("Expected %s for %d scenarios, got %v", colors.Red(test.err), len(test.scenarios), err)
+		}
+	}
+}
+
+// The report has a row for each scenario with its differences
+func TestNewComparisonReport(t *testing.T) {
+	comparison, _ := CompareScenarios([]Scenario{
+		{Name: "A", User: user.User{Income: money.Euros(30000)}},
+		{Name: "B", User: user.User{Income: money.Euros(30000), Children: 1}},
+	}, CONFIG)
+	var report = NewComparisonReport(comparison)
+
+	var rows = report.Rows()
+	if len(rows) != 2 || len(rows[1]) != len(COMPARISON_HEADER) {
+		t.Fatalf("Expected 2 rows of %s columns, got %s", colors.Red(len(COMPARISON_HEADER)), colors.Red(rows))
+	}
+	if rows[1][0] != "B" || rows[1][4] != "1" || rows[1][5] != "2" || rows[1][9] != "1" {
+		t.Errorf("Expected the scenario B of an isolated parent with a share more, got %s", colors.Red(rows[1]))
+	}
+	if report.Scenarios[1].Diff.Tax != comparison.Diffs[1].Tax.Euros() || len(report.Scenarios[1].Diff.Tranches) != 5 {
+		t.Errorf("Expected the differences in euros, got %+v", report.Scenarios[1].Diff)
+	}
+}
+
+// The console asks again an invalid answer then shows the comparison of the scenarios
+func TestStartCompare(t *testing.T) {
+	var script = strings.Join([]string{"5", "2", "", "40000", "n", "0", "1990", "2021", "40000", "y", "2"}, "\n")
+	var output bytes.Buffer
+	StartCompare(utils.NewPrompter(strings.NewReader(script), &output), CONFIG, &user.User{})
+
+	var text = output.String()
+	if count := strings.Count(text, "Invalid response"); count != 2 {
+		t.Errorf("Expected %s invalid responses, got %s:\n%s", colors.Red(2), colors.Red(count), text)
+	}
+	if !strings.Contains(text, "Scenarios Comparison") || !strings.Contains(text, "2021") || !strings.Contains(text, "Scenario 2") {
+		t.Errorf("Expected the comparison of the scenarios, got:\n%s", text)
+	}
+}
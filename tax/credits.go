@@ -0,0 +1,157 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package tax is the algorithm to calculate taxes
+package tax
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Name of the tax reductions and tax credits
+const (
+	AID_DONATION    string = "Aid donations"
+	DONATION        string = "Donations"
+	HOME_EMPLOYMENT string = "Home employment"
+	CHILDCARE       string = "Childcare"
+)
+
+// Credit represent a tax reduction or a tax credit calculated for the user
+type Credit struct {
+	Name         string      // Name of the reduction or credit
+	Expenses     money.Money // Expenses declared by the user
+	Ceiling      money.Money // Ceiling of the expenses retained
+	Amount       money.Money // Amount of the reduction or credit from the expenses retained rounded to the euro
+	Applied      money.Money // Amount deducted from the tax or refunded for a credit
+	CarryForward money.Money // Expenses over the ceiling which can be carried forward on next years
+	Refundable   bool        // True for a tax credit, false for a tax reduction
+}
+
+// calculateCredits determine the tax reductions and tax credits of the user
+// Reductions are sorted before credits to be applied in the legal order
+// returns the list of reductions and credits
+func calculateCredits(user user.User, credits config.Credits) []Credit {
+	var list = make([]Credit, 0, 4)
+
+	// Aid donations over the ceiling are retained with the other donations
+	var aidDonation = calculateCredit(AID_DONATION, user.Credits.AidDonations, credits.AidDonation.Ceiling, credits.AidDonation)
+	var overAidCeiling = aidDonation.Expenses - aidDonation.Expenses.Min(aidDonation.Ceiling)
+	list = append(list, aidDonation)
+
+	// Donations are capped to a rate of the taxable income
+	incomeRate, _ := money.ParseRate(credits.Donation.IncomeCeiling)
+	var donationCeiling = user.Income.Floor().ApplyRate(incomeRate).Round()
+	var donation = calculateCredit(DONATION, user.Credits.Donations+overAidCeiling, donationCeiling, credits.Donation)
+	list = append(list, donation)
+
+	// Home employment ceiling is increased for each child
+	var homeEmploymentCeiling = credits.HomeEmployment.Ceiling + credits.HomeEmployment.ExtraCeiling.Mul(int64(user.Children), 1)
+	if credits.HomeEmployment.MaxCeiling > 0 {
+		homeEmploymentCeiling = homeEmploymentCeiling.Min(credits.HomeEmployment.MaxCeiling)
+	}
+	list = append(list, calculateCredit(HOME_EMPLOYMENT, user.Credits.HomeEmployment, homeEmploymentCeiling, credits.HomeEmployment))
+
+	// Childcare ceiling is for each child under 6
+	var childcareCeiling = credits.Childcare.Ceiling.Mul(int64(user.Credits.YoungChildren), 1)
+	list = append(list, calculateCredit(CHILDCARE, user.Credits.ChildcareExpenses, childcareCeiling, credits.Childcare))
+
+	return list
+}
+
+// calculateCredit determine the amount of a reduction or a credit from the expenses and the ceiling
+// returns the reduction or credit calculated
+func calculateCredit(name string, expenses money.Money, ceiling money.Money, param config.Credit) Credit {
+	rate, _ := money.ParseRate(param.Rate)
+
+	var retained = expenses.Min(ceiling)
+	var credit = Credit{
+		Name:       name,
+		Expenses:   expenses,
+		Ceiling:    ceiling,
+		Amount:     retained.ApplyRate(rate).Round(),
+		Refundable: param.Refundable,
+	}
+
+	if param.CarryForward > 0 {
+		credit.CarryForward = expenses - retained
+	}
+	return credit
+}
+
+// applyCredits deduct the reductions then the credits from the tax
+// Reductions can't be greater than the tax, credits over the tax are refunded
+// returns the tax after reductions and credits, negative if the user is refunded
+func applyCredits(tax money.Money, credits []Credit) money.Money {
+	// Reductions first
+	for index, credit := range credits {
+		if credit.Refundable {
+			continue
+		}
+		credits[index].Applied = credit.Amount.Min(tax)
+		tax -= credits[index].Applied
+	}
+
+	// Then credits
+	for index, credit := range credits {
+		if !credit.Refundable {
+			continue
+		}
+		credits[index].Applied = credit.Amount
+		tax -= credit.Amount
+	}
+	return tax
+}
+
+// hasCredits check if the user declared expenses giving right to reductions or credits
+func hasCredits(result Result) bool {
+	for _, credit := range result.Credits {
+		if credit.Expenses > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// showCreditsResult show details of the tax reductions and tax credits of the result
+func showCreditsResult(w io.Writer, result Result) {
+	table := tablewriter.NewWriter(w)
+	table.SetBorder(true)
+
+	// Setting header
+	var header = []string{"Credit", "Type", "Expenses", "Ceiling", "Amount", "Applied", "Carry forward"}
+	table.SetHeader(header)
+
+	for _, credit := range result.Credits {
+		var kind = "Reduction"
+		if credit.Refundable {
+			kind = "Credit"
+		}
+		var ceiling = "-"
+		if credit.Ceiling > 0 {
+			ceiling = fmt.Sprintf("%s €", credit.Ceiling)
+		}
+
+		table.Append([]string{
+			credit.Name,
+			kind,
+			fmt.Sprintf("%s €", credit.Expenses),
+			ceiling,
+			fmt.Sprintf("%s €", credit.Amount),
+			fmt.Sprintf("%s €", credit.Applied),
+			fmt.Sprintf("%s €", credit.CarryForward),
+		})
+	}
+
+	table.SetFooter([]string{"", "", "", "", "", "Net tax", fmt.Sprintf("%s €", result.NetTax)})
+
+	fmt.Fprintln(w, colors.Yellow("\t\t\t Tax reductions and credits \t\t\t"))
+	table.Render()
+}
@@ -0,0 +1,149 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package tax is the algorithm to calculate taxes
+package tax
+
+import (
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd tax
+// $ go test -v
+
+// getCredit returns the credit named in the result
+func getCredit(result Result, name string) Credit {
+	for _, credit := range result.Credits {
+		if credit.Name == name {
+			return credit
+		}
+	}
+	return Credit{}
+}
+
+// Calculate tax for a single person with donations deducted from the tax
+// 75% of 500 + 66% of 1000 = 1035 deducted from 2922
+func TestCalculateTaxWithDonations(t *testing.T) {
+	var user = user.User{
+		Income:  money.Euros(30000),
+		Credits: user.Credits{Donations: money.Euros(1000), AidDonations: money.Euros(500)},
+	}
+
+	result := CalculateTax(user, CONFIG)
+	t.Logf("Function result:\t%+v", result)
+
+	expected := Result{Income: money.Euros(30000), Tax: money.Euros(2922), NetTax: money.Euros(1887), Remainder: money.Euros(28113)}
+	t.Logf("Expected:\t\t%+v", expected)
+
+	if result.Tax != expected.Tax || result.NetTax != expected.NetTax || result.Remainder != expected.Remainder {
+		t.Errorf("Expected that the Tax %s should be equal to %s", colors.Red(expected.Tax), colors.Red(result.Tax))
+		t.Errorf("Expected that the NetTax %s should be equal to %s", colors.Red(expected.NetTax), colors.Red(result.NetTax))
+		t.Errorf("Expected that the Remainder %s should be equal to %s", colors.Red(expected.Remainder), colors.Red(result.Remainder))
+	}
+}
+
+// Aid donations over the ceiling of 1000 are retained with the other donations at 66%
+func TestCalculateAidDonationsOverCeiling(t *testing.T) {
+	var user = user.User{
+		Income:  money.Euros(30000),
+		Credits: user.Credits{AidDonations: money.Euros(1500)},
+	}
+
+	result := CalculateTax(user, CONFIG)
+	t.Logf("Function result:\t%+v", result)
+
+	var aidDonation = getCredit(result, AID_DONATION)
+	var donation = getCredit(result, DONATION)
+
+	if aidDonation.Amount != money.Euros(750) {
+		t.Errorf("Expected that the aid donation %s should be equal to %s", colors.Red(750), colors.Red(aidDonation.Amount))
+	}
+	if donation.Expenses != money.Euros(500) || donation.Amount != money.Euros(330) {
+		t.Errorf("Expected that the donation %s should be equal to %s", colors.Red(330), colors.Red(donation.Amount))
+	}
+}
+
+// Donations over 20% of the income are carried forward
+// and the reduction can't be greater than the tax
+func TestCalculateDonationsCarryForward(t *testing.T) {
+	var user = user.User{
+		Income:  money.Euros(10000),
+		Credits: user.Credits{Donations: money.Euros(3000)},
+	}
+
+	result := CalculateTax(user, CONFIG)
+	t.Logf("Function result:\t%+v", result)
+
+	var donation = getCredit(result, DONATION)
+	t.Logf("Donation:\t%+v", donation)
+
+	if donation.Ceiling != money.Euros(2000) || donation.CarryForward != money.Euros(1000) || donation.Amount != money.Euros(1320) {
+		t.Errorf("Expected that the carry forward %s should be equal to %s", colors.Red(1000), colors.Red(donation.CarryForward))
+	}
+	if donation.Applied != money.Euros(0) || result.NetTax != money.Euros(0) {
+		t.Errorf("Expected that the reduction applied %s should be equal to %s", colors.Red(0), colors.Red(donation.Applied))
+	}
+}
+
+// Tax credits greater than the tax are refunded
+func TestCalculateRefundableCredits(t *testing.T) {
+	var user = user.User{
+		Income:  money.Euros(10000),
+		Credits: user.Credits{HomeEmployment: money.Euros(4000)},
+	}
+
+	result := CalculateTax(user, CONFIG)
+	t.Logf("Function result:\t%+v", result)
+
+	expected := Result{Income: money.Euros(10000), Tax: money.Euros(0), NetTax: money.Euros(-2000), Remainder: money.Euros(12000)}
+	t.Logf("Expected:\t\t%+v", expected)
+
+	if result.NetTax != expected.NetTax || result.Remainder != expected.Remainder {
+		t.Errorf("Expected that the NetTax %s should be equal to %s", colors.Red(expected.NetTax), colors.Red(result.NetTax))
+		t.Errorf("Expected that the Remainder %s should be equal to %s", colors.Red(expected.Remainder), colors.Red(result.Remainder))
+	}
+}
+
+// Home employment ceiling is increased by 1500 for each child up to 15000
+func TestCalculateHomeEmploymentCeiling(t *testing.T) {
+	var user = user.User{
+		Income:     money.Euros(100000),
+		IsInCouple: true,
+		Children:   3,
+		Credits:    user.Credits{HomeEmployment: money.Euros(20000)},
+	}
+
+	result := CalculateTax(user, CONFIG)
+	var homeEmployment = getCredit(result, HOME_EMPLOYMENT)
+	t.Logf("Home employment:\t%+v", homeEmployment)
+
+	if homeEmployment.Ceiling != money.Euros(15000) || homeEmployment.Amount != money.Euros(7500) {
+		t.Errorf("Expected that the home employment %s should be equal to %s", colors.Red(7500), colors.Red(homeEmployment.Amount))
+	}
+}
+
+// Childcare ceiling is for each child under 6
+func TestCalculateChildcareCredit(t *testing.T) {
+	var user = user.User{
+		Income:     money.Euros(60000),
+		IsInCouple: true,
+		Children:   2,
+		Credits:    user.Credits{ChildcareExpenses: money.Euros(3000), YoungChildren: 1},
+	}
+
+	result := CalculateTax(user, CONFIG)
+	var childcare = getCredit(result, CHILDCARE)
+	t.Logf("Childcare:\t%+v", childcare)
+
+	if childcare.Ceiling != money.Euros(2300) || childcare.Amount != money.Euros(1150) {
+		t.Errorf("Expected that the childcare %s should be equal to %s", colors.Red(1150), colors.Red(childcare.Amount))
+	}
+	if result.NetTax != result.Tax-money.Euros(1150) {
+		t.Errorf("Expected that the NetTax %s should be equal to %s", colors.Red(result.Tax-money.Euros(1150)), colors.Red(result.NetTax))
+	}
+}
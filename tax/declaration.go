@@ -0,0 +1,215 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package tax is the algorithm to calculate taxes
+package tax
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// ErrInvalidHousehold is returned when the declarations of a household can't be optimized
+var ErrInvalidHousehold = errors.New("invalid household")
+
+// Declaration is a legal way for the two declarants of a household to file their tax declarations
+type Declaration struct {
+	IsJoint    bool                // True for a joint declaration of the two declarants, false for separate declarations
+	Dependents [2][]user.Dependent // Children attached to each declarant, all on the first one for a joint declaration
+	Results    []Result            // Result of the joint declaration or of each separate declaration
+	Tax        money.Money         // Tax of the household with the contribution on high incomes
+	Remainder  money.Money         // Remainder of the household after tax
+}
+
+// Optimization is the legal declarations of a household from the cheapest
+type Optimization struct {
+	Declarations []Declaration // Declarations sorted by tax from the cheapest
+	Savings      money.Money   // Tax saved by the cheapest declaration compared with the most expensive one
+}
+
+// Best returns the cheapest declaration of the household
+func (optimization Optimization) Best() Declaration {
+	return optimization.Declarations[0]
+}
+
+// Name returns the name of the declaration (ex: "Joint" or "Separate (1 + 2 children)")
+func (declaration Declaration) Name() string {
+	if declaration.IsJoint {
+		return "Joint"
+	}
+	return fmt.Sprintf("Separate (%d + %d children)", len(declaration.Dependents[0]), len(declaration.Dependents[1]))
+}
+
+// OptimizeDeclarations calculate the tax of every legal declaration of the household for its event
+// a joint declaration for a union, separate declarations for a union or a separation
+// with every attachment of the children to either declarant, the children of the same custody and disability
+// being interchangeable
+// In the year of a union the declarants filing separately live together so they are not isolated parents
+// returns ErrInvalidHousehold if the household is not valid or has no event
+func OptimizeDeclarations(household user.Household, cfg *config.Config) (Optimization, error) {
+	if err := household.Validate(); err != nil {
+		return Optimization{}, fmt.Errorf("%w: %v", ErrInvalidHousehold, err)
+	}
+	if household.Event == "" {
+		return Optimization{}, fmt.Errorf("%w: no event in the year", ErrInvalidHousehold)
+	}
+
+	var year = cfg.GetTax().Year
+	var declarations []Declaration
+	if household.Event != user.EVENT_SEPARATION {
+		var result = CalculateTax(household.GetUser(year), cfg)
+		declarations = append(declarations, Declaration{
+			IsJoint:    true,
+			Dependents: [2][]user.Dependent{household.Dependents, nil},
+			Results:    []Result{result},
+			Tax:        getTotalTax(result),
+			Remainder:  result.Remainder,
+		})
+	}
+
+	for _, attachment := range getAttachments(household.Dependents) {
+		var declaration = Declaration{Dependents: attachment}
+		for index, declarant := range household.Declarants {
+			var single = user.Household{
+				Declarants: []user.Declarant{declarant},
+				Dependents: attachment[index],
+				IsIsolated: household.Event == user.EVENT_SEPARATION && len(attachment[index]) > 0,
+			}
+			var result = CalculateTax(single.GetUser(year), cfg)
+			declaration.Results = append(declaration.Results, result)
+			declaration.Tax += getTotalTax(result)
+			declaration.Remainder += result.Remainder
+		}
+		declarations = append(declarations, declaration)
+	}
+
+	sort.SliceStable(declarations, func(i, j int) bool { return declarations[i].Tax < declarations[j].Tax })
+	return Optimization{
+		Declarations: declarations,
+		Savings:      declarations[len(declarations)-1].Tax - declarations[0].Tax,
+	}, nil
+}
+
+// getAttachments returns the different ways to attach the dependents to two declarants
+// from all the dependents on the first declarant to all on the second one
+// The dependents of the same custody and disability are interchangeable so only their number on each declarant matters
+func getAttachments(dependents []user.Dependent) [][2][]user.Dependent {
+	// Dependents grouped by custody and disability in their order
+	var groups [][]user.Dependent
+	var indexes = make(map[[2]bool]int)
+	for _, dependent := range dependents {
+		var kind = [2]bool{dependent.IsAlternating, dependent.IsDisabled}
+		index, ok := indexes[kind]
+		if !ok {
+			index = len(groups)
+			indexes[kind] = index
+			groups = append(groups, nil)
+		}
+		groups[index] = append(groups[index], dependent)
+	}
+
+	// Number of dependents of each group on the second declarant, incremented like an odometer
+	var counts = make([]int, len(groups))
+	var attachments [][2][]user.Dependent
+	for {
+		var attachment [2][]user.Dependent
+		for index, group := range groups {
+			var split = len(group) - counts[index]
+			attachment[0] = append(attachment[0], group[:split]...)
+			attachment[1] = append(attachment[1], group[split:]...)
+		}
+		attachments = append(attachments, attachment)
+
+		var index = 0
+		for index < len(groups) && counts[index] == len(groups[index]) {
+			counts[index] = 0
+			index++
+		}
+		if index == len(groups) {
+			return attachments
+		}
+		counts[index]++
+	}
+}
+
+// StartDeclarationOptimizer find the cheapest declarations of a couple seized by user
+// in the year of a marriage, a PACS or a divorce
+func StartDeclarationOptimizer(prompter *utils.Prompter, cfg *config.Config, _ *user.User) {
+	prompter.Printf("The optimizer is based on %s\n", colors.Teal(cfg.GetTax().Year))
+
+	var household = user.Household{Declarants: make([]user.Declarant, 2)}
+	err := household.AskEvent(prompter, fmt.Sprintf("1. Event of the year (%s) ? ", strings.Join(user.EVENTS, "/")))
+	if err != nil {
+		log.Printf("Error: asking event, details: %v", err)
+		return
+	}
+
+	for index := range household.Declarants {
+		prompter.Printf("%d. Enter the incomes of the declarant %d\n", index+2, index+1)
+		if err := household.Declarants[index].AskIncomes(prompter, fmt.Sprintf("declarant %d", index+1)); err != nil {
+			log.Printf("Error: asking incomes of declarant %d, details: %v", index+1, err)
+			return
+		}
+	}
+
+	err = household.AskDependents(prompter, "4. How many children do you have ? ")
+	if err != nil {
+		log.Printf("Error: asking has children, details: %v", err)
+		return
+	}
+
+	optimization, err := OptimizeDeclarations(household, cfg)
+	if err != nil {
+		prompter.Printf("%s %v\n", colors.Red("Optimization failed:"), err)
+		return
+	}
+	ShowOptimization(prompter.Writer(), optimization)
+}
+
+// ShowOptimization show the table of the declarations from the cheapest with their extra cost
+// then the cheapest declaration and its savings
+func ShowOptimization(w io.Writer, optimization Optimization) {
+	table := tablewriter.NewWriter(w)
+	table.SetBorder(true)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Declaration", "Shares", "Tax", "Remainder", "Extra cost"})
+
+	var best = optimization.Best()
+	for i, declaration := range optimization.Declarations {
+		var shares = make([]string, 0, len(declaration.Results))
+		for _, result := range declaration.Results {
+			shares = append(shares, strconv.FormatFloat(result.Shares, 'f', -1, 64))
+		}
+		var line = []string{
+			declaration.Name(),
+			strings.Join(shares, " + "),
+			fmt.Sprintf("%s €", declaration.Tax),
+			fmt.Sprintf("%s €", declaration.Remainder),
+			fmt.Sprintf("%s €", declaration.Tax-best.Tax),
+		}
+		if i == 0 {
+			for j := range line {
+				line[j] = colors.Green(line[j])
+			}
+		}
+		table.Append(line)
+	}
+
+	fmt.Fprintln(w, colors.Yellow("\t\t\t Declarations \t\t\t"))
+	table.Render()
+	fmt.Fprintf(w, "The cheapest is %s with a tax of %s €, saving %s € compared with the most expensive declaration\n",
+		colors.Green(best.Name()), colors.Teal(best.Tax), colors.Teal(optimization.Savings))
+}
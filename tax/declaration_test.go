@@ -0,0 +1,146 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package tax is the algorithm to calculate taxes
+package tax
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd tax
+// $ go test -v
+
+// newHousehold returns a household of two declarants with their salaries in euros and their children
+func newHousehold(event string, first int, second int, children int) user.Household {
+	return user.Household{
+		Event: event,
+		Declarants: []user.Declarant{
+			{Incomes: user.Incomes{Salaries: money.Euros(first)}},
+			{Incomes: user.Incomes{Salaries: money.Euros(second)}},
+		},
+		Dependents: make([]user.Dependent, children),
+	}
+}
+
+// In the year of a union the joint declaration and the separate declarations with each attachment of the children are legal
+func TestOptimizeDeclarationsUnion(t *testing.T) {
+	optimization, err := OptimizeDeclarations(newHousehold(user.EVENT_UNION, 50000, 20000, 2), CONFIG)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(optimization.Declarations) != 4 {
+		t.Fatalf("Expected %s declarations, got %s", colors.Red(4), colors.Red(len(optimization.Declarations)))
+	}
+
+	var joint = CalculateTax(user.User{Income: money.Euros(70000), IsInCouple: true, Children: 2}, CONFIG)
+	var best = optimization.Best()
+	if !best.IsJoint || best.Tax != joint.Tax {
+		t.Errorf("Expected the joint declaration with a tax of %s, got %s %s", colors.Red(joint.Tax), best.Name(), colors.Red(best.Tax))
+	}
+
+	var worst = optimization.Declarations[len(optimization.Declarations)-1]
+	if optimization.Savings != worst.Tax-best.Tax || optimization.Savings <= 0 {
+		t.Errorf("Expected the savings from the most expensive declaration, got %s", colors.Red(optimization.Savings))
+	}
+	for _, declaration := range optimization.Declarations {
+		if !declaration.IsJoint && (len(declaration.Results) != 2 || len(declaration.Dependents[0])+len(declaration.Dependents[1]) != 2) {
+			t.Errorf("Expected a result for each declarant with the 2 children attached, got %+v", declaration)
+		}
+		if !declaration.IsJoint && len(declaration.Dependents[0]) == 2 && declaration.Results[0].Shares != 2 {
+			t.Errorf("Expected no isolated parent half share while living together, got %s", colors.Red(declaration.Results[0].Shares))
+		}
+	}
+}
+
+// After a separation only separate declarations are legal and a parent alone is isolated
+func TestOptimizeDeclarationsSeparation(t *testing.T) {
+	optimization, err := OptimizeDeclarations(newHousehold(user.EVENT_SEPARATION, 30000, 30000, 1), CONFIG)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(optimization.Declarations) != 2 {
+		t.Fatalf("Expected %s declarations, got %s", colors.Red(2), colors.Red(len(optimization.Declarations)))
+	}
+	for _, declaration := range optimization.Declarations {
+		var parent = 0
+		if len(declaration.Dependents[1]) == 1 {
+			parent = 1
+		}
+		if declaration.IsJoint || declaration.Results[parent].Shares != 2 {
+			t.Errorf("Expected separate declarations with an isolated parent of 2 shares, got %+v", declaration)
+		}
+	}
+	if optimization.Savings != 0 {
+		t.Errorf("Expected no savings for the same incomes, got %s", colors.Red(optimization.Savings))
+	}
+}
+
+// The year of a death has no choice of declarations, an invalid household is rejected
+func TestOptimizeDeclarationsInvalid(t *testing.T) {
+	_, err := OptimizeDeclarations(newHousehold(user.EVENT_DEATH, 30000, 10000, 0), CONFIG)
+	if !errors.Is(err, ErrInvalidHousehold) || !strings.Contains(err.Error(), user.ErrDeathEvent.Error()) {
+		t.Errorf("Expected %s for a death, got %v", colors.Red(user.ErrDeathEvent), err)
+	}
+
+	var invalids = []user.Household{
+		newHousehold("wedding", 30000, 10000, 0),
+		newHousehold(user.EVENT_UNION, -1, 10000, 0),
+		newHousehold("", 30000, 10000, 0),
+		{Event: user.EVENT_UNION, Declarants: []user.Declarant{{}}},
+	}
+	for _, household := range invalids {
+		if _, err := OptimizeDeclarations(household, CONFIG); !errors.Is(err, ErrInvalidHousehold) {
+			t.Errorf("Expected %s for %+v, got %v", colors.Red(ErrInvalidHousehold), household, err)
+		}
+	}
+}
+
+// The children of the same custody and disability are interchangeable when they are attached to the declarants
+func TestOptimizeDeclarationsDependents(t *testing.T) {
+	var household = newHousehold(user.EVENT_UNION, 50000, 20000, 0)
+	household.Dependents = []user.Dependent{{}, {}, {IsAlternating: true}, {IsDisabled: true}}
+	optimization, err := OptimizeDeclarations(household, CONFIG)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// 1 joint declaration and 3 * 2 * 2 attachments of the separate declarations
+	if len(optimization.Declarations) != 13 {
+		t.Fatalf("Expected %s declarations, got %s", colors.Red(13), colors.Red(len(optimization.Declarations)))
+	}
+	for _, declaration := range optimization.Declarations {
+		if len(declaration.Dependents[0])+len(declaration.Dependents[1]) != 4 {
+			t.Errorf("Expected the 4 children attached, got %s", declaration.Name())
+		}
+	}
+}
+
+// The console asks again an invalid event then shows the declarations from the cheapest
+func TestStartDeclarationOptimizer(t *testing.T) {
+	var script = strings.Join([]string{
+		"wedding", "death", "union",
+		"40000", "", "", "", "n", "n",
+		"abc", "15000", "", "", "", "n", "n",
+		"1", "2015", "n", "n",
+	}, "\n")
+	var output bytes.Buffer
+	StartDeclarationOptimizer(utils.NewPrompter(strings.NewReader(script), &output), CONFIG, &user.User{})
+
+	var text = output.String()
+	if count := strings.Count(text, "Invalid response"); count != 3 {
+		t.Errorf("Expected %s invalid responses, got %s:\n%s", colors.Red(3), colors.Red(count), text)
+	}
+	if !strings.Contains(text, "Separate (1 + 0 children)") || !strings.Contains(text, "The cheapest is") {
+		t.Errorf("Expected the declarations and the cheapest one, got:\n%s", text)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package tax is the algorithm to calculate taxes
+package tax
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// StartHouseholdCalculator calculate the tax of a household seized by user
+// with the incomes of each declarant by category, the details of each child and the particular cases
+// The household replaces the user to be saved into a profile
+func StartHouseholdCalculator(prompter *utils.Prompter, cfg *config.Config, u *user.User) {
+	prompter.Printf("The calculator is based on %s\n", colors.Teal(cfg.GetTax().Year))
+
+	var household = user.Household{Declarants: make([]user.Declarant, 1)}
+	isInCouple, err := prompter.AskYesNo("1. Do you declare in couple (Y/n) ? ")
+	if err != nil {
+		log.Printf("Error: asking is in couple, details: %v", err)
+		return
+	}
+	if isInCouple {
+		household.Declarants = make([]user.Declarant, 2)
+	}
+
+	for index := range household.Declarants {
+		prompter.Printf("2. Enter the incomes of the declarant %d\n", index+1)
+		if err := household.Declarants[index].AskIncomes(prompter, fmt.Sprintf("declarant %d", index+1)); err != nil {
+			log.Printf("Error: asking incomes of declarant %d, details: %v", index+1, err)
+			return
+		}
+	}
+
+	err = household.AskDependents(prompter, "3. How many children do you have ? ")
+	if err != nil {
+		log.Printf("Error: asking has children, details: %v", err)
+		return
+	}
+
+	if err := household.AskCases(prompter); err != nil {
+		log.Printf("Error: asking particular cases, details: %v", err)
+		return
+	}
+
+	if err := household.Validate(); err != nil {
+		prompter.Printf("%s %v\n", colors.Red("Calculation failed:"), err)
+		return
+	}
+
+	*u = household.GetUser(cfg.GetTax().Year)
+	result := CalculateTax(*u, cfg)
+	applyResult(u, result)
+	u.Show(prompter.Writer())
+	showTaxTrancheResult(prompter.Writer(), result, cfg.GetTax().Year)
+}
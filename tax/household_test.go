@@ -0,0 +1,84 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package tax is the algorithm to calculate taxes
+package tax
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd tax
+// $ go test -v
+
+// The household of a couple sums the incomes of the declarants and counts the young children and the invalids
+func TestHouseholdGetUser(t *testing.T) {
+	var household = user.Household{
+		Declarants: []user.Declarant{
+			{Incomes: user.Incomes{Salaries: money.Euros(30000), Property: money.Euros(5000)}},
+			{Incomes: user.Incomes{Pensions: money.Euros(15000)}, IsVeteran: true},
+		},
+		Dependents: []user.Dependent{{BirthYear: 2010}, {BirthYear: 2016, IsAlternating: true}},
+	}
+	var u = household.GetUser(CONFIG.GetTax().Year)
+	t.Logf("Function result:\t%+v", u)
+
+	if u.Income != money.Euros(50000) || !u.IsInCouple || u.Children != 2 || u.IsCohabiting {
+		t.Errorf("Expected a couple with 50000 € and 2 children, got %+v", u)
+	}
+	if u.Credits.YoungChildren != 1 || u.Cases.Invalids != 1 {
+		t.Errorf("Expected %s young child and %s invalid, got %s and %s", colors.Red(1), colors.Red(1), colors.Red(u.Credits.YoungChildren), colors.Red(u.Cases.Invalids))
+	}
+	// 2 shares of the couple, 0.5 and 0.25 for the children, 0.5 for the veteran
+	if shares := CalculateTax(u, CONFIG).Shares; shares != 3.25 {
+		t.Errorf("Expected %s shares, got %s", colors.Red(3.25), colors.Red(shares))
+	}
+}
+
+// A single declarant is an isolated parent only when living alone with the children
+func TestHouseholdGetUserSingle(t *testing.T) {
+	var tests = []struct {
+		household user.Household
+		shares    float64
+	}{
+		{user.Household{Declarants: make([]user.Declarant, 1), Dependents: make([]user.Dependent, 1)}, 1.5},
+		{user.Household{Declarants: make([]user.Declarant, 1), Dependents: make([]user.Dependent, 1), IsIsolated: true}, 2},
+		{user.Household{Declarants: make([]user.Declarant, 1), Dependents: make([]user.Dependent, 1), IsWidowed: true}, 2.5},
+	}
+
+	for _, test := range tests {
+		if shares := getShares(test.household.GetUser(CONFIG.GetTax().Year)); shares != test.shares {
+			t.Errorf("Expected %s shares for %+v, got %s", colors.Red(test.shares), test.household, colors.Red(shares))
+		}
+	}
+}
+
+// The console asks again an invalid income and an invalid number of children then shows the tax of the household
+func TestStartHouseholdCalculator(t *testing.T) {
+	var script = strings.Join([]string{
+		"n",
+		"abc", "40000", "", "", "", "y", "n",
+		"1000000000", "-1", "2", "2018", "y", "n", "", "n", "y",
+		"n", "y",
+	}, "\n")
+	var output bytes.Buffer
+	var u user.User
+	StartHouseholdCalculator(utils.NewPrompter(strings.NewReader(script), &output), CONFIG, &u)
+
+	var text = output.String()
+	if count := strings.Count(text, "Invalid response"); count != 3 {
+		t.Errorf("Expected %s invalid responses, got %s:\n%s", colors.Red(3), colors.Red(count), text)
+	}
+	// 1 share, 0.5 for the disability card, 0.5 for the case T, 1 for the disabled child, 0.25 for the alternating custody
+	if u.Shares != 3.25 || u.Credits.YoungChildren != 1 || !strings.Contains(text, "Tax Results") {
+		t.Errorf("Expected the tax of an isolated parent with 3.25 shares, got %+v:\n%s", u, text)
+	}
+}
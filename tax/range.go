@@ -0,0 +1,241 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package tax is the algorithm to calculate taxes
+package tax
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Size of the chart of a range in characters
+const (
+	RANGE_CHART_WIDTH  int = 60 // Maximum number of columns of the chart
+	RANGE_CHART_HEIGHT int = 15 // Number of lines of the chart
+)
+
+// ErrInvalidRange is returned when the incomes of a range can't be simulated
+var ErrInvalidRange = errors.New("invalid income range")
+
+// SimulateRange calculate the tax of the household of the user for each income from min to max by step
+// max is always the last income simulated even if it's not reached by a step
+// The user is not changed, the income of each result is the income simulated
+// returns ErrInvalidRange if min is negative or greater than max, if step is not positive
+// or if there are more than config.RANGE_MAX_STEPS incomes to simulate
+func SimulateRange(user user.User, cfg *config.Config, min money.Money, max money.Money, step money.Money) ([]Result, error) {
+	if min < 0 || max < min {
+		return nil, fmt.Errorf("%w: the minimum %s should be between 0 and the maximum %s", ErrInvalidRange, min, max)
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("%w: the step %s should be positive", ErrInvalidRange, step)
+	}
+	var count = (max-min)/step + 1
+	if (max-min)%step != 0 {
+		count++
+	}
+	if count > money.Money(config.RANGE_MAX_STEPS) {
+		return nil, fmt.Errorf("%w: %d incomes to simulate, the maximum is %d", ErrInvalidRange, count, config.RANGE_MAX_STEPS)
+	}
+
+	var results = make([]Result, 0, count)
+	for income := min; income < max; income += step {
+		user.Income = income
+		results = append(results, CalculateTax(user, cfg))
+	}
+	user.Income = max
+	return append(results, CalculateTax(user, cfg)), nil
+}
+
+// StartSimulateRange calculate the taxes of the household of the user for a range of incomes seized by user
+// then show a table of the results and a chart of the tax if the user wants
+func StartSimulateRange(prompter *utils.Prompter, cfg *config.Config, user *user.User) {
+	prompter.Printf("The simulation is based on %s\n", colors.Teal(cfg.GetTax().Year))
+
+	// Ask range of incomes
+	var min, max, step money.Money
+	var questions = []struct {
+		question string
+		amount   *money.Money
+	}{
+		{"1. Enter the minimum income ? ", &min},
+		{"2. Enter the maximum income ? ", &max},
+		{"3. Enter the step between two incomes ? ", &step},
+	}
+	for _, question := range questions {
+		if err := askAmount(prompter, question.question, question.amount); err != nil {
+			log.Printf("Error: asking range, details: %v", err)
+			return
+		}
+	}
+
+	// Ask if user is in couple
+	err := user.AskIsInCouple(prompter, "4. Are you in couple (Y/n) ? ")
+	if err != nil {
+		log.Printf("Error: asking is in couple for user, details: %v", err)
+		return
+	}
+
+	// Ask if user hasChildren
+	err = user.AskHasChildren(prompter, "5. How many children do you have ? ")
+	if err != nil {
+		log.Printf("Error: asking has children, details: %v", err)
+		return
+	}
+
+	results, err := SimulateRange(*user, cfg, min, max, step)
+	if err != nil {
+		prompter.Printf("%s %v\n", colors.Red("Simulation failed:"), err)
+		return
+	}
+	ShowRange(prompter.Writer(), results)
+
+	// Ask user if he wants to see the chart
+	ok, err := prompter.AskYesNo("Do you want to see the chart of the tax (Y/n) ? ")
+	if err != nil {
+		log.Printf("Error: asking chart, details: %v", err)
+		return
+	}
+	if ok {
+		ShowRangeChart(prompter.Writer(), results, RANGE_CHART_WIDTH, RANGE_CHART_HEIGHT)
+	}
+}
+
+// ShowRange show the table of the tax, the net income and the rates of each income of the range
+// the incomes where the marginal rate changes are highlighted
+func ShowRange(w io.Writer, results []Result) {
+	table := tablewriter.NewWriter(w)
+	table.SetBorder(true)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Income", "Tax", "Net", "Marginal rate", "Average rate"})
+
+	for i, result := range results {
+		var line = []string{
+			fmt.Sprintf("%s €", result.Income),
+			fmt.Sprintf("%s €", result.Tax+result.HighIncomeTax),
+			fmt.Sprintf("%s €", result.Remainder),
+			fmt.Sprintf("%g %%", result.MarginalRate),
+			fmt.Sprintf("%.1f %%", result.AverageRate),
+		}
+		if isThreshold(results, i) {
+			for j := range line {
+				line[j] = colors.Yellow(line[j])
+			}
+		}
+		table.Append(line)
+	}
+
+	fmt.Fprintln(w, colors.Yellow("\t\t\t Range Simulation \t\t\t"))
+	table.Render()
+	fmt.Fprintf(w, "The incomes in %s reach a new marginal rate\n", colors.Yellow("yellow"))
+}
+
+// ShowRangeChart draw the tax of the range by income in a chart of height lines and at most width columns
+// a column is an income, the columns of the incomes reaching a new marginal rate are highlighted
+func ShowRangeChart(w io.Writer, results []Result, width int, height int) {
+	if len(results) == 0 || width < 1 || height < 2 {
+		return
+	}
+
+	// An income for each column, sampled if there are more incomes than columns
+	var columns = make([]int, 0, width)
+	if len(results) <= width {
+		for i := range results {
+			columns = append(columns, i)
+		}
+	} else {
+		for column := 0; column < width; column++ {
+			columns = append(columns, column*(len(results)-1)/(width-1))
+		}
+	}
+
+	// Line of the tax of each column from 0 at the bottom
+	var maxTax money.Money
+	for _, result := range results {
+		maxTax = maxTax.Max(result.Tax + result.HighIncomeTax)
+	}
+	var levels = make([]int, len(columns))
+	for i, index := range columns {
+		var tax = results[index].Tax + results[index].HighIncomeTax
+		if maxTax > 0 {
+			levels[i] = int(math.Round(tax.Euros() / maxTax.Euros() * float64(height-1)))
+		}
+	}
+
+	var labels = []string{fmt.Sprintf("%s €", maxTax), "0 €"}
+	var padding = utf8.RuneCountInString(labels[0])
+
+	fmt.Fprintln(w, colors.Yellow("\t\t\t Tax by income \t\t\t"))
+	for line := height - 1; line >= 0; line-- {
+		var label string
+		switch line {
+		case height - 1:
+			label = labels[0]
+		case 0:
+			label = labels[1]
+		}
+		fmt.Fprintf(w, "%*s ┤", padding, label)
+
+		for i, level := range levels {
+			// the points are joined to the previous column by a vertical line
+			var previous = level
+			if i > 0 {
+				previous = levels[i-1]
+			}
+			var char = " "
+			switch {
+			case line == level:
+				char = "•"
+			case (line > level && line < previous) || (line < level && line > previous):
+				char = "│"
+			}
+			var threshold = i > 0 && results[columns[i]].MarginalRate != results[columns[i-1]].MarginalRate
+			if char != " " && threshold {
+				char = colors.Yellow(char)
+			} else if char != " " {
+				char = colors.Green(char)
+			}
+			fmt.Fprint(w, char)
+		}
+		fmt.Fprintln(w)
+	}
+
+	// Axis of the incomes
+	var first, last = fmt.Sprintf("%s €", results[0].Income), fmt.Sprintf("%s €", results[len(results)-1].Income)
+	fmt.Fprintf(w, "%*s └%s\n", padding, "", strings.Repeat("─", len(columns)))
+	var space = len(columns) - utf8.RuneCountInString(first) - utf8.RuneCountInString(last)
+	if space < 1 {
+		space = 1
+	}
+	fmt.Fprintf(w, "%*s  %s%s%s\n", padding, "", first, strings.Repeat(" ", space), last)
+}
+
+// isThreshold returns true if the result at index has a marginal rate different from the previous result
+func isThreshold(results []Result, index int) bool {
+	return index > 0 && results[index].MarginalRate != results[index-1].MarginalRate
+}
+
+// askAmount ask a positive amount in euros until the answer is valid
+func askAmount(prompter *utils.Prompter, question string, amount *money.Money) error {
+	return prompter.Ask(question, func(input string) error {
+		value, err := money.Parse(input)
+		if err != nil || value < 0 {
+			return fmt.Errorf("'%s' is not a positive amount", input)
+		}
+		*amount = value
+		return nil
+	})
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package tax is the algorithm to calculate taxes
+package tax
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd tax
+// $ go test -v
+
+// Simulate the incomes of a range with the maximum as last income
+func TestSimulateRange(t *testing.T) {
+	var household = user.User{Income: money.Euros(1), IsInCouple: true}
+	results, err := SimulateRange(household, CONFIG, money.Euros(10000), money.Euros(45000), money.Euros(10000))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var incomes = []money.Money{money.Euros(10000), money.Euros(20000), money.Euros(30000), money.Euros(40000), money.Euros(45000)}
+	if len(results) != len(incomes) {
+		t.Fatalf("Expected %s results, got %s", colors.Red(len(incomes)), colors.Red(len(results)))
+	}
+	for i, income := range incomes {
+		var expected = CalculateTax(user.User{Income: income, IsInCouple: true}, CONFIG)
+		if results[i].Income != income || results[i].Tax != expected.Tax {
+			t.Errorf("Expected the tax %s for %s, got %s", colors.Red(expected.Tax), income, colors.Red(results[i].Tax))
+		}
+	}
+	if household.Income != money.Euros(1) {
+		t.Errorf("Expected the user not changed, got the income %s", colors.Red(household.Income))
+	}
+}
+
+// A range without income to simulate or with too many incomes is not valid
+func TestSimulateRangeInvalid(t *testing.T) {
+	var tests = []struct {
+		min, max, step money.Money
+	}{
+		{money.Euros(-1), money.Euros(10), money.Euros(1)},
+		{money.Euros(20), money.Euros(10), money.Euros(1)},
+		{money.Euros(0), money.Euros(10), money.ZERO},
+		{money.Euros(0), money.Euros(100000), money.Euros(1)},
+	}
+
+	for _, test := range tests {
+		if _, err := SimulateRange(user.User{}, CONFIG, test.min, test.max, test.step); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("Expected %s for %+v, got %v", colors.Red(ErrInvalidRange), test, err)
+		}
+	}
+}
+
+// The chart has a line for each level and a column for each income
+func TestShowRangeChart(t *testing.T) {
+	results, _ := SimulateRange(user.User{}, CONFIG, money.ZERO, money.Euros(100000), money.Euros(1000))
+	var output bytes.Buffer
+	ShowRangeChart(&output, results, 40, 10)
+
+	var lines = strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	if len(lines) != 13 {
+		t.Fatalf("Expected %s lines (title, 10 levels and the axis), got %s:\n%s", colors.Red(13), colors.Red(len(lines)), output.String())
+	}
+	if !strings.Contains(lines[1], "•") || !strings.HasPrefix(strings.TrimSpace(lines[10]), "0 €") {
+		t.Errorf("Expected the highest tax on the first level and 0 on the last level, got:\n%s", output.String())
+	}
+	if !strings.Contains(lines[11], strings.Repeat("─", 40)) || !strings.Contains(lines[12], "100000 €") {
+		t.Errorf("Expected an axis of 40 columns up to the maximum income, got:\n%s", output.String())
+	}
+}
+
+// The console asks again an invalid amount then shows the table and the chart
+func TestStartSimulateRange(t *testing.T) {
+	var script = strings.Join([]string{"0", "abc", "50000", "10000", "n", "0", "y"}, "\n")
+	var output bytes.Buffer
+	StartSimulateRange(utils.NewPrompter(strings.NewReader(script), &output), CONFIG, &user.User{})
+
+	var text = output.String()
+	if strings.Count(text, "Invalid response") != 1 {
+		t.Errorf("Expected an invalid response, got:\n%s", text)
+	}
+	if !strings.Contains(text, "Range Simulation") || !strings.Contains(text, "Tax by income") || !strings.Contains(text, "50000 €") {
+		t.Errorf("Expected the table and the chart, got:\n%s", text)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package tax is the algorithm to calculate taxes
+package tax
+
+import (
+	"strconv"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
+)
+
+// Report is the result of a tax calculation in a format readable by other programs (JSON, YAML, CSV)
+// The amounts are in euros with cents as decimals
+type Report struct {
+	Year           int             `json:"year" yaml:"year"`
+	Income         float64         `json:"income" yaml:"income"`
+	Shares         float64         `json:"shares" yaml:"shares"`
+	UncappedTax    float64         `json:"uncapped_tax" yaml:"uncapped_tax"`
+	IsCapped       bool            `json:"is_capped" yaml:"is_capped"`
+	Decote         float64         `json:"decote" yaml:"decote"`
+	Tax            float64         `json:"tax" yaml:"tax"`
+	HighIncomeTax  float64         `json:"high_income_tax" yaml:"high_income_tax"`
+	NetTax         float64         `json:"net_tax" yaml:"net_tax"`
+	Remainder      float64         `json:"remainder" yaml:"remainder"`
+	MarginalRate   float64         `json:"marginal_rate" yaml:"marginal_rate"`
+	AverageRate    float64         `json:"average_rate" yaml:"average_rate"`
+	NextIncomeCost float64         `json:"next_income_cost" yaml:"next_income_cost"`
+	Tranches       []TrancheReport `json:"tranches" yaml:"tranches"`
+}
+
+// TrancheReport is a tranche of a scale with the tax of the household in this tranche
+type TrancheReport struct {
+	Min  float64  `json:"min" yaml:"min"`
+	Max  *float64 `json:"max" yaml:"max"` // nil for the last tranche without limit
+	Rate string   `json:"rate" yaml:"rate"`
+	Tax  *float64 `json:"tax,omitempty" yaml:"tax,omitempty"` // nil when only the scale is reported
+}
+
+// ScaleReport is the scale of a year in a format readable by other programs
+type ScaleReport struct {
+	Year     int             `json:"year" yaml:"year"`
+	Source   string          `json:"source" yaml:"source"`
+	Tranches []TrancheReport `json:"tranches" yaml:"tranches"`
+}
+
+// REPORT_HEADER is the list of columns of a report in a table or in a CSV file
+var REPORT_HEADER = []string{
+	"year", "income", "shares", "uncapped_tax", "is_capped", "decote", "tax",
+	"high_income_tax", "net_tax", "remainder", "marginal_rate", "average_rate", "next_income_cost",
+}
+
+// NewReport convert the result of a tax calculation into a report
+func NewReport(result Result) Report {
+	var tranches = make([]TrancheReport, 0, len(result.TaxTranches))
+	for _, taxTranche := range result.TaxTranches {
+		var tranche = newTrancheReport(taxTranche.Tranche)
+		var tax = taxTranche.Tax.Euros()
+		tranche.Tax = &tax
+		tranches = append(tranches, tranche)
+	}
+
+	return Report{
+		Year:           result.Year,
+		Income:         result.Income.Euros(),
+		Shares:         result.Shares,
+		UncappedTax:    result.UncappedTax.Euros(),
+		IsCapped:       result.IsCapped,
+		Decote:         result.Decote.Euros(),
+		Tax:            result.Tax.Euros(),
+		HighIncomeTax:  result.HighIncomeTax.Euros(),
+		NetTax:         result.NetTax.Euros(),
+		Remainder:      result.Remainder.Euros(),
+		MarginalRate:   result.MarginalRate,
+		AverageRate:    result.AverageRate,
+		NextIncomeCost: result.NextIncomeCost.Euros(),
+		Tranches:       tranches,
+	}
+}
+
+// NewScaleReport convert the scale of a year into a report
+func NewScaleReport(tax config.Tax) ScaleReport {
+	var tranches = make([]TrancheReport, 0, len(tax.Tranches))
+	for _, tranche := range tax.Tranches {
+		tranches = append(tranches, newTrancheReport(tranche))
+	}
+	return ScaleReport{Year: tax.Year, Source: tax.Source, Tranches: tranches}
+}
+
+// newTrancheReport convert a tranche into a report without tax
+func newTrancheReport(tranche config.Tranche) TrancheReport {
+	var report = TrancheReport{Min: tranche.Min.Euros(), Rate: tranche.Rate}
+	if tranche.Max != money.MAX {
+		var max = tranche.Max.Euros()
+		report.Max = &max
+	}
+	return report
+}
+
+// Row returns the values of the report in the order of REPORT_HEADER
+func (report Report) Row() []string {
+	return []string{
+		strconv.Itoa(report.Year),
+		formatFloat(report.Income),
+		formatFloat(report.Shares),
+		formatFloat(report.UncappedTax),
+		strconv.FormatBool(report.IsCapped),
+		formatFloat(report.Decote),
+		formatFloat(report.Tax),
+		formatFloat(report.HighIncomeTax),
+		formatFloat(report.NetTax),
+		formatFloat(report.Remainder),
+		formatFloat(report.MarginalRate),
+		formatFloat(report.AverageRate),
+		formatFloat(report.NextIncomeCost),
+	}
+}
+
+// formatFloat convert a number into a string without useless decimals (ex: 2.5 or 3225.75)
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// ComparisonReport is the comparison of scenarios in a format readable by other programs
+type ComparisonReport struct {
+	Scenarios []ScenarioReport `json:"scenarios" yaml:"scenarios"`
+}
+
+// ScenarioReport is a scenario of a comparison with its result and its differences from the first scenario
+type ScenarioReport struct {
+	Name       string     `json:"name" yaml:"name"`
+	IsInCouple bool       `json:"couple" yaml:"couple"`
+	Children   int        `json:"children" yaml:"children"`
+	Result     Report     `json:"result" yaml:"result"`
+	Diff       DiffReport `json:"diff" yaml:"diff"`
+}
+
+// DiffReport is the differences of a scenario from the first scenario, the amounts are in euros
+type DiffReport struct {
+	Tax       float64   `json:"tax" yaml:"tax"`
+	Shares    float64   `json:"shares" yaml:"shares"`
+	Remainder float64   `json:"remainder" yaml:"remainder"`
+	Tranches  []float64 `json:"tranches" yaml:"tranches"`
+}
+
+// COMPARISON_HEADER is the list of columns of a comparison in a table or in a CSV file
+var COMPARISON_HEADER = []string{
+	"name", "year", "income", "couple", "children", "shares", "tax", "remainder",
+	"diff_tax", "diff_shares", "diff_remainder",
+}
+
+// NewComparisonReport convert a comparison of scenarios into a report
+func NewComparisonReport(comparison Comparison) ComparisonReport {
+	var scenarios = make([]ScenarioReport, 0, len(comparison.Scenarios))
+	for i, scenario := range comparison.Scenarios {
+		var diff = comparison.Diffs[i]
+		var tranches = make([]float64, 0, len(diff.Tranches))
+		for _, tranche := range diff.Tranches {
+			tranches = append(tranches, tranche.Euros())
+		}
+		scenarios = append(scenarios, ScenarioReport{
+			Name:       scenario.Name,
+			IsInCouple: scenario.User.IsInCouple,
+			Children:   scenario.User.Children,
+			Result:     NewReport(comparison.Results[i]),
+			Diff: DiffReport{
+				Tax:       diff.Tax.Euros(),
+				Shares:    diff.Shares,
+				Remainder: diff.Remainder.Euros(),
+				Tranches:  tranches,
+			},
+		})
+	}
+	return ComparisonReport{Scenarios: scenarios}
+}
+
+// Rows returns the values of each scenario of the report in the order of COMPARISON_HEADER
+// the tax is the tax with the contribution on high incomes
+func (report ComparisonReport) Rows() [][]string {
+	var rows = make([][]string, 0, len(report.Scenarios))
+	for _, scenario := range report.Scenarios {
+		rows = append(rows, []string{
+			scenario.Name,
+			strconv.Itoa(scenario.Result.Year),
+			formatFloat(scenario.Result.Income),
+			strconv.FormatBool(scenario.IsInCouple),
+			strconv.Itoa(scenario.Children),
+			formatFloat(scenario.Result.Shares),
+			formatFloat(scenario.Result.Tax + scenario.Result.HighIncomeTax),
+			formatFloat(scenario.Result.Remainder),
+			formatFloat(scenario.Diff.Tax),
+			formatFloat(scenario.Diff.Shares),
+			formatFloat(scenario.Diff.Remainder),
+		})
+	}
+	return rows
+}
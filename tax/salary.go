@@ -0,0 +1,193 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package tax is the algorithm to calculate taxes
+package tax
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// SalaryBreakdown represent each step of the conversion of a gross salary into a taxable income
+type SalaryBreakdown struct {
+	Gross               money.Money // Gross annual salary (salaire brut)
+	SocialContributions money.Money // Employee social contributions without CSG and CRDS
+	DeductibleCSG       money.Money // Deductible CSG
+	NonDeductibleCSG    money.Money // Non-deductible CSG
+	CRDS                money.Money // CRDS
+	NetSalary           money.Money // Net salary paid before tax (net à payer avant impôt)
+	NetTaxableSalary    money.Money // Net salary with non-deductible CSG and CRDS (net imposable)
+	Allowance           money.Money // Allowance for professional expenses or actual expenses deducted
+	IsActualExpenses    bool        // True if the actual expenses replaced the allowance
+	TaxableIncome       money.Money // Taxable income rounded to the euro (revenu net imposable)
+}
+
+// StartGrossSalaryCalculator calculate taxes from the gross salary seized by user
+func StartGrossSalaryCalculator(prompter *utils.Prompter, cfg *config.Config, user *user.User) {
+	prompter.Printf("The calculator is based on %s\n", colors.Teal(cfg.GetTax().Year))
+	status := true
+
+	// Ask gross salary's user
+	err := user.AskGrossSalary(prompter, "1. Enter your gross annual salary\n    (en) Gross salary\n    (fr) Salaire brut\n> ")
+	if err != nil {
+		log.Printf("Error: asking gross salary for user, details: %v", err)
+		status = false
+		return
+	}
+
+	// Ask if user is an executive
+	err = user.AskIsExecutive(prompter, "2. Are you an executive (cadre) (Y/n) ? ")
+	if err != nil {
+		log.Printf("Error: asking is executive for user, details: %v", err)
+		status = false
+		return
+	}
+
+	// Ask actual expenses
+	err = user.AskActualExpenses(prompter, "3. Enter your actual professional expenses (frais réels), empty to keep the allowance ? ")
+	if err != nil {
+		log.Printf("Error: asking actual expenses for user, details: %v", err)
+		status = false
+		return
+	}
+
+	// Ask if user is in couple
+	err = user.AskIsInCouple(prompter, "4. Are you in couple (Y/n) ? ")
+	if err != nil {
+		log.Printf("Error: asking is in couple for user, details: %v", err)
+		status = false
+		return
+	}
+
+	// Ask if user hasChildren
+	err = user.AskHasChildren(prompter, "5. How many children do you have ? ")
+	if err != nil {
+		log.Printf("Error: asking has children, details: %v", err)
+		status = false
+		return
+	}
+
+	// Convert gross salary then calculate tax
+	breakdown := ConvertGrossSalary(user.Salary, cfg.GetTax().Salary)
+	user.Income = breakdown.TaxableIncome
+	showSalaryBreakdown(prompter.Writer(), breakdown)
+
+	result := CalculateTax(*user, cfg)
+	applyResult(user, result)
+
+	// Show user
+	user.Show(prompter.Writer())
+
+	// Ask user if he wants to see tax tranches
+	ok, err := user.AskTaxDetails(prompter)
+	if err != nil {
+		log.Printf("Error: asking tax details, details: %v", err)
+		return
+	}
+	if ok {
+		showTaxTrancheResult(prompter.Writer(), result, cfg.Tax.Year)
+	}
+
+	if status {
+		prompter.Println(colors.Green("Tax process successful"))
+	} else {
+		prompter.Println(colors.Red("Tax process failed"))
+	}
+	prompter.Println("----------------------------------------")
+
+	// ask user to restart program else we exit
+	if user.AskRestart(prompter, "Would you want to enter a new salary (Y/n): ") {
+		prompter.Println("Restarting program...")
+		StartGrossSalaryCalculator(prompter, cfg, user)
+	} else {
+		prompter.Println("Quitting gross_salary_calculator")
+	}
+}
+
+// ConvertGrossSalary convert the gross salary into the taxable income
+// Social contributions and CSG are deducted from the gross salary to get the net salary,
+// the non-deductible CSG and CRDS are added back to get the net taxable salary
+// then the allowance for professional expenses or the actual expenses are deducted
+// returns every step of the conversion
+func ConvertGrossSalary(salary user.Salary, rates config.Salary) SalaryBreakdown {
+	var breakdown = SalaryBreakdown{Gross: salary.Gross}
+
+	var contributionRate = rates.NonExecutiveRate
+	if salary.IsExecutive {
+		contributionRate = rates.ExecutiveRate
+	}
+
+	// Social contributions on the gross salary and CSG, CRDS on a part of it
+	var csgBase = applyRate(breakdown.Gross, rates.CSGBase)
+	breakdown.SocialContributions = applyRate(breakdown.Gross, contributionRate)
+	breakdown.DeductibleCSG = applyRate(csgBase, rates.DeductibleCSG)
+	breakdown.NonDeductibleCSG = applyRate(csgBase, rates.NonDeductibleCSG)
+	breakdown.CRDS = applyRate(csgBase, rates.CRDS)
+
+	breakdown.NetSalary = breakdown.Gross - breakdown.SocialContributions - breakdown.DeductibleCSG - breakdown.NonDeductibleCSG - breakdown.CRDS
+	breakdown.NetTaxableSalary = breakdown.NetSalary + breakdown.NonDeductibleCSG + breakdown.CRDS
+
+	// Allowance for professional expenses between its limits or actual expenses
+	if salary.ActualExpenses > 0 {
+		breakdown.Allowance = salary.ActualExpenses
+		breakdown.IsActualExpenses = true
+	} else {
+		breakdown.Allowance = applyRate(breakdown.NetTaxableSalary, rates.Allowance.Rate)
+		breakdown.Allowance = breakdown.Allowance.Max(rates.Allowance.Min)
+		breakdown.Allowance = breakdown.Allowance.Min(rates.Allowance.Max)
+	}
+	breakdown.Allowance = breakdown.Allowance.Min(breakdown.NetTaxableSalary)
+
+	breakdown.TaxableIncome = (breakdown.NetTaxableSalary - breakdown.Allowance).Round()
+	return breakdown
+}
+
+// applyRate apply the rate string like '10%' on the value
+// returns the value multiplied by the rate rounded to the cent
+func applyRate(value money.Money, rate string) money.Money {
+	r, _ := money.ParseRate(rate)
+	return value.ApplyRate(r)
+}
+
+// showSalaryBreakdown show each step of the conversion of the gross salary into taxable income
+func showSalaryBreakdown(w io.Writer, breakdown SalaryBreakdown) {
+	table := tablewriter.NewWriter(w)
+	table.SetBorder(true)
+	table.SetHeader([]string{"Step", "Amount"})
+
+	var allowance = "Allowance for professional expenses"
+	if breakdown.IsActualExpenses {
+		allowance = "Actual professional expenses"
+	}
+
+	var lines = []struct {
+		label  string
+		amount money.Money
+	}{
+		{"Gross salary", breakdown.Gross},
+		{"Social contributions", -breakdown.SocialContributions},
+		{"Deductible CSG", -breakdown.DeductibleCSG},
+		{"Non-deductible CSG", -breakdown.NonDeductibleCSG},
+		{"CRDS", -breakdown.CRDS},
+		{"Net salary", breakdown.NetSalary},
+		{"Net taxable salary", breakdown.NetTaxableSalary},
+		{allowance, -breakdown.Allowance},
+	}
+	for _, line := range lines {
+		table.Append([]string{line.label, fmt.Sprintf("%.2f €", line.amount.Euros())})
+	}
+	table.SetFooter([]string{"Taxable income", fmt.Sprintf("%s €", breakdown.TaxableIncome)})
+
+	fmt.Fprintln(w, colors.Yellow("\t Salary breakdown \t"))
+	table.Render()
+}
@@ -0,0 +1,90 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package tax is the algorithm to calculate taxes
+package tax
+
+import (
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd tax
+// $ go test -v
+
+// Convert a gross salary of 40000 for a non-executive
+func TestConvertGrossSalary(t *testing.T) {
+	var salary = user.Salary{Gross: money.Euros(40000)}
+
+	breakdown := ConvertGrossSalary(salary, CONFIG.GetTax().Salary)
+	t.Logf("Function result:\t%+v", breakdown)
+
+	expected := SalaryBreakdown{
+		Gross:               money.Euros(40000),
+		SocialContributions: money.Euros(5000),
+		DeductibleCSG:       money.Cents(267240),
+		NonDeductibleCSG:    money.Cents(94320),
+		CRDS:                money.Cents(19650),
+		NetSalary:           money.Cents(3118790),
+		NetTaxableSalary:    money.Cents(3232760),
+		Allowance:           money.Cents(323276),
+		TaxableIncome:       money.Euros(29095),
+	}
+	t.Logf("Expected:\t\t%+v", expected)
+
+	if breakdown != expected {
+		t.Errorf("Expected that the breakdown %+v should be equal to %+v", expected, breakdown)
+	}
+}
+
+// Convert a gross salary for an executive which has more social contributions
+func TestConvertGrossSalaryForExecutive(t *testing.T) {
+	var salary = user.Salary{Gross: money.Euros(40000), IsExecutive: true}
+
+	breakdown := ConvertGrossSalary(salary, CONFIG.GetTax().Salary)
+	t.Logf("Function result:\t%+v", breakdown)
+
+	if breakdown.SocialContributions != money.Euros(6200) {
+		t.Errorf("Expected that the SocialContributions %s should be equal to %s", colors.Red(6200), colors.Red(breakdown.SocialContributions))
+	}
+}
+
+// The allowance can't be greater than its maximum
+func TestConvertGrossSalaryAllowanceMax(t *testing.T) {
+	var salary = user.Salary{Gross: money.Euros(200000)}
+
+	breakdown := ConvertGrossSalary(salary, CONFIG.GetTax().Salary)
+	t.Logf("Function result:\t%+v", breakdown)
+
+	if breakdown.Allowance != money.Euros(12829) {
+		t.Errorf("Expected that the Allowance %s should be equal to %s", colors.Red(12829), colors.Red(breakdown.Allowance))
+	}
+}
+
+// The allowance can't be lower than its minimum
+func TestConvertGrossSalaryAllowanceMin(t *testing.T) {
+	var salary = user.Salary{Gross: money.Euros(3000)}
+
+	breakdown := ConvertGrossSalary(salary, CONFIG.GetTax().Salary)
+	t.Logf("Function result:\t%+v", breakdown)
+
+	if breakdown.Allowance != money.Euros(442) {
+		t.Errorf("Expected that the Allowance %s should be equal to %s", colors.Red(442), colors.Red(breakdown.Allowance))
+	}
+}
+
+// Actual expenses replace the allowance
+func TestConvertGrossSalaryWithActualExpenses(t *testing.T) {
+	var salary = user.Salary{Gross: money.Euros(40000), ActualExpenses: money.Euros(5000)}
+
+	breakdown := ConvertGrossSalary(salary, CONFIG.GetTax().Salary)
+	t.Logf("Function result:\t%+v", breakdown)
+
+	if !breakdown.IsActualExpenses || breakdown.Allowance != money.Euros(5000) || breakdown.TaxableIncome != money.Euros(27328) {
+		t.Errorf("Expected that the TaxableIncome %s should be equal to %s", colors.Red(27328), colors.Red(breakdown.TaxableIncome))
+	}
+}
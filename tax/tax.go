@@ -5,13 +5,17 @@
 package tax
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math"
-	"os"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
 	"github.com/LucasNoga/corpos-christie/user"
 	"github.com/LucasNoga/corpos-christie/utils"
 	"github.com/LucasNoga/corpos-christie/utils/colors"
@@ -19,28 +23,47 @@ import (
 	"github.com/olekukonko/tablewriter"
 )
 
+// ErrRemainderTooHigh is returned when no income up to money.MAX_AMOUNT leaves the remainder wished
+var ErrRemainderTooHigh = errors.New("remainder too high")
+
 // Result define the result after calculating tax
 type Result struct {
-	Income      int          // Input income from the user
-	Tax         float64      // Tax to pay from the user
-	Remainder   float64      // Value Remain for the user
+	Year        int          // Year of the tax scale used
+	Income      money.Money  // Input income from the user rounded down to the euro
+	Tax         money.Money  // Tax to pay from the user
+	UncappedTax money.Money  // Tax calculated with all the shares without the family quotient cap
+	CappedTax   money.Money  // Tax after applying the family quotient cap (plafonnement du quotient familial)
+	IsCapped    bool         // True if the benefit of the family quotient has been capped
+	Decote      money.Money  // Discount on tax for low incomes (décote) substracted from the capped tax
+	Remainder   money.Money  // Value Remain for the user
 	TaxTranches []TaxTranche // List of tax by tranches
 	Shares      float64      // family quotient to adjust taxes (parts in french)
+
+	HighIncomeTax         money.Money  // Contribution on high incomes (CEHR) to pay in addition of the tax
+	HighIncomeTaxTranches []TaxTranche // List of contribution on high incomes by tranches
+	IsHighIncomeSmoothed  bool         // True if the contribution on high incomes has been smoothed
+
+	Credits []Credit    // List of tax reductions and tax credits applied on the tax
+	NetTax  money.Money // Tax to pay after reductions and credits, negative if the user is refunded
+
+	MarginalTranche int         // Index in TaxTranches of the tranche of the last euro of income
+	MarginalRate    float64     // Rate in percent of the marginal tranche (taux marginal d'imposition)
+	AverageRate     float64     // Tax and contribution on high incomes paid on the income in percent (taux moyen d'imposition)
+	NextIncomeCost  money.Money // Extra tax to pay for the next config.NEXT_INCOME euros of income
 }
 
 // TaxTranche represent the tax calculating for each tranch when we calculate tax
 type TaxTranche struct {
-	Tax     float64        // Tax in € on a tranche for the user
-	tranche config.Tranche // Param of the tranche calculated (Min, Max, Rate)
+	Tax     money.Money    // Tax in € on a tranche for the household, exact to the cent
+	Tranche config.Tranche // Param of the tranche calculated (Min, Max, Rate)
 }
 
 // StartTaxCalculator calculate taxes from income seized by user
-func StartTaxCalculator(cfg *config.Config, user *user.User) {
-	fmt.Printf("The calculator is based on %s\n", colors.Teal(cfg.GetTax().Year))
+func StartTaxCalculator(prompter *utils.Prompter, cfg *config.Config, user *user.User) {
+	prompter.Printf("The calculator is based on %s\n", colors.Teal(cfg.GetTax().Year))
 	status := true
 	// Ask income's user
-	fmt.Print("1. Enter your income\n    (en) Taxable income\n    (fr) Revenus net imposable\n> ")
-	_, err := user.AskIncome()
+	err := user.AskIncome(prompter, "1. Enter your income\n    (en) Taxable income\n    (fr) Revenus net imposable\n> ")
 	if err != nil {
 		log.Printf("Error: asking income for user, details: %v", err)
 		status = false
@@ -48,8 +71,7 @@ func StartTaxCalculator(cfg *config.Config, user *user.User) {
 	}
 
 	// Ask if user is in couple
-	fmt.Print("2. Are you in couple (Y/n) ? ")
-	_, err = user.AskIsInCouple()
+	err = user.AskIsInCouple(prompter, "2. Are you in couple (Y/n) ? ")
 	if err != nil {
 		log.Printf("Error: asking is in couple for user, details: %v", err)
 		status = false
@@ -57,262 +79,637 @@ func StartTaxCalculator(cfg *config.Config, user *user.User) {
 	}
 
 	// Ask if user hasChildren
-	fmt.Print("3. How many children do you have ? ")
-	_, err = user.AskHasChildren()
+	err = user.AskHasChildren(prompter, "3. How many children do you have ? ")
 	if err != nil {
 		log.Printf("Error: asking has children, details: %v", err)
 		status = false
 		return
 	}
 
+	// Ask expenses for tax reductions and tax credits
+	_, err = user.AskCredits(prompter, "4. Do you have expenses giving right to tax reductions or credits (Y/n) ? ")
+	if err != nil {
+		log.Printf("Error: asking credits, details: %v", err)
+		status = false
+		return
+	}
+
+	// Ask previous incomes if user has to pay the contribution on high incomes
+	if IsHighIncome(*user, cfg.GetTax().HighIncome) {
+		err = user.AskPreviousIncomes(prompter, "5. Enter your reference incomes of the two previous years to smooth the high income contribution (ex: 180000 200000) ? ")
+		if err != nil {
+			log.Printf("Error: asking previous incomes, details: %v", err)
+			status = false
+			return
+		}
+	}
+
 	// Calculate tax
-	result := CalculateTax(user, cfg)
-	user.Shares = result.Shares
+	result := CalculateTax(*user, cfg)
+	applyResult(user, result)
 
 	// Show user
-	user.Show()
+	user.Show(prompter.Writer())
 
 	// Ask user if he wants to see tax tranches
-	if ok, err := user.AskTaxDetails(); ok {
-		if err != nil {
-			log.Printf("Error: asking tax details, details: %v", err)
+	ok, err := user.AskTaxDetails(prompter)
+	if err != nil {
+		log.Printf("Error: asking tax details, details: %v", err)
+		return
+	}
+	if ok {
+		showTaxTrancheResult(prompter.Writer(), result, cfg.Tax.Year)
+		if hasCredits(result) {
+			showCreditsResult(prompter.Writer(), result)
 		}
-		showTaxTrancheResult(result, cfg.Tax.Year)
 	}
 
 	if status {
-		fmt.Println(colors.Green("Tax process successful"))
+		prompter.Println(colors.Green("Tax process successful"))
 	} else {
-		fmt.Println(colors.Red("Tax process failed"))
+		prompter.Println(colors.Red("Tax process failed"))
 	}
-	fmt.Println("----------------------------------------")
+	prompter.Println("----------------------------------------")
 
 	// ask user to restart program else we exit
-	fmt.Print("Would you want to enter a new income (Y/n): ")
-	if user.AskRestart() {
-		fmt.Println("Restarting program...")
-		StartTaxCalculator(cfg, user)
+	if user.AskRestart(prompter, "Would you want to enter a new income (Y/n): ") {
+		prompter.Println("Restarting program...")
+		StartTaxCalculator(prompter, cfg, user)
 	} else {
-		fmt.Println("Quitting tax_calculator")
+		prompter.Println("Quitting tax_calculator")
 	}
 }
 
 // StartReverseTaxCalculator calculate income needed from remainder seized by user
-func StartReverseTaxCalculator(cfg *config.Config, user *user.User) {
-	fmt.Printf("The calculator is based on %s\n", colors.Teal(cfg.GetTax().Year))
+func StartReverseTaxCalculator(prompter *utils.Prompter, cfg *config.Config, user *user.User) {
+	prompter.Printf("The calculator is based on %s\n", colors.Teal(cfg.GetTax().Year))
 	status := true
 
 	// Ask income's user
-	fmt.Print("1. Enter your income wished\n    (en) Income after taxes income\n    (fr) Revenus après impot\n> ")
-	_, err := user.AskRemainder()
+	err := user.AskRemainder(prompter, "1. Enter your income wished\n    (en) Income after taxes income\n    (fr) Revenus après impot\n> ")
 	if err != nil {
 		log.Printf("Error: asking income for user, details: %v", err)
 		status = false
+		return
 	}
 
 	// Ask if user is in couple
-	fmt.Print("2. Are you in couple (Y/n) ? ")
-	_, err = user.AskIsInCouple()
+	err = user.AskIsInCouple(prompter, "2. Are you in couple (Y/n) ? ")
 	if err != nil {
 		log.Printf("Error: asking is in couple for user, details: %v", err)
 		status = false
+		return
 	}
 
 	// Ask if user hasChildren
-	fmt.Print("3. How many children do you have ? ")
-	_, err = user.AskHasChildren()
+	err = user.AskHasChildren(prompter, "3. How many children do you have ? ")
 	if err != nil {
 		log.Printf("Error: asking has children, details: %v", err)
 		status = false
+		return
 	}
 
 	// Calculate tax
-	result := calculateReverseTax(user, cfg)
-	user.Shares = result.Shares
+	result, err := CalculateReverseTax(*user, cfg)
+	if err != nil {
+		prompter.Printf("%s %v\n", colors.Red("Calculation failed:"), err)
+		return
+	}
+	applyResult(user, result)
 
 	// Show user
-	user.Show()
+	user.Show(prompter.Writer())
 
 	// Ask user if he wants to see tax tranches
-	if ok, err := user.AskTaxDetails(); ok {
-		if err != nil {
-			log.Printf("Error: asking tax details, details: %v", err)
-		}
-		showTaxTrancheResult(result, cfg.Tax.Year)
+	ok, err := user.AskTaxDetails(prompter)
+	if err != nil {
+		log.Printf("Error: asking tax details, details: %v", err)
+		return
+	}
+	if ok {
+		showTaxTrancheResult(prompter.Writer(), result, cfg.Tax.Year)
 	}
 
 	if status {
-		fmt.Println(colors.Green("Tax process successful"))
+		prompter.Println(colors.Green("Tax process successful"))
 	} else {
-		fmt.Println(colors.Red("Tax process failed"))
+		prompter.Println(colors.Red("Tax process failed"))
 	}
-	fmt.Println("----------------------------------------")
+	prompter.Println("----------------------------------------")
 
 	// ask user to restart program else we exit
-	fmt.Print("Would you want to enter a new income (Y/n): ")
-	if user.AskRestart() {
-		fmt.Println("Restarting program...")
-		StartReverseTaxCalculator(cfg, user)
+	if user.AskRestart(prompter, "Would you want to enter a new income (Y/n): ") {
+		prompter.Println("Restarting program...")
+		StartReverseTaxCalculator(prompter, cfg, user)
 	} else {
-		fmt.Println("Quitting tax_calculator")
+		prompter.Println("Quitting tax_calculator")
 	}
 }
 
+// CalculateTax determine the tax to pay from the income of the user
+// with the marginal rate, the average rate and the tax on the next config.NEXT_INCOME euros of income
+// The user is not changed, use applyResult to show the result with the user
+// returns the result of the processing
+func CalculateTax(user user.User, cfg *config.Config) Result {
+	var result = calculateTax(user, cfg)
+
+	// Tax on the next euros of income
+	var next = user
+	next.Income = result.Income + money.Euros(config.NEXT_INCOME)
+	var nextResult = calculateTax(next, cfg)
+	result.NextIncomeCost = nextResult.NetTax + nextResult.HighIncomeTax - result.NetTax - result.HighIncomeTax
+
+	return result
+}
+
+// applyResult add the data of the result into the user to show them
+func applyResult(user *user.User, result Result) {
+	user.Income = result.Income
+	user.Tax = result.Tax
+	user.Remainder = result.Remainder
+	user.Shares = result.Shares
+	user.MarginalRate = result.MarginalRate
+	user.AverageRate = result.AverageRate
+	user.NextIncomeCost = result.NextIncomeCost
+}
+
 // calculateTax determine the tax to pay from the income of the user
+// The income is rounded down to the euro, the tax is rounded to the nearest euro (CGI art. 193 and 1657)
+// The benefit of the extra shares of the family quotient is capped (plafonnement du quotient familial)
+// then the discount for low incomes (décote) is applied
+// Tax reductions then tax credits are deducted from the tax
+// The contribution on high incomes (CEHR) is added to the remainder calculation
 // returns the result of the processing
-func CalculateTax(user *user.User, cfg *config.Config) Result {
-	var tax float64
-	var taxable = float64(user.Income)
-	var shares = getShares(*user)
+func calculateTax(user user.User, cfg *config.Config) Result {
+	var income = user.Income.Floor()
+	var shares = getShares(user)
+	var baseShares = getBaseShares(user)
+
+	// Tax with all the shares of the household
+	uncappedTax, taxTranches := calculateTaxWithShares(income, shares, cfg.GetTax().Tranches)
+
+	// Tax with only the shares of the declarants
+	baseTax, _ := calculateTaxWithShares(income, baseShares, cfg.GetTax().Tranches)
+
+	// Cap the benefit given by the extra shares
+	var tax = uncappedTax
+	var maxBenefit = getQuotientCap(user, shares, baseShares, cfg.GetTax().QuotientCap)
+	var isCapped = baseTax-uncappedTax > maxBenefit
+	if isCapped {
+		tax = baseTax - maxBenefit
+	}
+	var cappedTax = tax.Round()
+
+	// The marginal tranche is the one of the shares used to calculate the tax
+	var marginalTranche = getMarginalTranche(income, shares, cfg.GetTax().Tranches)
+	if isCapped {
+		marginalTranche = getMarginalTranche(income, baseShares, cfg.GetTax().Tranches)
+	}
+	marginalRate, _ := utils.ConvertPercentageToFloat64(cfg.GetTax().Tranches[marginalTranche].Rate)
+
+	// Apply the discount for low incomes
+	var decote = calculateDecote(cappedTax, user, cfg.GetTax().Decote)
+
+	// Contribution on high incomes
+	highIncomeTax, highIncomeTaxTranches, isSmoothed := calculateHighIncomeTax(user, cfg.GetTax().HighIncome)
+	highIncomeTax = highIncomeTax.Round()
+
+	// Tax reductions and tax credits
+	var credits = calculateCredits(user, cfg.GetTax().Credits)
+	var netTax = applyCredits(cappedTax-decote, credits)
+
+	result := Result{
+		Year:        cfg.GetTax().Year,
+		Income:      income,
+		Tax:         cappedTax - decote,
+		UncappedTax: uncappedTax.Round(),
+		CappedTax:   cappedTax,
+		IsCapped:    isCapped,
+		Decote:      decote,
+		Remainder:   income - netTax - highIncomeTax,
+		TaxTranches: taxTranches,
+		Shares:      shares,
+
+		HighIncomeTax:         highIncomeTax,
+		HighIncomeTaxTranches: highIncomeTaxTranches,
+		IsHighIncomeSmoothed:  isSmoothed,
 
-	// Divide taxable by shares
-	taxable /= shares
+		Credits: credits,
+		NetTax:  netTax,
+
+		MarginalTranche: marginalTranche,
+		MarginalRate:    marginalRate,
+		AverageRate:     getAverageRate(netTax+highIncomeTax, income),
+	}
+
+	return result
+}
+
+// getMarginalTranche find the tranche of the last euro of income divided by the shares
+// returns the index of the tranche
+func getMarginalTranche(income money.Money, shares float64, tranches []config.Tranche) int {
+	var quarters = getQuarters(shares)
+	var marginal int
+	for index, tranche := range tranches {
+		if income > getTrancheLower(tranche).Mul(quarters, 4) {
+			marginal = index
+		}
+	}
+	return marginal
+}
+
+// getAverageRate calculate the rate of the tax on the income
+// returns the rate in percent rounded to the first decimal
+func getAverageRate(tax money.Money, income money.Money) float64 {
+	if income <= 0 || tax <= 0 {
+		return 0
+	}
+	// Rate in tenths of percent rounded half up
+	var tenths = (int64(tax)*1000 + int64(income)/2) / int64(income)
+	return float64(tenths) / 10
+}
+
+// calculateTaxWithShares determine the tax to pay from the income divided by the shares
+// Instead of dividing the income, the limits of the tranches are multiplied by the shares
+// so the tax is exact to the cent
+// returns the tax for all the shares and the tax of each tranche for all the shares
+func calculateTaxWithShares(income money.Money, shares float64, tranches []config.Tranche) (money.Money, []TaxTranche) {
+	var tax money.Money
+	var quarters = getQuarters(shares)
 
 	// Store each tranche taxes
-	var taxTranches = make([]TaxTranche, 0)
+	var taxTranches = make([]TaxTranche, 0, len(tranches))
 
 	// for each tranche
-	for _, tranche := range cfg.GetTax().Tranches {
-		var taxTranche = calculateTranche(taxable, tranche)
+	for _, tranche := range tranches {
+		var taxTranche = calculateTranche(income, quarters, tranche)
 		taxTranches = append(taxTranches, taxTranche)
 
 		// add into final tax the tax tranche
 		tax += taxTranche.Tax
 	}
 
-	// Reajust tax by shares
-	tax *= shares
+	return tax, taxTranches
+}
 
-	// Format to round in integer tax and remainder
-	result := Result{
-		Income:      user.Income,
-		Tax:         math.Round(tax),
-		Remainder:   float64(user.Income) - math.Round(tax),
-		TaxTranches: taxTranches,
-		Shares:      shares,
+// calculateDecote calculate the discount on tax for low incomes (décote)
+// The discount is the threshold of the declaration minus the rate applied on the tax
+// returns the discount rounded to the euro which can't be greater than the tax
+func calculateDecote(tax money.Money, user user.User, decote config.Decote) money.Money {
+	var threshold = decote.SingleThreshold
+	if user.IsInCouple {
+		threshold = decote.CoupleThreshold
 	}
 
-	// Add data into the user
-	user.Tax = result.Tax
-	user.Remainder = result.Remainder
+	rate, _ := money.ParseRate(decote.Rate)
+	var discount = (threshold - tax.ApplyRate(rate)).Round()
+	if discount <= 0 {
+		return money.ZERO
+	}
+	return discount.Min(tax)
+}
 
-	return result
+// calculateHighIncomeTax calculate the contribution on high incomes (CEHR) from the reference income
+// When the reference income is at least 1.5 times the average of the two previous years
+// and these previous years were under the first threshold, the contribution is smoothed:
+// it's twice the contribution on the half of the reference income plus the half of the average
+// returns the contribution, the contribution of each tranche and if it has been smoothed
+func calculateHighIncomeTax(user user.User, highIncome config.HighIncome) (money.Money, []TaxTranche, bool) {
+	var tranches = highIncome.Single
+	if user.IsInCouple {
+		tranches = highIncome.Couple
+	}
+	if len(tranches) == 0 {
+		return money.ZERO, []TaxTranche{}, false
+	}
+
+	var income = user.GetReferenceIncome().Floor()
+
+	if isHighIncomeSmoothed(user, tranches[0].Max) {
+		var average = (user.PreviousIncomes[0] + user.PreviousIncomes[1]).Mul(1, 2)
+		tax, taxTranches := calculateTaxWithShares(income.Mul(1, 2)+average.Mul(1, 2), 1, tranches)
+		for index := range taxTranches {
+			taxTranches[index].Tax *= 2
+		}
+		return tax * 2, taxTranches, true
+	}
+
+	tax, taxTranches := calculateTaxWithShares(income, 1, tranches)
+	return tax, taxTranches, false
 }
 
-// calculateReverseTax determine the income to have, and tax to pay from the remainder of the user
-// returns the result of the processing
-func calculateReverseTax(user *user.User, cfg *config.Config) Result {
-	var income float64
+// isHighIncomeSmoothed check if the contribution on high incomes of the user can be smoothed
+// The previous incomes have to be known and under the threshold of the contribution
+// returns true if the reference income is at least 1.5 times the average of the previous incomes
+func isHighIncomeSmoothed(user user.User, threshold money.Money) bool {
+	for _, income := range user.PreviousIncomes {
+		if income <= 0 || income > threshold {
+			return false
+		}
+	}
+	var total = user.PreviousIncomes[0] + user.PreviousIncomes[1]
+	var income = user.GetReferenceIncome()
 
-	var taxTranches []TaxTranche
-	var shares = getShares(*user)
+	// income >= 1.5 * total / 2
+	return income > threshold && income.Mul(4, 1) >= total.Mul(3, 1)
+}
 
-	var incomeAfterTaxes = user.Remainder
-	var target = incomeAfterTaxes // income to find
+// IsHighIncome check if the reference income of the user is over the threshold of the contribution on high incomes
+func IsHighIncome(user user.User, highIncome config.HighIncome) bool {
+	var tranches = highIncome.Single
+	if user.IsInCouple {
+		tranches = highIncome.Couple
+	}
+	return len(tranches) > 0 && user.GetReferenceIncome() > tranches[0].Max
+}
 
-	// Divide taxable by shares
-	target /= shares
+// CalculateReverseTax determine the income to have, and tax to pay from the remainder of the user
+// The remainder is a piecewise linear function of the income, linear between the limits of the tranches
+// multiplied by the shares, so the income is found by walking the tranches then solving the linear equation
+// of the tranche reached, the discount (décote) and the family quotient cap only add a few steps
+// The user is not changed, the income found is the income of the result
+// returns the result of the smallest income in euros leaving at least the remainder wished
+// or ErrRemainderTooHigh if an income up to money.MAX_AMOUNT doesn't leave the remainder
+func CalculateReverseTax(user user.User, cfg *config.Config) (Result, error) {
+	var target = user.Remainder
+	lower, upper, err := findReverseTaxTranche(user, cfg, target)
+	if err != nil {
+		return Result{}, err
+	}
+	var income = solveReverseTax(user, cfg, target, lower, upper)
 
-	// Brut force to find target with incomeAfterTaxes
-	for {
+	// Calculate tax with the income found
+	user.Income = income
+	return CalculateTax(user, cfg), nil
+}
 
-		var tax float64
+// findReverseTaxTranche walk the limits of the tranches to find the one where the remainder is reached
+// returns the lower limit where the remainder is not reached and the upper limit where it's reached
+// or ErrRemainderTooHigh if the remainder is not reached with an income of money.MAX_AMOUNT
+func findReverseTaxTranche(user user.User, cfg *config.Config, target money.Money) (money.Money, money.Money, error) {
+	var lower = money.ZERO
+	if getRemainder(user, cfg, lower) >= target {
+		return lower, lower, nil
+	}
+	// The remainder is never over the income
+	if target > money.MAX_AMOUNT {
+		return lower, lower, fmt.Errorf("%w: %s is over %s", ErrRemainderTooHigh, target, money.MAX_AMOUNT)
+	}
 
-		taxTranches = make([]TaxTranche, 0)
-		// for each tranche
-		for _, tranche := range cfg.GetTax().Tranches {
-			var taxTranche = calculateTranche(target, tranche)
-			taxTranches = append(taxTranches, taxTranche)
+	for _, limit := range getReverseTaxLimits(user, cfg) {
+		if limit <= lower || limit > money.MAX_AMOUNT {
+			continue
+		}
+		if getRemainder(user, cfg, limit) >= target {
+			return lower, limit, nil
+		}
+		lower = limit
+	}
+
+	// Over the last limit the remainder grows by at least the half of the income
+	// so the upper limit is extended until the remainder is reached, up to money.MAX_AMOUNT
+	var upper = lower + (target-getRemainder(user, cfg, lower)).Mul(2, 1).Floor() + money.Euros(1)
+	for upper < money.MAX_AMOUNT && getRemainder(user, cfg, upper) < target {
+		lower, upper = upper, upper+(upper-lower)
+	}
+	if upper >= money.MAX_AMOUNT {
+		upper = money.MAX_AMOUNT
+		if getRemainder(user, cfg, upper) < target {
+			return lower, upper, fmt.Errorf("%w: %s needs an income over %s", ErrRemainderTooHigh, target, money.MAX_AMOUNT)
+		}
+	}
+	return lower, upper, nil
+}
+
+// solveReverseTax solve the linear equation of the remainder between the lower and upper limits
+// The income is interpolated on the segment, it's exact in one step when the remainder is linear,
+// a kink of the discount or of the family quotient cap between the limits only adds a few steps
+// returns the smallest income in euros between the limits leaving at least the remainder
+func solveReverseTax(user user.User, cfg *config.Config, target money.Money, lower money.Money, upper money.Money) money.Money {
+	var remainderLower = getRemainder(user, cfg, lower)
+	var remainderUpper = getRemainder(user, cfg, upper)
+	var isBisection bool
+
+	for upper-lower > money.Euros(1) {
+		var width = upper - lower
+
+		// Interpolate the income on the segment rounded up to the euro
+		var income = (lower + width.Mul(int64(target-remainderLower), int64(remainderUpper-remainderLower))).Floor() + money.Euros(1)
+		if isBisection || income <= lower || income >= upper {
+			income = (lower + width.Mul(1, 2)).Floor()
+		}
 
-			// add into final tax the tax tranche
-			tax += taxTranche.Tax
+		// The euro next to the income tells if the interpolation was exact
+		if remainder := getRemainder(user, cfg, income); remainder < target {
+			if getRemainder(user, cfg, income+money.Euros(1)) >= target {
+				return income + money.Euros(1)
+			}
+			lower, remainderLower = income, remainder
+		} else {
+			if getRemainder(user, cfg, income-money.Euros(1)) < target {
+				return income
+			}
+			upper, remainderUpper = income, remainder
 		}
 
-		tax *= shares
+		// Bisect on next step if the interpolation didn't halve the segment
+		isBisection = upper-lower > width.Mul(1, 2)
+	}
+	return upper
+}
 
-		// When target has been reached
-		if incomeAfterTaxes <= target*shares-tax {
-			income = target*shares - shares
-			break
+// getReverseTaxLimits list the incomes where the remainder changes of slope: the limits of the tranches
+// multiplied by the shares with and without the extra shares, and the thresholds of the contribution on high incomes
+// returns the limits sorted
+func getReverseTaxLimits(user user.User, cfg *config.Config) []money.Money {
+	var limits = make([]money.Money, 0, 3*len(cfg.GetTax().Tranches))
+	for _, shares := range []float64{getShares(user), getBaseShares(user)} {
+		var quarters = getQuarters(shares)
+		for _, tranche := range cfg.GetTax().Tranches {
+			if tranche.Max != money.MAX {
+				limits = append(limits, tranche.Max.Mul(quarters, 4).Floor())
+			}
 		}
-		// Increase target to find if we not find
-		target++
 	}
 
-	// Format to round in integer tax and remainder
-	result := Result{
-		Income:      int(income),
-		Tax:         math.Round(income - incomeAfterTaxes),
-		Remainder:   incomeAfterTaxes,
-		TaxTranches: taxTranches,
-		Shares:      shares,
+	var highIncome = cfg.GetTax().HighIncome.Single
+	if user.IsInCouple {
+		highIncome = cfg.GetTax().HighIncome.Couple
+	}
+	for _, tranche := range highIncome {
+		if tranche.Max != money.MAX {
+			limits = append(limits, tranche.Max)
+		}
 	}
 
-	// Add data into the user
-	user.Income = result.Income
-	user.Tax = result.Tax
+	sort.Slice(limits, func(i, j int) bool { return limits[i] < limits[j] })
+	return limits
+}
 
-	return result
+// getRemainder calculate the remainder of the user for the income without changing the user
+// returns the remainder after taxes
+func getRemainder(user user.User, cfg *config.Config, income money.Money) money.Money {
+	user.Income = income
+	return calculateTax(user, cfg).Remainder
 }
 
-// calculateTranche calculate the tax for the tranche base on your taxable income
+// calculateTranche calculate the tax for the tranche base on the income of the household
+// All the limits of the tranche are multiplied by the shares given in quarters
 // returns TaxTranche which amount to pay for the specific tranche
-func calculateTranche(taxable float64, tranche config.Tranche) TaxTranche {
+func calculateTranche(income money.Money, quarters int64, tranche config.Tranche) TaxTranche {
 	var taxTranche = TaxTranche{
-		tranche: tranche,
+		Tranche: tranche,
 	}
 
-	// convert rate string like '10%' into float 10.00
-	rate, _ := utils.ConvertPercentageToFloat64(tranche.Rate)
+	// convert rate string like '10%' into 1000 hundredths of percent
+	rate, _ := money.ParseRate(tranche.Rate)
 
-	// if income is superior to maximum of the tranche to pass to tranch superior
-	// Diff between min and max of the tranche applied tax rate
-	if int(taxable) > tranche.Max {
-		taxTranche.Tax = float64(tranche.Max-tranche.Min) * (rate / 100)
-	} else if int(taxable) > tranche.Min && int(taxable) < tranche.Max {
-		// else if your income taxable is between min and max tranch is the last operation
-		// Diff between min of the tranche and the income of the user applied tax rate
-		taxTranche.Tax = float64(int(taxable)-tranche.Min) * (rate / 100)
+	var lower = getTrancheLower(tranche).Mul(quarters, 4)
+
+	// Part of the income in the tranche
+	var taxable = income - lower
+	if tranche.Max != money.MAX {
+		taxable = taxable.Min(tranche.Max.Mul(quarters, 4) - lower)
+	}
+	if taxable > 0 {
+		taxTranche.Tax = taxable.ApplyRate(rate)
 	}
 	return taxTranche
 }
 
+// getTrancheLower returns the amount where the tranche starts: the maximum of the previous tranche (Min - 1 €)
+func getTrancheLower(tranche config.Tranche) money.Money {
+	if tranche.Min > 0 {
+		return tranche.Min - money.Euros(1)
+	}
+	return tranche.Min
+}
+
 // getShares calculate the family quotient of the user (parts in french)
+// The shares are counted in quarters for the children in alternating custody
 // returns the shares calculated
 func getShares(user user.User) float64 {
-	var shares float64 = 1 // single person only 1 share
+	var quarters int64 = 4 // single person only 1 share
 
-	// if user is in couple we have 1 more shares,
-	if user.IsInCouple {
-		shares += 1
+	// if user is in couple we have 1 more shares, a widowed parent keeps it
+	if user.IsInCouple || (user.Cases.IsWidowed && user.Children > 0) {
+		quarters += 4
 	}
 
 	// if parent is single and have children it's a isolated parent
-	if user.IsIsolated() {
-		shares += 0.5
+	quarters += getIsolatedQuarters(user)
+
+	// For the children, the declarants holding a disability card and the veterans
+	quarters += getChildrenQuarters(user)
+	quarters += int64(user.Cases.Invalids) * 2
+
+	return float64(quarters) / 4
+}
+
+// getIsolatedQuarters calculate the extra quarters of share of an isolated parent (case T)
+// returns 2 quarters with a child in exclusive custody, 1 quarter with only children in alternating custody, otherwise 0
+func getIsolatedQuarters(user user.User) int64 {
+	if !user.IsIsolated() {
+		return 0
+	}
+	// Without their details the children are in exclusive custody
+	if !user.HasDependents() {
+		return 2
 	}
+	for _, dependent := range user.Dependents {
+		if !dependent.IsAlternating {
+			return 2
+		}
+	}
+	return 1
+}
 
-	// For the two first children we add 0.5
-	for i := 1; i <= user.Children && i <= 2; i++ {
-		shares += 0.5
+// getChildrenQuarters calculate the quarters of share of the children of the user from their details
+// or from their number for children in exclusive custody without disability if the details are not set for each child
+// returns the number of quarters, 0 for a negative number of children
+func getChildrenQuarters(user user.User) int64 {
+	if user.HasDependents() {
+		return getDependentsQuarters(user.Dependents)
 	}
+	var children = int64(user.Children)
+	switch {
+	case children <= 0:
+		return 0
+	case children <= 2:
+		return children * 2
+	default:
+		return 4 + (children-2)*4
+	}
+}
 
-	// For the others children we add 1
-	for i := 3; i <= user.Children; i++ {
-		shares += 1
+// getDependentsQuarters calculate the quarters of share of the children
+// The children in exclusive custody are ranked before the children in alternating custody
+// The two first children give a half share, the others a share, and a disability card a half share more
+// A child in alternating custody gives the half of it
+// returns the number of quarters
+func getDependentsQuarters(dependents []user.Dependent) int64 {
+	var ranked = make([]user.Dependent, 0, len(dependents))
+	for _, alternating := range []bool{false, true} {
+		for _, dependent := range dependents {
+			if dependent.IsAlternating == alternating {
+				ranked = append(ranked, dependent)
+			}
+		}
+	}
+
+	var quarters int64
+	for rank, dependent := range ranked {
+		var childQuarters int64 = 2
+		if rank >= 2 {
+			childQuarters = 4
+		}
+		if dependent.IsDisabled {
+			childQuarters += 2
+		}
+		if dependent.IsAlternating {
+			childQuarters /= 2
+		}
+		quarters += childQuarters
 	}
+	return quarters
+}
 
-	// for each child of the user we put 0.5 shares
-	return shares
+// getBaseShares calculate the shares of the declarants without the children
+// returns 2 if the user is in couple otherwise 1
+func getBaseShares(user user.User) float64 {
+	if user.IsInCouple {
+		return 2
+	}
+	return 1
+}
+
+// getQuotientCap calculate the maximum benefit in euros given by the extra shares of the family quotient
+// The two first extra half-shares of an isolated parent have their own ceiling
+// returns the ceiling of the benefit
+func getQuotientCap(user user.User, shares float64, baseShares float64, quotientCap config.QuotientCap) money.Money {
+	var quarters = getQuarters(shares) - getQuarters(baseShares)
+
+	// The quarters of the case T and of the first child, halved in alternating custody
+	if isolated := getIsolatedQuarters(user) * 2; isolated > 0 {
+		return quotientCap.IsolatedParent.Mul(isolated, 4) + quotientCap.HalfShare.Mul(quarters-isolated, 2)
+	}
+	return quotientCap.HalfShare.Mul(quarters, 2)
+}
+
+// getQuarters convert the shares into a number of quarters of share to calculate without float errors
+// returns the number of quarters
+func getQuarters(shares float64) int64 {
+	return int64(math.Round(shares * 4))
 }
 
 // showTaxTranche show details of calculation showing every tax at each tranche
-func showTaxTrancheResult(result Result, year int) {
+func showTaxTrancheResult(w io.Writer, result Result, year int) {
 
 	// Install this: $ go get https://github.com/olekukonko/tablewriter
 	// Create table
-	table := tablewriter.NewWriter(os.Stdout)
+	table := tablewriter.NewWriter(w)
 	table.SetBorder(true) // Set Border to false
+	table.SetAutoWrapText(false)
 
 	// Setting header
 	var header = []string{"Tranche", "Min", "Max", "Rate", "Tax"}
@@ -324,11 +721,10 @@ func showTaxTrancheResult(result Result, year int) {
 		index := i + 1
 
 		var trancheNumber = fmt.Sprintf("Tranche %d", index)
-		var min = fmt.Sprintf("%s €", strconv.Itoa(val.tranche.Min))
-		var max = fmt.Sprintf("%s €", strconv.Itoa(val.tranche.Max))
-		rate, _ := utils.ConvertPercentageToFloat64(val.tranche.Rate)
-		var rateStr = fmt.Sprintf("%s %%", strconv.Itoa(int(rate)))
-		var tax = fmt.Sprintf("%s €", strconv.Itoa(int(val.Tax)))
+		var min = fmt.Sprintf("%s €", val.Tranche.Min)
+		var max = fmt.Sprintf("%s €", val.Tranche.Max)
+		var rateStr = fmt.Sprintf("%s %%", strings.TrimSuffix(val.Tranche.Rate, "%"))
+		var tax = fmt.Sprintf("%s €", val.Tax)
 
 		var line = make([]string, 5)
 		line[0] = trancheNumber
@@ -336,6 +732,30 @@ func showTaxTrancheResult(result Result, year int) {
 		line[2] = max
 		line[3] = rateStr
 		line[4] = tax
+
+		// Highlight the marginal tranche
+		if i == result.MarginalTranche {
+			for j := range line {
+				line[j] = colors.Green(line[j])
+			}
+		}
+		data = append(data, line)
+	}
+
+	// Add decote line
+	var decote = make([]string, 5)
+	decote[0] = "Decote"
+	decote[4] = fmt.Sprintf("-%s €", result.Decote)
+	data = append(data, decote)
+
+	// Add high income contribution lines
+	for i, val := range result.HighIncomeTaxTranches {
+		var line = make([]string, 5)
+		line[0] = fmt.Sprintf("CEHR %d", i+1)
+		line[1] = fmt.Sprintf("%s €", val.Tranche.Min)
+		line[2] = fmt.Sprintf("%s €", val.Tranche.Max)
+		line[3] = fmt.Sprintf("%s %%", strings.TrimSuffix(val.Tranche.Rate, "%"))
+		line[4] = fmt.Sprintf("%s €", val.Tax)
 		data = append(data, line)
 	}
 
@@ -345,71 +765,84 @@ func showTaxTrancheResult(result Result, year int) {
 	// Add footer
 	var footer = []string{
 		"Result",
-		"Remainder",
-		fmt.Sprintf("%s €", strconv.Itoa(int(result.Remainder))),
-		"Total Tax",
-		fmt.Sprintf("%s €", strconv.Itoa(int(result.Tax))),
+		"Remainder\nMarginal rate",
+		fmt.Sprintf("%s €\n%g %%", result.Remainder, result.MarginalRate),
+		fmt.Sprintf("Total Tax\nAverage rate\nNext %d €", config.NEXT_INCOME),
+		fmt.Sprintf("%s €\n%.1f %%\n%s €", result.Tax+result.HighIncomeTax, result.AverageRate, result.NextIncomeCost),
 	}
 	table.SetFooter(footer)
 
-	fmt.Println(colors.Yellow("\t\t\t Tax Details \t\t\t"))
-	fmt.Printf("For an income of %s € in %s\n", colors.Teal(result.Income), colors.Teal(year))
+	fmt.Fprintln(w, colors.Yellow("\t\t\t Tax Details \t\t\t"))
+	fmt.Fprintf(w, "For an income of %s € in %s\n", colors.Teal(result.Income), colors.Teal(year))
+	if result.IsCapped {
+		fmt.Fprintf(w, "Family quotient capped: tax of %s € without cap\n", colors.Teal(result.UncappedTax))
+	}
+	if result.IsHighIncomeSmoothed {
+		fmt.Fprintln(w, "Contribution on high incomes smoothed with the previous incomes")
+	}
 	table.Render()
 }
 
 // ShowTaxList show in the console the list of year metrics
-func ShowTaxList(cfg config.Config) {
-	fmt.Println(colors.Yellow("Tax list year"))
-	fmt.Println("-------------")
+func ShowTaxList(w io.Writer, cfg config.Config) {
+	fmt.Fprintln(w, colors.Yellow("Tax list year"))
+	fmt.Fprintln(w, "-------------")
 	for _, v := range cfg.TaxList {
 		var year = strconv.Itoa(v.Year)
 		if cfg.GetTax().Year == v.Year {
 			year = "* " + colors.Green(v.Year)
 		}
-		fmt.Printf("%s\n", year)
+		fmt.Fprintf(w, "%s\n", year)
 	}
 }
 
 // ShowTaxTranche show in the console the list of year metrics
-func ShowTaxTranche(cfg config.Config) {
-	fmt.Printf("Tax tranche of year (%s)\n", colors.Teal(cfg.GetTax().Year))
-	fmt.Println("-------------")
+func ShowTaxTranche(w io.Writer, cfg config.Config) {
+	fmt.Fprintf(w, "Tax tranche of year (%s)\n", colors.Teal(cfg.GetTax().Year))
+	fmt.Fprintln(w, "-------------")
 	for index, tranche := range cfg.GetTax().Tranches {
-		fmt.Printf("%d - From %s to %s - Rate taxes : %s\n", index, colors.Teal(tranche.Min), colors.Teal(tranche.Max), colors.Yellow(tranche.Rate))
+		fmt.Fprintf(w, "%d - From %s to %s - Rate taxes : %s\n", index, colors.Teal(tranche.Min), colors.Teal(tranche.Max), colors.Yellow(tranche.Rate))
 	}
 }
 
 // ShowTaxListUsed show the current tax used in the console
-func ShowTaxListUsed(cfg config.Config) {
-	fmt.Printf("The tax year base to calculate your taxes is %s\n", colors.Teal(cfg.GetTax().Year))
+func ShowTaxListUsed(w io.Writer, cfg config.Config) {
+	fmt.Fprintf(w, "The tax year base to calculate your taxes is %s\n", colors.Teal(cfg.GetTax().Year))
 }
 
 // SelectTaxYear ask in console if you want
 // Ask to the user if he wants to change the year of the tax metrics
-// to calculate taxes from another year
-func SelectTaxYear(cfg *config.Config) {
-	fmt.Printf("The calculator is based on %s\n", colors.Teal(cfg.GetTax().Year))
+// to calculate taxes from another year, the question is asked again while the year is not on the list
+func SelectTaxYear(prompter *utils.Prompter, cfg *config.Config) {
+	prompter.Printf("The calculator is based on %s\n", colors.Teal(cfg.GetTax().Year))
 
 	// Asking year
-	fmt.Print("List of years: ")
+	prompter.Print("List of years: ")
 	for _, v := range cfg.TaxList {
 		var year = strconv.Itoa(v.Year)
 		if cfg.GetTax().Year == v.Year {
 			year = colors.Green(v.Year)
 		}
-		fmt.Printf("%s ", year)
+		prompter.Printf("%s ", year)
 	}
-	fmt.Print("\nWhich year do you want ? ")
-
-	var input = utils.ReadValue()
+	prompter.Println()
 
-	year, err := utils.ConvertStringToInt(input)
+	err := prompter.Ask("Which year do you want ? ", func(input string) error {
+		year, err := utils.ConvertStringToInt(input)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a year", input)
+		}
+		tax, err := cfg.FindTax(year)
+		if err != nil {
+			return fmt.Errorf("%d is not on the list", year)
+		}
+		cfg.Tax = tax
+		return nil
+	})
 	if err != nil {
-		log.Printf("Error: Tax year is not convertible in int, details: %v", err)
+		log.Printf("Error: asking tax year, details: %v", err)
 		return
 	}
 
-	cfg.ChangeTax(year)
-	fmt.Printf("The tax year is now based on %s\n", colors.Teal(cfg.GetTax().Year))
-
+	prompter.Printf("The tax year is now based on %s\n", colors.Teal(cfg.GetTax().Year))
 }
@@ -5,10 +5,11 @@
 package tax
 
 import (
-	"math"
+	"errors"
 	"testing"
 
 	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
 	"github.com/LucasNoga/corpos-christie/user"
 	"github.com/LucasNoga/corpos-christie/utils/colors"
 )
@@ -26,11 +27,62 @@ func init() {
 	CONFIG.Tax = config.Tax{
 		Year: 2022,
 		Tranches: []config.Tranche{
-			{Min: 0, Max: 10225, Rate: "0%"},
-			{Min: 10226, Max: 26070, Rate: "11%"},
-			{Min: 26071, Max: 74545, Rate: "30%"},
-			{Min: 74546, Max: 160336, Rate: "41%"},
-			{Min: 160337, Max: math.MaxInt64, Rate: "45%"},
+			{Min: money.Euros(0), Max: money.Euros(10225), Rate: "0%"},
+			{Min: money.Euros(10226), Max: money.Euros(26070), Rate: "11%"},
+			{Min: money.Euros(26071), Max: money.Euros(74545), Rate: "30%"},
+			{Min: money.Euros(74546), Max: money.Euros(160336), Rate: "41%"},
+			{Min: money.Euros(160337), Max: money.MAX, Rate: "45%"},
+		},
+		QuotientCap: config.QuotientCap{HalfShare: money.Euros(1592), IsolatedParent: money.Euros(3756)},
+		Decote:      config.Decote{SingleThreshold: money.Euros(790), CoupleThreshold: money.Euros(1307), Rate: "45.25%"},
+		HighIncome: config.HighIncome{
+			Single: []config.Tranche{
+				{Min: money.Euros(0), Max: money.Euros(250000), Rate: "0%"},
+				{Min: money.Euros(250001), Max: money.Euros(500000), Rate: "3%"},
+				{Min: money.Euros(500001), Max: money.MAX, Rate: "4%"},
+			},
+			Couple: []config.Tranche{
+				{Min: money.Euros(0), Max: money.Euros(500000), Rate: "0%"},
+				{Min: money.Euros(500001), Max: money.Euros(1000000), Rate: "3%"},
+				{Min: money.Euros(1000001), Max: money.MAX, Rate: "4%"},
+			},
+		},
+		Credits: config.Credits{
+			Donation:       config.Credit{Rate: "66%", IncomeCeiling: "20%", CarryForward: 5},
+			AidDonation:    config.Credit{Rate: "75%", Ceiling: money.Euros(1000)},
+			HomeEmployment: config.Credit{Rate: "50%", Ceiling: money.Euros(12000), ExtraCeiling: money.Euros(1500), MaxCeiling: money.Euros(15000), Refundable: true},
+			Childcare:      config.Credit{Rate: "50%", Ceiling: money.Euros(2300), Refundable: true},
+		},
+		Withholding: []config.Tranche{
+			{Min: money.Euros(0), Max: money.Euros(1439), Rate: "0%"},
+			{Min: money.Euros(1440), Max: money.Euros(1495), Rate: "0.5%"},
+			{Min: money.Euros(1496), Max: money.Euros(1591), Rate: "1.3%"},
+			{Min: money.Euros(1592), Max: money.Euros(1698), Rate: "2.1%"},
+			{Min: money.Euros(1699), Max: money.Euros(1815), Rate: "2.9%"},
+			{Min: money.Euros(1816), Max: money.Euros(1912), Rate: "3.5%"},
+			{Min: money.Euros(1913), Max: money.Euros(2039), Rate: "4.1%"},
+			{Min: money.Euros(2040), Max: money.Euros(2413), Rate: "5.3%"},
+			{Min: money.Euros(2414), Max: money.Euros(2762), Rate: "7.5%"},
+			{Min: money.Euros(2763), Max: money.Euros(3146), Rate: "9.9%"},
+			{Min: money.Euros(3147), Max: money.Euros(3542), Rate: "11.9%"},
+			{Min: money.Euros(3543), Max: money.Euros(4133), Rate: "13.8%"},
+			{Min: money.Euros(4134), Max: money.Euros(4955), Rate: "15.8%"},
+			{Min: money.Euros(4956), Max: money.Euros(6201), Rate: "17.9%"},
+			{Min: money.Euros(6202), Max: money.Euros(7746), Rate: "20%"},
+			{Min: money.Euros(7747), Max: money.Euros(10751), Rate: "24%"},
+			{Min: money.Euros(10752), Max: money.Euros(14562), Rate: "28%"},
+			{Min: money.Euros(14563), Max: money.Euros(22859), Rate: "33%"},
+			{Min: money.Euros(22860), Max: money.Euros(48966), Rate: "38%"},
+			{Min: money.Euros(48967), Max: money.MAX, Rate: "43%"},
+		},
+		Salary: config.Salary{
+			NonExecutiveRate: "12.5%",
+			ExecutiveRate:    "15.5%",
+			CSGBase:          "98.25%",
+			DeductibleCSG:    "6.8%",
+			NonDeductibleCSG: "2.4%",
+			CRDS:             "0.5%",
+			Allowance:        config.Allowance{Rate: "10%", Min: money.Euros(442), Max: money.Euros(12829)},
 		},
 	}
 	CONFIG.TaxList = []config.Tax{
@@ -38,41 +90,43 @@ func init() {
 			Year: 2022,
 			Tranches: []config.Tranche{
 
-				{Min: 0, Max: 10225, Rate: "0%"},
-				{Min: 10226, Max: 26070, Rate: "11%"},
-				{Min: 26071, Max: 74545, Rate: "30%"},
-				{Min: 74546, Max: 160336, Rate: "41%"},
-				{Min: 160337, Max: 1000000, Rate: "45%"},
+				{Min: money.Euros(0), Max: money.Euros(10225), Rate: "0%"},
+				{Min: money.Euros(10226), Max: money.Euros(26070), Rate: "11%"},
+				{Min: money.Euros(26071), Max: money.Euros(74545), Rate: "30%"},
+				{Min: money.Euros(74546), Max: money.Euros(160336), Rate: "41%"},
+				{Min: money.Euros(160337), Max: money.Euros(1000000), Rate: "45%"},
 			},
+			QuotientCap: config.QuotientCap{HalfShare: money.Euros(1592), IsolatedParent: money.Euros(3756)},
+			Decote:      config.Decote{SingleThreshold: money.Euros(790), CoupleThreshold: money.Euros(1307), Rate: "45.25%"},
 		},
 		{
 			Year: 2021,
 			Tranches: []config.Tranche{
-				{Min: 0, Max: 10084, Rate: "0%"},
-				{Min: 10085, Max: 25710, Rate: "11%"},
-				{Min: 25711, Max: 73516, Rate: "30%"},
-				{Min: 73517, Max: 158122, Rate: "41%"},
-				{Min: 158123, Max: 1000000, Rate: "45%"},
+				{Min: money.Euros(0), Max: money.Euros(10084), Rate: "0%"},
+				{Min: money.Euros(10085), Max: money.Euros(25710), Rate: "11%"},
+				{Min: money.Euros(25711), Max: money.Euros(73516), Rate: "30%"},
+				{Min: money.Euros(73517), Max: money.Euros(158122), Rate: "41%"},
+				{Min: money.Euros(158123), Max: money.Euros(1000000), Rate: "45%"},
 			},
 		},
 		{
 			Year: 2020,
 			Tranches: []config.Tranche{
-				{Min: 0, Max: 10064, Rate: "0%"},
-				{Min: 10065, Max: 25659, Rate: "11%"},
-				{Min: 25660, Max: 73369, Rate: "30%"},
-				{Min: 73370, Max: 157806, Rate: "41%"},
-				{Min: 157807, Max: 1000000, Rate: "45%"},
+				{Min: money.Euros(0), Max: money.Euros(10064), Rate: "0%"},
+				{Min: money.Euros(10065), Max: money.Euros(25659), Rate: "11%"},
+				{Min: money.Euros(25660), Max: money.Euros(73369), Rate: "30%"},
+				{Min: money.Euros(73370), Max: money.Euros(157806), Rate: "41%"},
+				{Min: money.Euros(157807), Max: money.Euros(1000000), Rate: "45%"},
 			},
 		},
 		{
 			Year: 2019,
 			Tranches: []config.Tranche{
-				{Min: 0, Max: 10064, Rate: "0%"},
-				{Min: 10065, Max: 27794, Rate: "14%"},
-				{Min: 27795, Max: 74517, Rate: "30%"},
-				{Min: 74518, Max: 157806, Rate: "41%"},
-				{Min: 157807, Max: 1000000, Rate: "45%"},
+				{Min: money.Euros(0), Max: money.Euros(10064), Rate: "0%"},
+				{Min: money.Euros(10065), Max: money.Euros(27794), Rate: "14%"},
+				{Min: money.Euros(27795), Max: money.Euros(74517), Rate: "30%"},
+				{Min: money.Euros(74518), Max: money.Euros(157806), Rate: "41%"},
+				{Min: money.Euros(157807), Max: money.Euros(1000000), Rate: "45%"},
 			},
 		},
 	}
@@ -81,12 +135,30 @@ func init() {
 // Calculate tax for a single person with 30000 of income
 func TestCalculateTaxForSinglePerson(t *testing.T) {
 	var user = user.User{}
-	user.Income = 30000
+	user.Income = money.Euros(30000)
+
+	result := CalculateTax(user, CONFIG)
+	t.Logf("Function result:\t%+v", result)
+
+	expected := Result{Income: money.Euros(30000), Tax: money.Euros(2922), Remainder: money.Euros(27078)}
+	t.Logf("Expected:\t\t%+v", expected)
+
+	if result.Income != expected.Income || result.Tax != expected.Tax || result.Remainder != expected.Remainder {
+		t.Errorf("Expected that the Income %s should be equal to %s", colors.Red(expected.Income), colors.Red(result.Income))
+		t.Errorf("Expected that the Tax %s should be equal to %s", colors.Red(expected.Tax), colors.Red(result.Tax))
+		t.Errorf("Expected that the Remainder %s should be equal to %s", colors.Red(expected.Remainder), colors.Red(result.Remainder))
+	}
+}
+
+// Calculate tax with an income in cents, the income is rounded down to the euro
+// and the tax of 2921.95 is rounded to the nearest euro
+func TestCalculateTaxRounding(t *testing.T) {
+	var user = user.User{Income: money.Cents(3000099)}
 
-	result := CalculateTax(&user, CONFIG)
+	result := CalculateTax(user, CONFIG)
 	t.Logf("Function result:\t%+v", result)
 
-	expected := Result{Income: 30000, Tax: 2922, Remainder: 27078}
+	expected := Result{Income: money.Euros(30000), Tax: money.Euros(2922), Remainder: money.Euros(27078)}
 	t.Logf("Expected:\t\t%+v", expected)
 
 	if result.Income != expected.Income || result.Tax != expected.Tax || result.Remainder != expected.Remainder {
@@ -97,17 +169,18 @@ func TestCalculateTaxForSinglePerson(t *testing.T) {
 }
 
 // Calculate tax for a couple with 2 children, testing shares with a couple and 2 childrens
+// The tax of 3225.75 is rounded up to 3226
 func TestCalculateTaxForCoupleWith2Children(t *testing.T) {
 	user := user.User{
-		Income:     60000,
+		Income:     money.Euros(60000),
 		IsInCouple: true,
 		Children:   2,
 	}
 
-	result := CalculateTax(&user, CONFIG)
+	result := CalculateTax(user, CONFIG)
 	t.Logf("Function result:\t%+v", result)
 
-	expected := Result{Income: 60000, Tax: 3225, Remainder: 56775}
+	expected := Result{Income: money.Euros(60000), Tax: money.Euros(3226), Remainder: money.Euros(56774)}
 	t.Logf("Expected:\t\t%+v", expected)
 
 	if result.Income != expected.Income || result.Tax != expected.Tax || result.Remainder != expected.Remainder {
@@ -118,17 +191,18 @@ func TestCalculateTaxForCoupleWith2Children(t *testing.T) {
 }
 
 // Calculate tax for a couple with 3 children, testing shares with a couple and 3 childrens
+// The benefit of the 4 extra half-shares is capped at 4 * 1592
 func TestCalculateTaxForCoupleWith3Children(t *testing.T) {
 	user := user.User{
-		Income:     100000,
+		Income:     money.Euros(100000),
 		IsInCouple: true,
 		Children:   3,
 	}
 
-	result := CalculateTax(&user, CONFIG)
+	result := CalculateTax(user, CONFIG)
 	t.Logf("Function result:\t%+v", result)
 
-	expected := Result{Income: 100000, Tax: 6501, Remainder: 93499}
+	expected := Result{Income: money.Euros(100000), Tax: money.Euros(11476), Remainder: money.Euros(88524)}
 	t.Logf("Expected:\t\t%+v", expected)
 
 	if result.Income != expected.Income || result.Tax != expected.Tax || result.Remainder != expected.Remainder {
@@ -141,15 +215,15 @@ func TestCalculateTaxForCoupleWith3Children(t *testing.T) {
 // Calculate tax for a couple with no children, testing shares with a couple and 0 childrens
 func TestCalculateTaxForCoupleWithNoChildren(t *testing.T) {
 	user := user.User{
-		Income:     60000,
+		Income:     money.Euros(60000),
 		IsInCouple: true,
 		Children:   0,
 	}
 
-	result := CalculateTax(&user, CONFIG)
+	result := CalculateTax(user, CONFIG)
 	t.Logf("Function result:\t%+v", result)
 
-	expected := Result{Income: 60000, Tax: 5843, Remainder: 54157}
+	expected := Result{Income: money.Euros(60000), Tax: money.Euros(5844), Remainder: money.Euros(54156)}
 	t.Logf("Expected:\t\t%+v", expected)
 
 	if result.Income != expected.Income || result.Tax != expected.Tax || result.Remainder != expected.Remainder {
@@ -159,17 +233,19 @@ func TestCalculateTaxForCoupleWithNoChildren(t *testing.T) {
 	}
 }
 
+// Calculate tax for an isolated parent with 2 children, testing shares of an isolated parent
+// The tax of 488 is cancelled by the decote
 func TestCalculateTaxForIsolatedParent(t *testing.T) {
 	user := user.User{
-		Income:     30000,
+		Income:     money.Euros(30000),
 		IsInCouple: false,
 		Children:   2,
 	}
 
-	result := CalculateTax(&user, CONFIG)
+	result := CalculateTax(user, CONFIG)
 	t.Logf("Function result:\t%+v", result)
 
-	expected := Result{Income: 30000, Tax: 488, Remainder: 29512}
+	expected := Result{Income: money.Euros(30000), Tax: money.Euros(0), Remainder: money.Euros(30000)}
 	t.Logf("Expected:\t\t%+v", expected)
 
 	if result.Income != expected.Income || result.Tax != expected.Tax || result.Remainder != expected.Remainder {
@@ -179,65 +255,309 @@ func TestCalculateTaxForIsolatedParent(t *testing.T) {
 	}
 }
 
+// Calculate tax for a couple with 2 children where the family quotient is not capped
+func TestCalculateTaxNotCapped(t *testing.T) {
+	user := user.User{
+		Income:     money.Euros(60000),
+		IsInCouple: true,
+		Children:   2,
+	}
+
+	result := CalculateTax(user, CONFIG)
+	t.Logf("Function result:\t%+v", result)
+
+	if result.IsCapped {
+		t.Errorf("Expected that the family quotient should not be capped")
+	}
+	if result.UncappedTax != result.CappedTax {
+		t.Errorf("Expected that the UncappedTax %s should be equal to %s", colors.Red(result.UncappedTax), colors.Red(result.CappedTax))
+	}
+}
+
+// Calculate tax for an isolated parent with 2 children where the family quotient is capped
+// The benefit is capped at 3756 for the first child and 1592 for the second
+func TestCalculateTaxForIsolatedParentCapped(t *testing.T) {
+	user := user.User{
+		Income:     money.Euros(80000),
+		IsInCouple: false,
+		Children:   2,
+	}
+
+	result := CalculateTax(user, CONFIG)
+	t.Logf("Function result:\t%+v", result)
+
+	expected := Result{Income: money.Euros(80000), Tax: money.Euros(13174), UncappedTax: money.Euros(8805), CappedTax: money.Euros(13174), IsCapped: true, Remainder: money.Euros(66826)}
+	t.Logf("Expected:\t\t%+v", expected)
+
+	if !result.IsCapped {
+		t.Errorf("Expected that the family quotient should be capped")
+	}
+	if result.Tax != expected.Tax || result.UncappedTax != expected.UncappedTax || result.CappedTax != expected.CappedTax || result.Remainder != expected.Remainder {
+		t.Errorf("Expected that the Tax %s should be equal to %s", colors.Red(expected.Tax), colors.Red(result.Tax))
+		t.Errorf("Expected that the UncappedTax %s should be equal to %s", colors.Red(expected.UncappedTax), colors.Red(result.UncappedTax))
+		t.Errorf("Expected that the CappedTax %s should be equal to %s", colors.Red(expected.CappedTax), colors.Red(result.CappedTax))
+		t.Errorf("Expected that the Remainder %s should be equal to %s", colors.Red(expected.Remainder), colors.Red(result.Remainder))
+	}
+}
+
+// Calculate tax for a single person with 20000 of income, testing a partial decote
+// Tax of 1075 is reduced by the decote 790 - 45.25% * 1075 = 304
+func TestCalculateTaxWithDecote(t *testing.T) {
+	var user = user.User{Income: money.Euros(20000)}
+
+	result := CalculateTax(user, CONFIG)
+	t.Logf("Function result:\t%+v", result)
+
+	expected := Result{Income: money.Euros(20000), Tax: money.Euros(771), CappedTax: money.Euros(1075), Decote: money.Euros(304), Remainder: money.Euros(19229)}
+	t.Logf("Expected:\t\t%+v", expected)
+
+	if result.Tax != expected.Tax || result.CappedTax != expected.CappedTax || result.Decote != expected.Decote || result.Remainder != expected.Remainder {
+		t.Errorf("Expected that the Tax %s should be equal to %s", colors.Red(expected.Tax), colors.Red(result.Tax))
+		t.Errorf("Expected that the CappedTax %s should be equal to %s", colors.Red(expected.CappedTax), colors.Red(result.CappedTax))
+		t.Errorf("Expected that the Decote %s should be equal to %s", colors.Red(expected.Decote), colors.Red(result.Decote))
+		t.Errorf("Expected that the Remainder %s should be equal to %s", colors.Red(expected.Remainder), colors.Red(result.Remainder))
+	}
+}
+
+// Calculate tax for a single person with a high income, testing the decote is not applied
+func TestCalculateTaxWithoutDecote(t *testing.T) {
+	var user = user.User{Income: money.Euros(30000)}
+
+	result := CalculateTax(user, CONFIG)
+	t.Logf("Function result:\t%+v", result)
+
+	if result.Decote != money.Euros(0) {
+		t.Errorf("Expected that the Decote %s should be equal to %s", colors.Red(0), colors.Red(result.Decote))
+	}
+}
+
+// Calculate the marginal rate, the average rate and the tax on the next 1000 for a single person
+// 2922 of tax on 30000 gives 9.7%, the next 1000 are taxed at 30%
+func TestCalculateTaxRates(t *testing.T) {
+	var user = user.User{Income: money.Euros(30000)}
+
+	result := CalculateTax(user, CONFIG)
+	t.Logf("Function result:\t%+v", result)
+
+	expected := Result{MarginalTranche: 2, MarginalRate: 30, AverageRate: 9.7, NextIncomeCost: money.Euros(300)}
+	t.Logf("Expected:\t\t%+v", expected)
+
+	if result.MarginalTranche != expected.MarginalTranche || result.MarginalRate != expected.MarginalRate || result.AverageRate != expected.AverageRate || result.NextIncomeCost != expected.NextIncomeCost {
+		t.Errorf("Expected that the MarginalRate %s should be equal to %s", colors.Red(expected.MarginalRate), colors.Red(result.MarginalRate))
+		t.Errorf("Expected that the AverageRate %s should be equal to %s", colors.Red(expected.AverageRate), colors.Red(result.AverageRate))
+		t.Errorf("Expected that the NextIncomeCost %s should be equal to %s", colors.Red(expected.NextIncomeCost), colors.Red(result.NextIncomeCost))
+	}
+	if user.Tax != 0 || user.Remainder != 0 || user.MarginalRate != 0 || user.AverageRate != 0 || user.NextIncomeCost != 0 {
+		t.Errorf("Expected that the user %+v should not be changed by the calculation", user)
+	}
+}
+
+// Calculate the marginal rate of a capped family quotient, it's the rate of the shares of the declarants
+// 100000 for 4 shares is in the tranche at 11% but the tax is calculated with 2 shares at 30%
+func TestCalculateTaxMarginalRateCapped(t *testing.T) {
+	var user = user.User{Income: money.Euros(100000), IsInCouple: true, Children: 3}
+
+	result := CalculateTax(user, CONFIG)
+	t.Logf("Function result:\t%+v", result)
+
+	if result.MarginalRate != 30 || result.NextIncomeCost != money.Euros(300) {
+		t.Errorf("Expected that the MarginalRate %s should be equal to %s", colors.Red(30), colors.Red(result.MarginalRate))
+		t.Errorf("Expected that the NextIncomeCost %s should be equal to %s", colors.Red(300), colors.Red(result.NextIncomeCost))
+	}
+}
+
+// Calculate the contribution on high incomes for a single person with 300000 of income
+func TestCalculateHighIncomeTax(t *testing.T) {
+	var user = user.User{Income: money.Euros(300000)}
+
+	result := CalculateTax(user, CONFIG)
+	t.Logf("Function result:\t%+v", result)
+
+	var expected = money.Euros(1500)
+	if result.HighIncomeTax != expected || result.IsHighIncomeSmoothed {
+		t.Errorf("Expected that the HighIncomeTax %s should be equal to %s", colors.Red(expected), colors.Red(result.HighIncomeTax))
+	}
+	if result.Remainder != user.Income-result.Tax-result.HighIncomeTax {
+		t.Errorf("Expected that the Remainder %s should include the contribution on high incomes", colors.Red(result.Remainder))
+	}
+}
+
+// Calculate the contribution on high incomes for a couple under the threshold
+func TestCalculateHighIncomeTaxForCoupleUnderThreshold(t *testing.T) {
+	var user = user.User{Income: money.Euros(400000), IsInCouple: true}
+
+	result := CalculateTax(user, CONFIG)
+	t.Logf("Function result:\t%+v", result)
+
+	if result.HighIncomeTax != money.Euros(0) {
+		t.Errorf("Expected that the HighIncomeTax %s should be equal to %s", colors.Red(0), colors.Red(result.HighIncomeTax))
+	}
+}
+
+// Calculate the smoothed contribution on high incomes for a single person with 600000 of income
+// and 200000 of income for the two previous years: 2 * contribution(300000 + 100000)
+func TestCalculateHighIncomeTaxSmoothed(t *testing.T) {
+	var user = user.User{Income: money.Euros(600000), PreviousIncomes: [2]money.Money{money.Euros(200000), money.Euros(200000)}}
+
+	result := CalculateTax(user, CONFIG)
+	t.Logf("Function result:\t%+v", result)
+
+	var expected = money.Euros(9000)
+	if result.HighIncomeTax != expected || !result.IsHighIncomeSmoothed {
+		t.Errorf("Expected that the HighIncomeTax %s should be equal to %s", colors.Red(expected), colors.Red(result.HighIncomeTax))
+	}
+}
+
+// checkReverseTax check the income found is the smallest income in euros leaving at least the remainder wished
+// The remainder of the income found is within one euro of the remainder wished
+func checkReverseTax(t *testing.T, household user.User, result Result, target money.Money) {
+	t.Helper()
+	if result.Remainder < target || result.Remainder >= target+money.Euros(1) {
+		t.Errorf("Expected that the Remainder %s should be within one euro over %s", colors.Red(result.Remainder), colors.Red(target))
+	}
+	if result.Income > 0 && getRemainder(household, CONFIG, result.Income-money.Euros(1)) >= target {
+		t.Errorf("Expected that the Income %s should be the smallest income to get %s", colors.Red(result.Income), colors.Red(target))
+	}
+}
+
 // Calculate reverse tax for a single person to get at the end 28395
 func TestCalculateReverseTaxForSinglePerson(t *testing.T) {
 	user := user.User{
-		Remainder: 28395,
+		Remainder: money.Euros(28395),
 	}
 
-	result := calculateReverseTax(&user, CONFIG)
+	result, err := CalculateReverseTax(user, CONFIG)
+	if err != nil {
+		t.Fatal(err)
+	}
 	t.Logf("Function result:\t%+v", result)
 
-	expected := Result{Income: 32000, Tax: 3605, Remainder: 28395}
+	expected := Result{Income: money.Euros(31881), Tax: money.Euros(3486), Remainder: money.Euros(28395)}
 	t.Logf("Expected:\t\t%+v", expected)
 
-	if result.Income != expected.Income && result.Tax != expected.Tax && result.Remainder != expected.Remainder {
+	if result.Income != expected.Income || result.Tax != expected.Tax || result.Remainder != expected.Remainder {
 		t.Errorf("Expected that the Income %s should be equal to %s", colors.Red(expected.Income), colors.Red(result.Income))
 		t.Errorf("Expected that the Tax %s should be equal to %s", colors.Red(expected.Tax), colors.Red(result.Tax))
 		t.Errorf("Expected that the Remainder %s should be equal to %s", colors.Red(expected.Remainder), colors.Red(result.Remainder))
 	}
+	checkReverseTax(t, user, result, money.Euros(28395))
 }
 
 // Calculate reverse tax for a couple with 2 children, testing shares with a couple and 2 childrens
 func TestCalculateReverseTaxForCoupleWith2Children(t *testing.T) {
 	user := user.User{
-		Remainder:  53124,
+		Remainder:  money.Euros(56774),
 		IsInCouple: true,
 		Children:   2,
 	}
 
-	result := calculateReverseTax(&user, CONFIG)
+	result, err := CalculateReverseTax(user, CONFIG)
+	if err != nil {
+		t.Fatal(err)
+	}
 	t.Logf("Function result:\t%+v", result)
 
-	expected := Result{Income: 55950, Tax: 2826, Remainder: 53124}
+	expected := Result{Income: money.Euros(60000), Tax: money.Euros(3226), Remainder: money.Euros(56774)}
 	t.Logf("Expected:\t\t%+v", expected)
 
-	if result.Income != expected.Income && result.Tax != expected.Tax && result.Remainder != expected.Remainder {
+	if result.Income != expected.Income || result.Tax != expected.Tax || result.Remainder != expected.Remainder {
 		t.Errorf("Expected that the Income %s should be equal to %s", colors.Red(expected.Income), colors.Red(result.Income))
 		t.Errorf("Expected that the Tax %s should be equal to %s", colors.Red(expected.Tax), colors.Red(result.Tax))
 		t.Errorf("Expected that the Remainder %s should be equal to %s", colors.Red(expected.Remainder), colors.Red(result.Remainder))
 	}
+	checkReverseTax(t, user, result, money.Euros(56774))
 }
 
-// Calculate reverse tax for a couple with 3 children, testing shares with a couple and 3 childrens
+// Calculate reverse tax for a couple with 3 children where the family quotient is capped
 func TestCalculateReverseTaxForCoupleWith3Children(t *testing.T) {
 	user := user.User{
-		Remainder:  93437,
+		Remainder:  money.Euros(88524),
 		IsInCouple: true,
 		Children:   3,
 	}
 
-	result := calculateReverseTax(&user, CONFIG)
+	result, err := CalculateReverseTax(user, CONFIG)
+	if err != nil {
+		t.Fatal(err)
+	}
 	t.Logf("Function result:\t%+v", result)
 
-	expected := Result{Income: 100000, Tax: 6563, Remainder: 93437}
+	expected := Result{Income: money.Euros(100000), Tax: money.Euros(11476), Remainder: money.Euros(88524)}
 	t.Logf("Expected:\t\t%+v", expected)
 
-	if result.Income != expected.Income && result.Tax != expected.Tax && result.Remainder != expected.Remainder {
+	if !result.IsCapped || result.Income != expected.Income || result.Tax != expected.Tax || result.Remainder != expected.Remainder {
 		t.Errorf("Expected that the Income %s should be equal to %s", colors.Red(expected.Income), colors.Red(result.Income))
 		t.Errorf("Expected that the Tax %s should be equal to %s", colors.Red(expected.Tax), colors.Red(result.Tax))
 		t.Errorf("Expected that the Remainder %s should be equal to %s", colors.Red(expected.Remainder), colors.Red(result.Remainder))
 	}
+	checkReverseTax(t, user, result, money.Euros(88524))
+}
+
+// Calculate reverse tax for a single person with a decote
+func TestCalculateReverseTaxWithDecote(t *testing.T) {
+	user := user.User{Remainder: money.Euros(19229)}
+
+	result, err := CalculateReverseTax(user, CONFIG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("Function result:\t%+v", result)
+
+	if result.Income != money.Euros(20000) || result.Decote != money.Euros(304) {
+		t.Errorf("Expected that the Income %s should be equal to %s", colors.Red(money.Euros(20000)), colors.Red(result.Income))
+	}
+	checkReverseTax(t, user, result, money.Euros(19229))
+}
+
+// Calculate reverse tax for a remainder of 1000000 with the contribution on high incomes
+func TestCalculateReverseTaxForHighIncome(t *testing.T) {
+	user := user.User{Remainder: money.Euros(1000000)}
+
+	result, err := CalculateReverseTax(user, CONFIG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("Function result:\t%+v", result)
+
+	if result.HighIncomeTax <= 0 {
+		t.Errorf("Expected that the HighIncomeTax %s should be greater than 0", colors.Red(result.HighIncomeTax))
+	}
+	checkReverseTax(t, user, result, money.Euros(1000000))
+}
+
+// Calculate reverse tax for many remainders and households
+// the income found is always the smallest income leaving the remainder within one euro
+func TestCalculateReverseTaxTolerance(t *testing.T) {
+	var households = []user.User{
+		{},
+		{IsInCouple: true, Children: 2},
+		{IsInCouple: true, Children: 3},
+		{Children: 2},
+		{Children: 1, PreviousIncomes: [2]money.Money{money.Euros(100000), money.Euros(100000)}},
+	}
+
+	for _, household := range households {
+		for target := money.ZERO; target <= money.Euros(700000); target += money.Cents(123457) {
+			var user = household
+			user.Remainder = target
+
+			result, err := CalculateReverseTax(user, CONFIG)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkReverseTax(t, household, result, target)
+		}
+	}
+}
+
+// A remainder over money.MAX_AMOUNT or too close to it is rejected instead of overflowing the income
+func TestCalculateReverseTaxTooHigh(t *testing.T) {
+	for _, remainder := range []money.Money{money.Cents(9223372036854775800), money.Cents(5000000000000000000), money.MAX_AMOUNT} {
+		var user = user.User{Remainder: remainder}
+		if result, err := CalculateReverseTax(user, CONFIG); !errors.Is(err, ErrRemainderTooHigh) {
+			t.Errorf("Expected %s for the remainder %s, got %+v %v", colors.Red(ErrRemainderTooHigh), remainder, result, err)
+		}
+	}
 }
 
 // Create user single with no children and check shares
@@ -329,3 +649,76 @@ func TestUserInCoupleWith4Children(t *testing.T) {
 		t.Errorf("Expected that the Shares \n%f\n should be equal to \n%f", sharesRef, shares)
 	}
 }
+
+// The shares of the particular cases of the household: children in alternating custody count for the half,
+// a disability card gives a half share more and a widowed parent keeps the share of the couple
+func TestGetSharesHousehold(t *testing.T) {
+	var tests = []struct {
+		name   string
+		user   user.User
+		shares float64
+	}{
+		{"couple with 2 children in alternating custody", user.User{IsInCouple: true, Children: 2, Dependents: []user.Dependent{{IsAlternating: true}, {IsAlternating: true}}}, 2.5},
+		{"couple with a child and 2 in alternating custody", user.User{IsInCouple: true, Children: 3, Dependents: []user.Dependent{{IsAlternating: true}, {}, {IsAlternating: true}}}, 3.25},
+		{"couple with a disabled child", user.User{IsInCouple: true, Children: 1, Dependents: []user.Dependent{{IsDisabled: true}}}, 3},
+		{"couple with a disabled child in alternating custody", user.User{IsInCouple: true, Children: 1, Dependents: []user.Dependent{{IsAlternating: true, IsDisabled: true}}}, 2.5},
+		{"widowed with a child", user.User{Children: 1, Cases: user.Cases{IsWidowed: true}}, 2.5},
+		{"widowed without children", user.User{Cases: user.Cases{IsWidowed: true}}, 1},
+		{"isolated with a child in alternating custody", user.User{Children: 1, Dependents: []user.Dependent{{IsAlternating: true}}}, 1.5},
+		{"isolated with a child and a child in alternating custody", user.User{Children: 2, Dependents: []user.Dependent{{}, {IsAlternating: true}}}, 2.25},
+		{"cohabiting with a child in alternating custody", user.User{Children: 1, IsCohabiting: true, Dependents: []user.Dependent{{IsAlternating: true}}}, 1.25},
+		{"couple with a veteran", user.User{IsInCouple: true, Cases: user.Cases{Invalids: 1}}, 2.5},
+		{"couple of disabled declarants", user.User{IsInCouple: true, Cases: user.Cases{Invalids: 2}}, 3},
+	}
+
+	for _, test := range tests {
+		if shares := getShares(test.user); shares != test.shares {
+			t.Errorf("Expected %s shares for a %s, got %s", colors.Red(test.shares), test.name, colors.Red(shares))
+		}
+	}
+}
+
+// The shares of an invalid number of children or of children without details for each one
+// are calculated from their number only
+func TestGetSharesChildrenCount(t *testing.T) {
+	var tests = []struct {
+		user   user.User
+		shares float64
+	}{
+		{user.User{Children: -1}, 1},
+		{user.User{Children: 1000000000, IsCohabiting: true}, 1000000000},
+		{user.User{Children: 1000000000, IsInCouple: true}, 1000000001},
+		{user.User{IsInCouple: true, Dependents: make([]user.Dependent, 3)}, 2},
+		{user.User{IsInCouple: true, Children: 1, Dependents: []user.Dependent{{IsDisabled: true}, {IsDisabled: true}}}, 2.5},
+	}
+
+	for _, test := range tests {
+		if shares := getShares(test.user); shares != test.shares {
+			t.Errorf("Expected %s shares for %d children, got %s", colors.Red(test.shares), test.user.Children, colors.Red(shares))
+		}
+	}
+	if result := CalculateTax(user.User{Income: money.Euros(30000), Children: -1}, CONFIG); result.Shares != 1 {
+		t.Errorf("Expected %s share for a negative number of children, got %s", colors.Red(1), colors.Red(result.Shares))
+	}
+}
+
+// The ceiling of an isolated parent is halved for a child in alternating custody only
+func TestGetQuotientCapAlternating(t *testing.T) {
+	var quotientCap = config.QuotientCap{HalfShare: money.Euros(1592), IsolatedParent: money.Euros(3756)}
+	var tests = []struct {
+		user user.User
+		cap  money.Money
+	}{
+		{user.User{Children: 1}, money.Euros(3756)},
+		{user.User{Children: 1, Dependents: []user.Dependent{{IsAlternating: true}}}, money.Euros(1878)},
+		{user.User{Children: 2, Dependents: []user.Dependent{{IsAlternating: true}, {IsAlternating: true}}}, money.Euros(1878 + 796)},
+		{user.User{IsInCouple: true, Children: 1, Dependents: []user.Dependent{{IsAlternating: true}}}, money.Euros(796)},
+	}
+
+	for _, test := range tests {
+		var cap = getQuotientCap(test.user, getShares(test.user), getBaseShares(test.user), quotientCap)
+		if cap != test.cap {
+			t.Errorf("Expected a ceiling of %s for %+v, got %s", colors.Red(test.cap), test.user, colors.Red(cap))
+		}
+	}
+}
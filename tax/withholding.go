@@ -0,0 +1,185 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package tax is the algorithm to calculate taxes
+package tax
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Withholding represent the rates of the withholding tax (prélèvement à la source)
+type Withholding struct {
+	Rate             float64        // Rate of the household in percent (taux du foyer)
+	IsIndividualized bool           // True if the rates are individualized between the members of the couple
+	Incomes          [2]money.Money // Incomes of the user and of the partner
+	IndividualRates  [2]float64     // Rates of the user and of the partner in percent (taux individualisés)
+	NeutralRates     [2]float64     // Rates of the user and of the partner from the neutral grid used by employers (taux neutre)
+}
+
+// StartWithholdingCalculator calculate withholding tax rates from income seized by user
+func StartWithholdingCalculator(prompter *utils.Prompter, cfg *config.Config, user *user.User) {
+	prompter.Printf("The calculator is based on %s\n", colors.Teal(cfg.GetTax().Year))
+	status := true
+
+	// Ask income's user
+	err := user.AskIncome(prompter, "1. Enter your income\n    (en) Taxable income\n    (fr) Revenus net imposable\n> ")
+	if err != nil {
+		log.Printf("Error: asking income for user, details: %v", err)
+		status = false
+		return
+	}
+
+	// Ask if user is in couple
+	err = user.AskIsInCouple(prompter, "2. Are you in couple (Y/n) ? ")
+	if err != nil {
+		log.Printf("Error: asking is in couple for user, details: %v", err)
+		status = false
+		return
+	}
+
+	// Ask income of the partner to individualize rates
+	user.PartnerIncome = 0
+	if user.IsInCouple {
+		err = user.AskPartnerIncome(prompter, "   Enter the income of your partner among this income (empty to skip) ? ")
+		if err != nil {
+			log.Printf("Error: asking partner income for user, details: %v", err)
+			status = false
+			return
+		}
+	}
+
+	// Ask if user hasChildren
+	err = user.AskHasChildren(prompter, "3. How many children do you have ? ")
+	if err != nil {
+		log.Printf("Error: asking has children, details: %v", err)
+		status = false
+		return
+	}
+
+	// Calculate tax and rates
+	result := CalculateTax(*user, cfg)
+	applyResult(user, result)
+	withholding := CalculateWithholding(result, *user, cfg)
+
+	showWithholdingResult(prompter.Writer(), withholding, cfg.GetTax().Year)
+
+	if status {
+		prompter.Println(colors.Green("Withholding process successful"))
+	} else {
+		prompter.Println(colors.Red("Withholding process failed"))
+	}
+	prompter.Println("----------------------------------------")
+
+	// ask user to restart program else we exit
+	if user.AskRestart(prompter, "Would you want to enter a new income (Y/n): ") {
+		prompter.Println("Restarting program...")
+		StartWithholdingCalculator(prompter, cfg, user)
+	} else {
+		prompter.Println("Quitting withholding_calculator")
+	}
+}
+
+// CalculateWithholding determine the rates of withholding tax from the result of the tax calculated
+// The household rate is the tax divided by the income
+// For a couple the partner with the lowest income gets the rate of the tax on its own income
+// with the half of the extra shares, the other gets the rest of the tax of the household
+// returns the rates of the household and of each member
+func CalculateWithholding(result Result, user user.User, cfg *config.Config) Withholding {
+	var withholding = Withholding{
+		Rate:    getAverageRate(result.Tax, result.Income),
+		Incomes: [2]money.Money{user.Income, 0},
+	}
+	if user.IsInCouple {
+		withholding.Incomes = [2]money.Money{user.Income - user.PartnerIncome, user.PartnerIncome}
+	}
+
+	for index, income := range withholding.Incomes {
+		withholding.IndividualRates[index] = withholding.Rate
+		withholding.NeutralRates[index] = getNeutralRate(income.Mul(1, 12), cfg.GetTax().Withholding)
+	}
+
+	if !user.IsInCouple || user.PartnerIncome == 0 {
+		return withholding
+	}
+
+	// Find the member of the couple with the lowest income
+	var low, high = 1, 0
+	if withholding.Incomes[0] < withholding.Incomes[1] {
+		low, high = 0, 1
+	}
+
+	// Tax of the lowest income with the half of the extra shares
+	var lowShares = 1 + (result.Shares-2)/2
+	lowTax, _ := calculateTaxWithShares(withholding.Incomes[low], lowShares, cfg.GetTax().Tranches)
+	var lowRate = getAverageRate(lowTax.Round(), withholding.Incomes[low])
+
+	// Individualization is only applied if it's favorable to the lowest income
+	if lowRate >= withholding.Rate {
+		return withholding
+	}
+
+	// Rate in tenths of percent applied on the lowest income
+	var highTax = result.Tax - withholding.Incomes[low].Mul(int64(lowRate*10+0.5), 1000)
+	highTax = highTax.Max(money.ZERO)
+	withholding.IndividualRates[low] = lowRate
+	withholding.IndividualRates[high] = getAverageRate(highTax, withholding.Incomes[high])
+	withholding.IsIndividualized = true
+
+	return withholding
+}
+
+// getNeutralRate find the rate of the monthly income in the neutral grid
+// returns the rate in percent
+func getNeutralRate(monthlyIncome money.Money, grid []config.Tranche) float64 {
+	var income = monthlyIncome.Floor()
+	for _, tranche := range grid {
+		if income >= tranche.Min && income <= tranche.Max {
+			rate, _ := utils.ConvertPercentageToFloat64(tranche.Rate)
+			return rate
+		}
+	}
+	return 0
+}
+
+// showWithholdingResult show the rates of withholding tax for each member of the household
+func showWithholdingResult(w io.Writer, withholding Withholding, year int) {
+	table := tablewriter.NewWriter(w)
+	table.SetBorder(true)
+
+	// Setting header
+	var header = []string{"Member", "Income", "Monthly income", "Individual rate", "Neutral rate"}
+	table.SetHeader(header)
+
+	var members = []string{"You", "Partner"}
+	for index, income := range withholding.Incomes {
+		if index > 0 && income == 0 {
+			continue
+		}
+		table.Append([]string{
+			members[index],
+			fmt.Sprintf("%s €", income),
+			fmt.Sprintf("%s €", income.Mul(1, 12).Floor()),
+			fmt.Sprintf("%.1f %%", withholding.IndividualRates[index]),
+			fmt.Sprintf("%.1f %%", withholding.NeutralRates[index]),
+		})
+	}
+	table.SetFooter([]string{"", "", "", "Household rate", fmt.Sprintf("%.1f %%", withholding.Rate)})
+
+	fmt.Fprintln(w, colors.Yellow("\t\t\t Withholding tax \t\t\t"))
+	fmt.Fprintf(w, "Rates of withholding tax based on %s\n", colors.Teal(year))
+	if withholding.IsIndividualized {
+		fmt.Fprintln(w, "Rates are individualized between the members of the couple")
+	}
+	table.Render()
+}
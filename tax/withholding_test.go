@@ -0,0 +1,73 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package tax is the algorithm to calculate taxes
+package tax
+
+import (
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/user"
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd tax
+// $ go test -v
+
+// Calculate withholding rates for a single person with 30000 of income
+// 2922 of tax on 30000 gives 9.7% and 2500 by month gives 7.5% in the neutral grid
+func TestCalculateWithholdingForSinglePerson(t *testing.T) {
+	var user = user.User{Income: money.Euros(30000)}
+
+	result := CalculateTax(user, CONFIG)
+	withholding := CalculateWithholding(result, user, CONFIG)
+	t.Logf("Function result:\t%+v", withholding)
+
+	if withholding.Rate != 9.7 {
+		t.Errorf("Expected that the Rate %s should be equal to %s", colors.Red(9.7), colors.Red(withholding.Rate))
+	}
+	if withholding.NeutralRates[0] != 7.5 {
+		t.Errorf("Expected that the NeutralRate %s should be equal to %s", colors.Red(7.5), colors.Red(withholding.NeutralRates[0]))
+	}
+	if withholding.IsIndividualized {
+		t.Errorf("Expected that the rates should not be individualized")
+	}
+}
+
+// Calculate individualized rates for a couple with 2 children where the partner earns 15000 of 60000
+// The partner has no tax with 1.5 shares so the user gets all the tax: 3226 on 45000 gives 7.2%
+func TestCalculateWithholdingIndividualized(t *testing.T) {
+	var user = user.User{Income: money.Euros(60000), IsInCouple: true, Children: 2, PartnerIncome: money.Euros(15000)}
+
+	result := CalculateTax(user, CONFIG)
+	withholding := CalculateWithholding(result, user, CONFIG)
+	t.Logf("Function result:\t%+v", withholding)
+
+	expected := Withholding{
+		Rate:             5.4,
+		IsIndividualized: true,
+		Incomes:          [2]money.Money{money.Euros(45000), money.Euros(15000)},
+		IndividualRates:  [2]float64{7.2, 0},
+		NeutralRates:     [2]float64{13.8, 0},
+	}
+	t.Logf("Expected:\t\t%+v", expected)
+
+	if withholding != expected {
+		t.Errorf("Expected that the withholding %+v should be equal to %+v", expected, withholding)
+	}
+}
+
+// Individualized rates are not applied when they are not favorable to the lowest income
+func TestCalculateWithholdingNotIndividualized(t *testing.T) {
+	var user = user.User{Income: money.Euros(60000), IsInCouple: true, PartnerIncome: money.Euros(30000)}
+
+	result := CalculateTax(user, CONFIG)
+	withholding := CalculateWithholding(result, user, CONFIG)
+	t.Logf("Function result:\t%+v", withholding)
+
+	if withholding.IsIndividualized || withholding.IndividualRates[0] != withholding.Rate || withholding.IndividualRates[1] != withholding.Rate {
+		t.Errorf("Expected that the rates %+v should be equal to %s", withholding.IndividualRates, colors.Red(withholding.Rate))
+	}
+}
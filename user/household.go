@@ -0,0 +1,285 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package uses store function to interact with user
+package user
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/LucasNoga/corpos-christie/money"
+	"github.com/LucasNoga/corpos-christie/utils"
+)
+
+// Events of the year changing the declarations of a couple
+const (
+	EVENT_UNION      string = "union"      // Marriage or PACS: a joint declaration or separate declarations
+	EVENT_SEPARATION string = "separation" // Divorce or end of PACS: separate declarations only
+
+	// EVENT_DEATH is the death of a spouse which is not on the list of EVENTS: the law requires a joint declaration
+	// up to the death and a declaration of the survivor for the rest of the year, there is no choice to optimize
+	EVENT_DEATH string = "death"
+)
+
+// EVENTS is the list of events of the year changing the declarations of a couple
+var EVENTS = []string{EVENT_UNION, EVENT_SEPARATION}
+
+// ErrDeathEvent is returned for the event of the death of a spouse
+var ErrDeathEvent = errors.New("in the year of a death the law requires a joint declaration up to the death and a declaration of the survivor for the rest of the year, there is no choice of declarations")
+
+// YOUNG_CHILD_AGE is the age under which a dependent gives right to the childcare credit
+const YOUNG_CHILD_AGE int = 6
+
+// Incomes defines the taxable incomes of a declarant by category
+type Incomes struct {
+	Salaries money.Money // Salaries and wages after the deduction of professional expenses
+	Pensions money.Money // Pensions and annuities after their allowance
+	Business money.Money // Profits of businesses, professions and farms (BIC, BNC, BA)
+	Property money.Money // Net property incomes (revenus fonciers)
+}
+
+// Declarant defines a person of the household filing a tax declaration with its own incomes
+type Declarant struct {
+	Incomes    Incomes // Taxable incomes of the declarant by category
+	IsDisabled bool    // Holder of a disability card (case P or F)
+	IsVeteran  bool    // Veteran over 74 years old or holder of a military pension (case W or S)
+}
+
+// Dependent defines a child of the household
+type Dependent struct {
+	BirthYear     int  // Year of birth of the child, 0 if unknown
+	IsAlternating bool // Alternating custody (résidence alternée), the child counts for half
+	IsDisabled    bool // Holder of a disability card, an extra half share
+}
+
+// Household defines the declarants of a tax declaration with their dependents and their particular cases
+type Household struct {
+	Event      string      // Event of the year changing the declarations of a couple, empty if none
+	Declarants []Declarant // The declarant or the two declarants of a couple
+	Dependents []Dependent // Dependent children of the household
+	IsWidowed  bool        // Widowed declarant (case V) keeping the shares of a couple with dependents
+	IsIsolated bool        // Declarant living alone with dependents (case T)
+}
+
+// Total returns the sum of the incomes of all categories
+func (incomes Incomes) Total() money.Money {
+	return incomes.Salaries + incomes.Pensions + incomes.Business + incomes.Property
+}
+
+// GetIncome returns the taxable income of the declarant
+func (declarant Declarant) GetIncome() money.Money {
+	return declarant.Incomes.Total()
+}
+
+// IsYoungChild returns true if the child is under YOUNG_CHILD_AGE years old on January 1st of the year
+// of the incomes, the year before the year of the tax scale
+func (dependent Dependent) IsYoungChild(year int) bool {
+	return dependent.BirthYear > 0 && dependent.BirthYear >= year-1-YOUNG_CHILD_AGE
+}
+
+// GetIncome returns the taxable income of the household
+func (household Household) GetIncome() money.Money {
+	var income money.Money
+	for _, declarant := range household.Declarants {
+		income += declarant.GetIncome()
+	}
+	return income
+}
+
+// GetUser returns the user to calculate the tax of the household with the tax scale of the year
+// A single declarant with dependents not living alone is not an isolated parent
+// The disabled declarants and the veterans get a half share each, not cumulative for a declarant
+func (household Household) GetUser(year int) User {
+	var user = User{
+		Income:       household.GetIncome(),
+		IsInCouple:   len(household.Declarants) == 2,
+		Children:     len(household.Dependents),
+		Declarants:   append([]Declarant(nil), household.Declarants...),
+		Dependents:   append([]Dependent(nil), household.Dependents...),
+		IsCohabiting: len(household.Declarants) == 1 && !household.IsIsolated && !household.IsWidowed,
+		Cases:        Cases{IsWidowed: household.IsWidowed},
+	}
+	for _, declarant := range household.Declarants {
+		if declarant.IsDisabled || declarant.IsVeteran {
+			user.Cases.Invalids++
+		}
+	}
+	for _, dependent := range household.Dependents {
+		if dependent.IsYoungChild(year) {
+			user.Credits.YoungChildren++
+		}
+	}
+	return user
+}
+
+// Validate check the declarants, the dependents and the cases of the household
+// returns an error if a field is not valid
+func (household Household) Validate() error {
+	if household.Event == EVENT_DEATH {
+		return ErrDeathEvent
+	}
+	if household.Event != "" && !IsEvent(household.Event) {
+		return fmt.Errorf("unknown event '%s', expected %s", household.Event, strings.Join(EVENTS, ", "))
+	}
+	if len(household.Declarants) < 1 || len(household.Declarants) > 2 {
+		return fmt.Errorf("%d declarants, expected 1 or 2", len(household.Declarants))
+	}
+	if household.Event != "" && len(household.Declarants) != 2 {
+		return fmt.Errorf("the event '%s' needs 2 declarants", household.Event)
+	}
+	for index, declarant := range household.Declarants {
+		var incomes = []money.Money{declarant.Incomes.Salaries, declarant.Incomes.Pensions, declarant.Incomes.Business, declarant.Incomes.Property}
+		for _, income := range incomes {
+			if income < 0 {
+				return fmt.Errorf("the incomes of the declarant %d can't be negative", index+1)
+			}
+		}
+	}
+	if (household.IsWidowed || household.IsIsolated) && len(household.Declarants) != 1 {
+		return fmt.Errorf("a widowed or isolated declarant has to declare alone")
+	}
+	if household.IsWidowed && household.IsIsolated {
+		return fmt.Errorf("a widowed declarant keeps the shares of a couple and can't be an isolated parent")
+	}
+	if len(household.Dependents) > MAX_CHILDREN {
+		return fmt.Errorf("%d dependents, expected at most %d", len(household.Dependents), MAX_CHILDREN)
+	}
+	for index, dependent := range household.Dependents {
+		if dependent.BirthYear < 0 {
+			return fmt.Errorf("the birth year of the dependent %d can't be negative", index+1)
+		}
+	}
+	return nil
+}
+
+// IsEvent returns true if the event is on the list of EVENTS
+func IsEvent(event string) bool {
+	for _, value := range EVENTS {
+		if value == event {
+			return true
+		}
+	}
+	return false
+}
+
+// AskEvent asks the event of the year of the household and set it into household struct
+// the question is asked again while the event is not on the list
+func (household *Household) AskEvent(prompter *utils.Prompter, question string) error {
+	return prompter.Ask(question, func(input string) error {
+		if input == EVENT_DEATH {
+			return ErrDeathEvent
+		}
+		if !IsEvent(input) {
+			return fmt.Errorf("you have to answer by %s", strings.Join(EVENTS, ", "))
+		}
+		household.Event = input
+		return nil
+	})
+}
+
+// AskIncomes asks the incomes of each category of the declarant then its disability card and its veteran status
+// and set them into declarant struct, each income can be skipped
+func (declarant *Declarant) AskIncomes(prompter *utils.Prompter, name string) error {
+	var questions = []struct {
+		label string
+		value *money.Money
+	}{
+		{"Salaries after professional expenses", &declarant.Incomes.Salaries},
+		{"Pensions", &declarant.Incomes.Pensions},
+		{"Business profits (BIC, BNC, BA)", &declarant.Incomes.Business},
+		{"Property incomes", &declarant.Incomes.Property},
+	}
+
+	for _, question := range questions {
+		var value = question.value
+		err := prompter.Ask(fmt.Sprintf("    %s of the %s ? ", question.label, name), func(input string) error {
+			if input == "" {
+				return nil
+			}
+			amount, err := parseAmount(input)
+			if err != nil {
+				return err
+			}
+			*value = amount
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+	declarant.IsDisabled, err = prompter.AskYesNo(fmt.Sprintf("    Has the %s a disability card (Y/n) ? ", name))
+	if err != nil {
+		return err
+	}
+	declarant.IsVeteran, err = prompter.AskYesNo(fmt.Sprintf("    Is the %s a veteran over 74 or a military pensioner (Y/n) ? ", name))
+	return err
+}
+
+// AskDependents asks the number of dependents, MAX_CHILDREN at most, then the birth year, the custody
+// and the disability of each one and set them into household struct, the question is asked again while the answer is not valid
+func (household *Household) AskDependents(prompter *utils.Prompter, question string) error {
+	var count int
+	err := prompter.Ask(question, func(input string) error {
+		// user can skip the question
+		if input == "" {
+			return nil
+		}
+		number, err := parseCount(input)
+		if err != nil {
+			return err
+		}
+		count = number
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	household.Dependents = make([]Dependent, count)
+	for index := range household.Dependents {
+		var dependent = &household.Dependents[index]
+		err := prompter.Ask(fmt.Sprintf("    Birth year of the child %d ? ", index+1), func(input string) error {
+			if input == "" {
+				return nil
+			}
+			year, err := parseYear(input)
+			if err != nil {
+				return err
+			}
+			dependent.BirthYear = year
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if dependent.IsAlternating, err = prompter.AskYesNo("    Is the child in alternating custody (Y/n) ? "); err != nil {
+			return err
+		}
+		if dependent.IsDisabled, err = prompter.AskYesNo("    Has the child a disability card (Y/n) ? "); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AskCases asks if a single declarant is widowed, or lives alone with dependents when not widowed
+// and set them into household struct
+func (household *Household) AskCases(prompter *utils.Prompter) error {
+	household.IsWidowed, household.IsIsolated = false, false
+	if len(household.Declarants) != 1 {
+		return nil
+	}
+
+	var err error
+	if household.IsWidowed, err = prompter.AskYesNo("    Are you widowed (Y/n) ? "); err != nil {
+		return err
+	}
+	if !household.IsWidowed && len(household.Dependents) > 0 {
+		household.IsIsolated, err = prompter.AskYesNo("    Do you live alone with your children (Y/n) ? ")
+	}
+	return err
+}
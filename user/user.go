@@ -7,98 +7,281 @@ package user
 import (
 	"errors"
 	"fmt"
-	"log"
+	"io"
+	"strings"
 
+	"github.com/LucasNoga/corpos-christie/config"
+	"github.com/LucasNoga/corpos-christie/money"
 	"github.com/LucasNoga/corpos-christie/utils"
 	"github.com/LucasNoga/corpos-christie/utils/colors"
 )
 
+// MAX_CHILDREN is the largest number of children of a household
+const MAX_CHILDREN int = 20
+
 // User defines a the user of the program
 type User struct {
-	Income     int     // Income (Revenu imposable) of the user
-	Tax        float64 // Tax to pay for the user
-	Remainder  float64 // Money remind after tax paid
-	Shares     float64 // Shares (or Parts in french) is the family quotient base on if you are in couple and if you have children to adjust your taxes
-	IsInCouple bool    // User is he in couple or not
-	Children   int     // number of children of the user
+	Income     money.Money // Income (Revenu imposable) of the user
+	Tax        money.Money // Tax to pay for the user
+	Remainder  money.Money // Money remind after tax paid
+	Shares     float64     // Shares (or Parts in french) is the family quotient base on if you are in couple and if you have children to adjust your taxes
+	IsInCouple bool        // User is he in couple or not
+	Children   int         // number of children of the user
+
+	IsCohabiting bool        // User lives with a partner without a joint declaration, so he is not an isolated parent
+	Declarants   []Declarant // Details of the declarants (incomes by category, cases), empty if only the income is known
+	Dependents   []Dependent // Details of the children (custody, disability), ignored if they are not Children
+	Cases        Cases       // Particular cases of the declarants giving extra shares
+
+	PreviousIncomes [2]money.Money // Reference incomes (revenu fiscal de référence) of the two previous years to smooth the high income contribution
+	Credits         Credits        // Expenses giving right to tax reductions and tax credits
+	PartnerIncome   money.Money    // Income of the partner among the income of the couple to individualize withholding rates
+	Salary          Salary         // Gross salary of the user to convert into taxable income
+
+	MarginalRate   float64     // Marginal rate in percent (taux marginal d'imposition)
+	AverageRate    float64     // Average rate in percent of the tax paid on the income
+	NextIncomeCost money.Money // Tax to pay on the next config.NEXT_INCOME euros of income
+}
+
+// Salary defines the gross salary of the user to convert into taxable income
+type Salary struct {
+	Gross          money.Money // Gross annual salary (salaire brut)
+	IsExecutive    bool        // Executive status (cadre) to get the rate of social contributions
+	ActualExpenses money.Money // Actual professional expenses (frais réels) replacing the allowance if set
+}
+
+// Cases defines the particular cases of the declarants giving extra shares
+type Cases struct {
+	IsWidowed bool // Widowed declarant (case V) keeping the shares of a couple with dependents
+	Invalids  int  // Number of declarants holding a disability card or veterans (cases P, F, W, S)
+}
+
+// Credits defines the expenses of the user giving right to tax reductions and tax credits
+type Credits struct {
+	Donations         money.Money // Donations to general interest organisations
+	AidDonations      money.Money // Donations to organisations helping people in difficulty
+	HomeEmployment    money.Money // Expenses for the employment of a home help
+	ChildcareExpenses money.Money // Childcare expenses for children under 6
+	YoungChildren     int         // Number of children under 6 among the children
 }
 
 // AskIncome asks the income of the user to calculate tax and set it into user struct
-// if value is set returns true, otherwise false
-func (user *User) AskIncome() (bool, error) {
-	var input = utils.ReadValue()
-	income, err := utils.ConvertStringToInt(input)
+// the question is asked again while the income is not valid
+func (user *User) AskIncome(prompter *utils.Prompter, question string) error {
+	return prompter.Ask(question, func(input string) error {
+		income, err := parseAmount(input)
+		if err != nil {
+			return err
+		}
+		user.Income = income
+		return nil
+	})
+}
+
+// AskGrossSalary asks the gross annual salary of the user and set it into user struct
+// the question is asked again while the salary is not valid
+func (user *User) AskGrossSalary(prompter *utils.Prompter, question string) error {
+	return prompter.Ask(question, func(input string) error {
+		gross, err := parseAmount(input)
+		if err != nil {
+			return err
+		}
+		user.Salary.Gross = gross
+		return nil
+	})
+}
+
+// AskIsExecutive asks if the user has an executive status and set it into user struct
+func (user *User) AskIsExecutive(prompter *utils.Prompter, question string) error {
+	response, err := prompter.AskYesNo(question)
 	if err != nil {
-		log.Printf("Error: Tax income is not convertible in int, details: %v", err)
-		return false, err
+		return err
 	}
-	user.Income = income
-	return true, nil
+	user.Salary.IsExecutive = response
+	return nil
+}
+
+// AskActualExpenses asks the actual professional expenses of the user and set it into user struct
+// the question is asked again while the expenses are not valid
+func (user *User) AskActualExpenses(prompter *utils.Prompter, question string) error {
+	return prompter.Ask(question, func(input string) error {
+		// user can skip the question to keep the allowance
+		if input == "" {
+			user.Salary.ActualExpenses = money.ZERO
+			return nil
+		}
+
+		expenses, err := parseAmount(input)
+		if err != nil {
+			return err
+		}
+		user.Salary.ActualExpenses = expenses
+		return nil
+	})
 }
 
 // AskRemainder asks the remainder of the user to calculate reverse tax and set it into user struct
-// if value is set returns true, otherwise false
-func (user *User) AskRemainder() (bool, error) {
-	var input = utils.ReadValue()
-	remainder, err := utils.ConvertStringToInt(input)
-	if err != nil {
-		log.Printf("Error: Tax remainder is not convertible in int, details: %v", err)
-		return false, err
-	}
-	user.Remainder = float64(remainder)
-	return true, nil
+// the question is asked again while the remainder is not valid
+func (user *User) AskRemainder(prompter *utils.Prompter, question string) error {
+	return prompter.Ask(question, func(input string) error {
+		remainder, err := parseAmount(input)
+		if err != nil {
+			return err
+		}
+		user.Remainder = remainder
+		return nil
+	})
+}
+
+// AskPartnerIncome asks the income of the partner among the income of the couple and set it into user struct
+// the question is asked again while the income is not valid
+func (user *User) AskPartnerIncome(prompter *utils.Prompter, question string) error {
+	return prompter.Ask(question, func(input string) error {
+		// user can skip the question
+		if input == "" {
+			return nil
+		}
+
+		income, err := parseAmount(input)
+		if err != nil {
+			return err
+		}
+		if income > user.Income {
+			return errors.New("the income of the partner can't be greater than the income of the couple")
+		}
+		user.PartnerIncome = income
+		return nil
+	})
 }
 
 // AskIsInCouple asks if the user is in couple set it into user struct
-// returns response of the user
-func (user *User) AskIsInCouple() (bool, error) {
-	response, err := askYesNo()
+// the declarants, the particular cases and the cohabitation of a household described before are cleared
+func (user *User) AskIsInCouple(prompter *utils.Prompter, question string) error {
+	response, err := prompter.AskYesNo(question)
 	if err != nil {
-		return false, err
-	}
-	if response {
-		user.IsInCouple = true
-	} else {
-		user.IsInCouple = false
+		return err
 	}
-	return response, nil
+	user.IsInCouple = response
+	user.IsCohabiting = false
+	user.Declarants = nil
+	user.Cases = Cases{}
+	return nil
 }
 
 // AskHasChildren asks the number of children of the user and set it into user struct
-// if value is set returns true, otherwise false
-func (user *User) AskHasChildren() (bool, error) {
-	var input = utils.ReadValue()
+// the details of the children of a household described before are cleared
+// the question is asked again while the number is not valid
+func (user *User) AskHasChildren(prompter *utils.Prompter, question string) error {
+	return prompter.Ask(question, func(input string) error {
+		// user can skip the question
+		if input == "" {
+			return nil
+		}
+
+		children, err := parseCount(input)
+		if err != nil {
+			return err
+		}
+		user.Children = children
+		user.Dependents = nil
+		return nil
+	})
+}
+
+// AskPreviousIncomes asks the reference incomes of the two previous years and set it into user struct
+// the question is asked again while the incomes are not valid
+func (user *User) AskPreviousIncomes(prompter *utils.Prompter, question string) error {
+	return prompter.Ask(question, func(input string) error {
+		// user can skip the question
+		if input == "" {
+			return nil
+		}
+
+		var values = strings.Fields(input)
+		if len(values) != 2 {
+			return errors.New("you have to enter two incomes separated by a space")
+		}
 
-	// user can skip the question
-	if input == "" {
-		return true, nil
+		var incomes [2]money.Money
+		for i, value := range values {
+			income, err := parseAmount(value)
+			if err != nil {
+				return err
+			}
+			incomes[i] = income
+		}
+		user.PreviousIncomes = incomes
+		return nil
+	})
+}
+
+// AskCredits asks if the user has expenses giving right to tax reductions and tax credits
+// then asks each expense and set it into user struct, each question can be skipped
+// returns true if the user has expenses
+func (user *User) AskCredits(prompter *utils.Prompter, question string) (bool, error) {
+	response, err := prompter.AskYesNo(question)
+	if err != nil || !response {
+		return false, err
+	}
+
+	var questions = []struct {
+		label string
+		value *money.Money
+	}{
+		{"Donations to general interest organisations", &user.Credits.Donations},
+		{"Donations to organisations helping people in difficulty", &user.Credits.AidDonations},
+		{"Expenses for a home help employee", &user.Credits.HomeEmployment},
+		{"Childcare expenses for children under 6", &user.Credits.ChildcareExpenses},
+	}
+
+	for _, question := range questions {
+		var value = question.value
+		err := prompter.Ask(fmt.Sprintf("    %s ? ", question.label), func(input string) error {
+			if input == "" {
+				return nil
+			}
+			amount, err := parseAmount(input)
+			if err != nil {
+				return err
+			}
+			*value = amount
+			return nil
+		})
+		if err != nil {
+			return false, err
+		}
 	}
 
-	childrens, err := utils.ConvertStringToInt(input)
+	err = prompter.Ask("    Number of children under 6 ? ", func(input string) error {
+		if input == "" {
+			return nil
+		}
+		youngChildren, err := parseCount(input)
+		if err != nil {
+			return err
+		}
+		if youngChildren > user.Children {
+			return fmt.Errorf("the number of children under 6 can't be greater than the number of children %d", user.Children)
+		}
+		user.Credits.YoungChildren = youngChildren
+		return nil
+	})
 	if err != nil {
-		log.Printf("Error: Childrens value is not convertible in int, details: %v", err)
 		return false, err
 	}
-
-	user.Children = childrens
 	return true, nil
 }
 
 // AskTaxDetails asks to the user if he wants to see details of his taxes
 // returns true if wants otherwise false
-func (*User) AskTaxDetails() (bool, error) {
-	fmt.Print("Do you want to see tax details (Y/n) ? ")
-	response, err := askYesNo()
-	if err != nil {
-		return false, err
-	}
-	return response, nil
+func (*User) AskTaxDetails(prompter *utils.Prompter) (bool, error) {
+	return prompter.AskYesNo("Do you want to see tax details (Y/n) ? ")
 }
 
 // AskRestart asks the user if he wants to retry a calculation of tax
-// returns true if wants otherwise false
-func (*User) AskRestart() bool {
-	response, _ := askYesNo()
+// returns true if wants otherwise false, also when there is nothing more to read
+func (*User) AskRestart(prompter *utils.Prompter, question string) bool {
+	response, _ := prompter.AskYesNo(question)
 	return response
 }
 
@@ -107,36 +290,78 @@ func (user *User) GetShares() float64 {
 	return user.Shares
 }
 
+// GetReferenceIncome returns the reference income of the user (revenu fiscal de référence)
+func (user *User) GetReferenceIncome() money.Money {
+	return user.Income
+}
+
 // IsIsolated return bool if parent has children to raise alone
+// a widowed parent keeps the shares of a couple instead
 func (user *User) IsIsolated() bool {
-	return !user.IsInCouple && user.Children > 0
+	return !user.IsInCouple && !user.IsCohabiting && !user.Cases.IsWidowed && user.Children > 0
+}
+
+// HasDependents returns true if the details of each child are set
+func (user *User) HasDependents() bool {
+	return len(user.Dependents) > 0 && len(user.Dependents) == user.Children
 }
 
-// Show show details of the user struct
-func (user *User) Show() {
+// Show write details of the user struct into w
+func (user *User) Show(w io.Writer) {
 	var isInCouple = "No"
 	if user.IsInCouple {
 		isInCouple = "Yes"
 	}
-	fmt.Println(colors.Yellow("\tTax Results"))
-	fmt.Printf("Income:\t\t%s €\n", colors.Red(user.Income))
-	fmt.Printf("In couple:\t%s\n", colors.Red(isInCouple))
-	fmt.Printf("Children:\t%s\n", colors.Red(user.Children))
-	fmt.Printf("Shares:\t\t%s\n", colors.Red(user.Shares))
-	fmt.Printf("Tax:\t\t%s €\n", colors.Green(user.Tax))
-	fmt.Printf("Remainder:\t%s €\n", colors.Green(user.Remainder))
-}
-
-// askYesNo handle the interaction of the user if he has to answer by 'yes' or 'no'
-// returns true if the user say 'yes', false if he answered 'no'
-// returns an error if the seize is not interpretable
-func askYesNo() (bool, error) {
-	var input = utils.ReadValue()
-	if input == "Y" || input == "y" || input == "Yes" || input == "yes" {
-		return true, nil
-	} else if input == "" || input == "N" || input == "n" || input == "No" || input == "no" {
-		return false, nil
-	} else {
-		return false, errors.New("invalid response you have to answer by (yes/Yes/Y/y or no/No/N/n)")
+	fmt.Fprintln(w, colors.Yellow("\tTax Results"))
+	fmt.Fprintf(w, "Income:\t\t%s €\n", colors.Red(user.Income))
+	fmt.Fprintf(w, "In couple:\t%s\n", colors.Red(isInCouple))
+	fmt.Fprintf(w, "Children:\t%s\n", colors.Red(user.Children))
+	fmt.Fprintf(w, "Shares:\t\t%s\n", colors.Red(user.Shares))
+	fmt.Fprintf(w, "Tax:\t\t%s €\n", colors.Green(user.Tax))
+	fmt.Fprintf(w, "Remainder:\t%s €\n", colors.Green(user.Remainder))
+	fmt.Fprintf(w, "Marginal rate:\t%s %%\n", colors.Green(user.MarginalRate))
+	fmt.Fprintf(w, "Average rate:\t%s %%\n", colors.Green(user.AverageRate))
+	fmt.Fprintf(w, "Next %d €:\t%s € of tax\n", config.NEXT_INCOME, colors.Green(user.NextIncomeCost))
+}
+
+// parseAmount convert the input into an amount in euros
+// returns an error if the input is not an amount or is negative
+func parseAmount(input string) (money.Money, error) {
+	amount, err := money.Parse(input)
+	if err != nil {
+		return money.ZERO, fmt.Errorf("'%s' is not an amount", input)
+	}
+	if amount < 0 {
+		return money.ZERO, fmt.Errorf("'%s' can't be negative", input)
+	}
+	return amount, nil
+}
+
+// parseCount convert the input into a number of persons
+// returns an error if the input is not a number, is negative or is over MAX_CHILDREN
+func parseCount(input string) (int, error) {
+	count, err := utils.ConvertStringToInt(input)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a number", input)
+	}
+	if count < 0 {
+		return 0, fmt.Errorf("'%s' can't be negative", input)
+	}
+	if count > MAX_CHILDREN {
+		return 0, fmt.Errorf("'%s' can't be over %d", input, MAX_CHILDREN)
+	}
+	return count, nil
+}
+
+// parseYear convert the input into a year
+// returns an error if the input is not a number or is not positive
+func parseYear(input string) (int, error) {
+	year, err := utils.ConvertStringToInt(input)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a number", input)
+	}
+	if year <= 0 {
+		return 0, fmt.Errorf("'%s' is not a year", input)
 	}
+	return year, nil
 }
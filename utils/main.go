@@ -5,9 +5,7 @@
 package utils
 
 import (
-	"bufio"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -18,13 +16,6 @@ const (
 	DEFAULT_PADDING = 10
 )
 
-// ReadValue read input from terminal and returns its value
-func ReadValue() string {
-	scanner := bufio.NewScanner(os.Stdin)
-	scanner.Scan()
-	return scanner.Text()
-}
-
 // ConvertStringToInt convert str string to an int and returns it
 // return an error if the string is not convertible into an int
 func ConvertStringToInt(str string) (int, error) {
@@ -51,11 +42,10 @@ func ConvertIntToString(v int) string {
 	return fmt.Sprintf("%d", v)
 }
 
-// ConvertPercentageToFloat64 convert str which is string percentage like 5% into 5
+// ConvertPercentageToFloat64 convert str which is string percentage like 5% or 45.25% into 5 or 45.25
 func ConvertPercentageToFloat64(str string) (float64, error) {
 	var s = strings.TrimSuffix(str, "%")
-	i, err := strconv.Atoi(s)
-	f := float64(i)
+	f, err := strconv.ParseFloat(s, 64)
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,96 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package utils define functions to multiple uses
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// Prompter reads the answers of the user and writes the questions and the results of the console
+// The same reader is kept between the questions so the input buffered is never lost
+type Prompter struct {
+	scanner *bufio.Scanner // Reader of the answers line by line
+	out     io.Writer      // Writer of the questions and the results
+}
+
+// NewPrompter create a prompter reading the answers from in and writing into out
+// (ex: os.Stdin and os.Stdout, or a script and a buffer in tests)
+func NewPrompter(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{scanner: bufio.NewScanner(in), out: out}
+}
+
+// Writer returns the writer of the prompter to write tables or results
+func (prompter *Prompter) Writer() io.Writer {
+	return prompter.out
+}
+
+// Print write the values like fmt.Print
+func (prompter *Prompter) Print(a ...interface{}) {
+	fmt.Fprint(prompter.out, a...)
+}
+
+// Printf write the values like fmt.Printf
+func (prompter *Prompter) Printf(format string, a ...interface{}) {
+	fmt.Fprintf(prompter.out, format, a...)
+}
+
+// Println write the values like fmt.Println
+func (prompter *Prompter) Println(a ...interface{}) {
+	fmt.Fprintln(prompter.out, a...)
+}
+
+// ReadValue read the next line of input without the spaces around it
+// returns io.EOF when there is nothing more to read
+func (prompter *Prompter) ReadValue() (string, error) {
+	if !prompter.scanner.Scan() {
+		if err := prompter.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return strings.TrimSpace(prompter.scanner.Text()), nil
+}
+
+// Ask write the question and read the answer given to parse
+// while parse returns an error the error is shown and the question is asked again
+// returns an error only if the input can't be read anymore (ex: io.EOF)
+func (prompter *Prompter) Ask(question string, parse func(input string) error) error {
+	for {
+		prompter.Print(question)
+		input, err := prompter.ReadValue()
+		if err != nil {
+			return err
+		}
+		if err := parse(input); err != nil {
+			prompter.Printf("%s %v. %s\n", colors.Red("Invalid response:"), err, colors.Red("Try again"))
+			continue
+		}
+		return nil
+	}
+}
+
+// AskYesNo ask a question to answer by 'yes' or 'no', an empty answer is 'no'
+// returns true if the user say 'yes', false if he answered 'no'
+func (prompter *Prompter) AskYesNo(question string) (bool, error) {
+	var response bool
+	err := prompter.Ask(question, func(input string) error {
+		switch input {
+		case "Y", "y", "Yes", "yes":
+			response = true
+		case "", "N", "n", "No", "no":
+			response = false
+		default:
+			return errors.New("you have to answer by (yes/Yes/Y/y or no/No/N/n)")
+		}
+		return nil
+	})
+	return response, err
+}
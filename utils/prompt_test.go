@@ -0,0 +1,70 @@
+// Copyright 2016 The corpos-christie author
+// Licensed under GPLv3.
+
+// Package utils define functions to multiple uses
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/LucasNoga/corpos-christie/utils/colors"
+)
+
+// For testing
+// $ cd utils
+// $ go test -v
+
+// The values are read line by line from the same input without losing the buffered lines
+func TestPrompterReadValue(t *testing.T) {
+	var prompter = NewPrompter(strings.NewReader("first\n  second  \r\nthird"), io.Discard)
+
+	for _, expected := range []string{"first", "second", "third"} {
+		if value, err := prompter.ReadValue(); err != nil || value != expected {
+			t.Errorf("Expected the value %s, got %s %v", colors.Red(expected), colors.Red(value), err)
+		}
+	}
+	if _, err := prompter.ReadValue(); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected the error %s, got %s", colors.Red(io.EOF), colors.Red(err))
+	}
+}
+
+// The question is asked again while the answer is not valid
+func TestPrompterAsk(t *testing.T) {
+	var out bytes.Buffer
+	var prompter = NewPrompter(strings.NewReader("abc\n-1\n12\n"), &out)
+
+	var value int
+	err := prompter.Ask("Number ? ", func(input string) error {
+		number, err := ConvertStringToInt(input)
+		if err != nil || number < 0 {
+			return errors.New("not a positive number")
+		}
+		value = number
+		return nil
+	})
+
+	if err != nil || value != 12 {
+		t.Errorf("Expected the value %s, got %s %v", colors.Red(12), colors.Red(value), err)
+	}
+	if count := strings.Count(out.String(), "Number ? "); count != 3 {
+		t.Errorf("Expected the question asked %s times, got %s", colors.Red(3), colors.Red(count))
+	}
+}
+
+// An answer by yes or no, empty is no, and io.EOF when there is nothing to read
+func TestPrompterAskYesNo(t *testing.T) {
+	var prompter = NewPrompter(strings.NewReader("maybe\ny\n\nNo\n"), io.Discard)
+
+	for _, expected := range []bool{true, false, false} {
+		if response, err := prompter.AskYesNo("Continue ? "); err != nil || response != expected {
+			t.Errorf("Expected the response %s, got %s %v", colors.Red(expected), colors.Red(response), err)
+		}
+	}
+	if _, err := prompter.AskYesNo("Continue ? "); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected the error %s, got %s", colors.Red(io.EOF), colors.Red(err))
+	}
+}
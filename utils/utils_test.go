@@ -42,6 +42,21 @@ func TestConvertPercentageToFloat64(t *testing.T) {
 	}
 }
 
+// Test string decimal percentage conversion to float64
+func TestConvertDecimalPercentageToFloat64(t *testing.T) {
+	var stringRef = "45.25%"
+	var expected = 45.25
+
+	val, err := ConvertPercentageToFloat64(stringRef)
+	t.Logf("Value converted %f", val)
+
+	if err != nil {
+		t.Errorf("Impossible to convert this string %s, err: %v", stringRef, err)
+	} else if val != expected {
+		t.Errorf("Value '%f' is not the same as ref '%s'", val, stringRef)
+	}
+}
+
 // Test if function return the maxLength among this item
 func TestMaxLength(t *testing.T) {
 	var longItem = "test max length"